@@ -0,0 +1,154 @@
+package api
+
+import (
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// AdminUser 是管理员看到的用户信息
+type AdminUser struct {
+	User
+	DisabledAt *time.Time `json:"disabled_at"`
+	// InviteID 为注册时使用的邀请码
+	InviteID *uuid.UUID `json:"invite_id"`
+	// Quota 为单独设置的配额，未设置的项使用角色的默认配额
+	Quota Quota `json:"quota"`
+}
+
+func NewAdminUser(u db.User) AdminUser {
+	return AdminUser{
+		User:       NewUser(u),
+		DisabledAt: nullTime(u.DisabledAt),
+		InviteID:   nullUUID(u.InviteID),
+		Quota:      NewUserQuotaOverride(u),
+	}
+}
+
+// AdminUserListItem 是用户列表项，附带订阅源数、关注数和最近活动时间
+type AdminUserListItem struct {
+	AdminUser
+	FeedsCount   int64 `json:"feeds_count"`
+	FollowsCount int64 `json:"follows_count"`
+	// LastSeenAt 为最近一次使用登录会话的时间，只使用 API Key 的用户为空
+	LastSeenAt *time.Time `json:"last_seen_at"`
+}
+
+func NewAdminUserListItem(row db.AdminListUsersRow) AdminUserListItem {
+	return AdminUserListItem{
+		AdminUser: NewAdminUser(db.User{
+			ID:            row.ID,
+			Username:      row.Username,
+			CreatedAt:     row.CreatedAt,
+			UpdatedAt:     row.UpdatedAt,
+			FeedTokenHash: row.FeedTokenHash,
+			FeverApiKey:   row.FeverApiKey,
+			DisplayName:   row.DisplayName,
+			Email:         row.Email,
+			Role:          row.Role,
+			DisabledAt:    row.DisabledAt,
+			InviteID:      row.InviteID,
+			MaxFeeds:      row.MaxFeeds,
+			MaxFollows:    row.MaxFollows,
+			MaxWebhooks:   row.MaxWebhooks,
+		}),
+		FeedsCount:   row.FeedsCount,
+		FollowsCount: row.FollowsCount,
+		LastSeenAt:   nullTime(row.LastSeenAt),
+	}
+}
+
+// AdminFeed 是管理员看到的订阅源，附带所有者用户名和关注数
+type AdminFeed struct {
+	Feed
+	OwnerUsername string `json:"owner_username"`
+	FollowsCount  int64  `json:"follows_count"`
+}
+
+func NewAdminFeed(row db.AdminListFeedsRow) AdminFeed {
+	return AdminFeed{
+		Feed: NewFeed(db.Feed{
+			ID:                   row.ID,
+			Name:                 row.Name,
+			Url:                  row.Url,
+			CreatedAt:            row.CreatedAt,
+			UpdatedAt:            row.UpdatedAt,
+			UserID:               row.UserID,
+			LastFetchedAt:        row.LastFetchedAt,
+			Description:          row.Description,
+			Language:             row.Language,
+			Link:                 row.Link,
+			Category:             row.Category,
+			LastFetchSucceededAt: row.LastFetchSucceededAt,
+			LastFetchError:       row.LastFetchError,
+			FetchErrorCount:      row.FetchErrorCount,
+			RefetchRequestedAt:   row.RefetchRequestedAt,
+		}),
+		OwnerUsername: row.OwnerUsername,
+		FollowsCount:  row.FollowsCount,
+	}
+}
+
+// ScraperHealth 是全部订阅源的抓取状态汇总，Unhealthy 为连续失败次数最多的订阅源
+type ScraperHealth struct {
+	Pending         int64       `json:"pending"`
+	Healthy         int64       `json:"healthy"`
+	Failing         int64       `json:"failing"`
+	Dead            int64       `json:"dead"`
+	FetchedLastHour int64       `json:"fetched_last_hour"`
+	OldestFetchedAt *time.Time  `json:"oldest_fetched_at"`
+	NewestFetchedAt *time.Time  `json:"newest_fetched_at"`
+	Unhealthy       []AdminFeed `json:"unhealthy"`
+}
+
+func NewScraperHealth(row db.GetScraperHealthRow, unhealthy []db.AdminListFeedsRow) ScraperHealth {
+	return ScraperHealth{
+		Pending:         row.Pending,
+		Healthy:         row.Healthy,
+		Failing:         row.Failing,
+		Dead:            row.Dead,
+		FetchedLastHour: row.FetchedRecently,
+		OldestFetchedAt: nullTime(row.OldestFetchedAt),
+		NewestFetchedAt: nullTime(row.NewestFetchedAt),
+		Unhealthy:       List(unhealthy, NewAdminFeed),
+	}
+}
+
+// SystemStats 是系统统计，Last24Hours 中的数字只统计最近 24 小时
+type SystemStats struct {
+	Users             int64 `json:"users"`
+	DisabledUsers     int64 `json:"disabled_users"`
+	Admins            int64 `json:"admins"`
+	Feeds             int64 `json:"feeds"`
+	Follows           int64 `json:"follows"`
+	Posts             int64 `json:"posts"`
+	ActiveSessions    int64 `json:"active_sessions"`
+	APIKeys           int64 `json:"api_keys"`
+	Webhooks          int64 `json:"webhooks"`
+	PendingDeliveries int64 `json:"pending_deliveries"`
+	Last24Hours       struct {
+		NewUsers    int64 `json:"new_users"`
+		ActiveUsers int64 `json:"active_users"`
+		NewPosts    int64 `json:"new_posts"`
+	} `json:"last_24_hours"`
+}
+
+func NewSystemStats(row db.GetSystemStatsRow) SystemStats {
+	stats := SystemStats{
+		Users:             row.Users,
+		DisabledUsers:     row.DisabledUsers,
+		Admins:            row.Admins,
+		Feeds:             row.Feeds,
+		Follows:           row.Follows,
+		Posts:             row.Posts,
+		ActiveSessions:    row.ActiveSessions,
+		APIKeys:           row.ApiKeys,
+		Webhooks:          row.Webhooks,
+		PendingDeliveries: row.PendingDeliveries,
+	}
+	stats.Last24Hours.NewUsers = row.NewUsers
+	stats.Last24Hours.ActiveUsers = row.ActiveUsers
+	stats.Last24Hours.NewPosts = row.NewPosts
+	return stats
+}
@@ -0,0 +1,64 @@
+// Package api 定义 HTTP 接口对外返回的数据结构
+// 字段统一使用 snake_case，可空值序列化为 null，不包含密码哈希、令牌等敏感字段
+package api
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Envelope 是除协议兼容接口外所有成功响应的外层结构
+type Envelope struct {
+	Data any `json:"data"`
+}
+
+// Error 是错误响应的结构
+type Error struct {
+	Error string `json:"error"`
+}
+
+// List 把查询结果逐条转换为接口结构，空结果返回 [] 而不是 null
+func List[T, R any](rows []T, convert func(T) R) []R {
+	list := make([]R, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, convert(row))
+	}
+	return list
+}
+
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullInt32(n sql.NullInt32) *int32 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int32
+}
+
+func nullUUID(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+func stringSlice(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// AuditEvent 是审计日志记录，Details 为各动作附带的信息
+type AuditEvent struct {
+	ID            uuid.UUID       `json:"id"`
+	ActorID       *uuid.UUID      `json:"actor_id"`
+	ActorUsername *string         `json:"actor_username"`
+	Action        string          `json:"action"`
+	TargetType    *string         `json:"target_type"`
+	TargetID      *uuid.UUID      `json:"target_id"`
+	Details       json.RawMessage `json:"details"`
+	IP            string          `json:"ip"`
+	UserAgent     string          `json:"user_agent"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+func NewAuditEvent(row db.ListAuditEventsRow) AuditEvent {
+	details := row.Details
+	if len(details) == 0 {
+		details = json.RawMessage("{}")
+	}
+	return AuditEvent{
+		ID:            row.ID,
+		ActorID:       nullUUID(row.ActorID),
+		ActorUsername: nullString(row.ActorUsername),
+		Action:        row.Action,
+		TargetType:    nullString(row.TargetType),
+		TargetID:      nullUUID(row.TargetID),
+		Details:       details,
+		IP:            row.Ip,
+		UserAgent:     row.UserAgent,
+		CreatedAt:     row.CreatedAt,
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// DigestSettings 是邮件摘要设置，退订令牌只出现在邮件中
+type DigestSettings struct {
+	Email      string      `json:"email"`
+	Frequency  string      `json:"frequency"`
+	SendHour   int32       `json:"send_hour"`
+	Weekday    int32       `json:"weekday"`
+	Timezone   string      `json:"timezone"`
+	FolderIDs  []uuid.UUID `json:"folder_ids"`
+	Enabled    bool        `json:"enabled"`
+	LastSentAt *time.Time  `json:"last_sent_at"`
+	NextSendAt time.Time   `json:"next_send_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+func NewDigestSettings(s db.DigestSetting) DigestSettings {
+	folderIDs := s.FolderIds
+	if folderIDs == nil {
+		folderIDs = []uuid.UUID{}
+	}
+	return DigestSettings{
+		Email:      s.Email,
+		Frequency:  s.Frequency,
+		SendHour:   s.SendHour,
+		Weekday:    s.Weekday,
+		Timezone:   s.Timezone,
+		FolderIDs:  folderIDs,
+		Enabled:    s.Enabled,
+		LastSentAt: nullTime(s.LastSentAt),
+		NextSendAt: s.NextSendAt,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+	}
+}
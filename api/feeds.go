@@ -0,0 +1,251 @@
+package api
+
+import (
+	"math"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/rss"
+	"github.com/google/uuid"
+)
+
+// Feed 是订阅源的公开信息，Health 由抓取状态计算得出
+type Feed struct {
+	ID                   uuid.UUID  `json:"id"`
+	Name                 string     `json:"name"`
+	URL                  string     `json:"url"`
+	OwnerID              uuid.UUID  `json:"owner_id"`
+	Description          *string    `json:"description"`
+	Language             *string    `json:"language"`
+	Link                 *string    `json:"link"`
+	Category             *string    `json:"category"`
+	Health               string     `json:"health"`
+	LastFetchedAt        *time.Time `json:"last_fetched_at"`
+	LastFetchSucceededAt *time.Time `json:"last_fetch_succeeded_at"`
+	LastFetchError       *string    `json:"last_fetch_error"`
+	FetchErrorCount      int32      `json:"fetch_error_count"`
+	RefetchRequestedAt   *time.Time `json:"refetch_requested_at"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+func NewFeed(f db.Feed) Feed {
+	return Feed{
+		ID:                   f.ID,
+		Name:                 f.Name,
+		URL:                  f.Url,
+		OwnerID:              f.UserID,
+		Description:          nullString(f.Description),
+		Language:             nullString(f.Language),
+		Link:                 nullString(f.Link),
+		Category:             nullString(f.Category),
+		Health:               rss.FeedHealth(f.LastFetchedAt, f.FetchErrorCount),
+		LastFetchedAt:        nullTime(f.LastFetchedAt),
+		LastFetchSucceededAt: nullTime(f.LastFetchSucceededAt),
+		LastFetchError:       nullString(f.LastFetchError),
+		FetchErrorCount:      f.FetchErrorCount,
+		RefetchRequestedAt:   nullTime(f.RefetchRequestedAt),
+		CreatedAt:            f.CreatedAt,
+		UpdatedAt:            f.UpdatedAt,
+	}
+}
+
+// FeedListItem 是订阅源广场的列表项
+type FeedListItem struct {
+	Feed
+	FollowsCount int64      `json:"follows_count"`
+	LastPostAt   *time.Time `json:"last_post_at"`
+	// IsFollowing 包括通过所在团队关注的订阅源，未登录时始终为 false
+	IsFollowing bool `json:"is_following"`
+}
+
+func NewFeedListItem(row db.ListFeedsRow) FeedListItem {
+	return FeedListItem{
+		Feed: NewFeed(db.Feed{
+			ID:                   row.ID,
+			Name:                 row.Name,
+			Url:                  row.Url,
+			CreatedAt:            row.CreatedAt,
+			UpdatedAt:            row.UpdatedAt,
+			UserID:               row.UserID,
+			LastFetchedAt:        row.LastFetchedAt,
+			Description:          row.Description,
+			Language:             row.Language,
+			Link:                 row.Link,
+			Category:             row.Category,
+			LastFetchSucceededAt: row.LastFetchSucceededAt,
+			LastFetchError:       row.LastFetchError,
+			FetchErrorCount:      row.FetchErrorCount,
+			RefetchRequestedAt:   row.RefetchRequestedAt,
+		}),
+		FollowsCount: row.FollowsCount,
+		LastPostAt:   nullTime(row.LastPostAt),
+		IsFollowing:  row.IsFollowing,
+	}
+}
+
+// FeedDetail 是订阅源详情：关注数、发文频率和最近的文章
+type FeedDetail struct {
+	Feed
+	FollowsCount    int64 `json:"follows_count"`
+	PostsCount      int64 `json:"posts_count"`
+	PostsLast30Days int64 `json:"posts_last_30_days"`
+	PostsLast90Days int64 `json:"posts_last_90_days"`
+	// 近 30/90 天平均每周发文数
+	PostsPerWeek30Days float64    `json:"posts_per_week_30_days"`
+	PostsPerWeek90Days float64    `json:"posts_per_week_90_days"`
+	LastPostAt         *time.Time `json:"last_post_at"`
+	IsFollowing        bool       `json:"is_following"`
+	RecentPosts        []Post     `json:"recent_posts"`
+}
+
+func NewFeedDetail(row db.GetFeedDetailRow, posts []db.GetRecentPostsByFeedRow) FeedDetail {
+	return FeedDetail{
+		Feed: NewFeed(db.Feed{
+			ID:                   row.ID,
+			Name:                 row.Name,
+			Url:                  row.Url,
+			CreatedAt:            row.CreatedAt,
+			UpdatedAt:            row.UpdatedAt,
+			UserID:               row.UserID,
+			LastFetchedAt:        row.LastFetchedAt,
+			Description:          row.Description,
+			Language:             row.Language,
+			Link:                 row.Link,
+			Category:             row.Category,
+			LastFetchSucceededAt: row.LastFetchSucceededAt,
+			LastFetchError:       row.LastFetchError,
+			FetchErrorCount:      row.FetchErrorCount,
+			RefetchRequestedAt:   row.RefetchRequestedAt,
+		}),
+		FollowsCount:       row.FollowsCount,
+		PostsCount:         row.PostsCount,
+		PostsLast30Days:    row.PostsLast30Days,
+		PostsLast90Days:    row.PostsLast90Days,
+		PostsPerWeek30Days: math.Round(float64(row.PostsLast30Days)/30*7*10) / 10,
+		PostsPerWeek90Days: math.Round(float64(row.PostsLast90Days)/90*7*10) / 10,
+		LastPostAt:         nullTime(row.LastPostAt),
+		IsFollowing:        row.IsFollowing,
+		RecentPosts:        List(posts, NewRecentPost),
+	}
+}
+
+// RecommendedFeed 是推荐的订阅源及其得分
+type RecommendedFeed struct {
+	Feed
+	FollowsCount int64 `json:"follows_count"`
+	// CoFollows 与用户已关注的订阅源共同被关注的次数
+	CoFollows          int64   `json:"co_follows"`
+	CollaborativeScore float64 `json:"collaborative_score"`
+	SimilarityScore    float64 `json:"similarity_score"`
+	Score              float64 `json:"score"`
+}
+
+func NewRecommendedFeed(row db.GetRecommendedFeedsRow) RecommendedFeed {
+	return RecommendedFeed{
+		Feed: NewFeed(db.Feed{
+			ID:                   row.ID,
+			Name:                 row.Name,
+			Url:                  row.Url,
+			CreatedAt:            row.CreatedAt,
+			UpdatedAt:            row.UpdatedAt,
+			UserID:               row.UserID,
+			LastFetchedAt:        row.LastFetchedAt,
+			Description:          row.Description,
+			Language:             row.Language,
+			Link:                 row.Link,
+			Category:             row.Category,
+			LastFetchSucceededAt: row.LastFetchSucceededAt,
+			LastFetchError:       row.LastFetchError,
+			FetchErrorCount:      row.FetchErrorCount,
+			RefetchRequestedAt:   row.RefetchRequestedAt,
+		}),
+		FollowsCount:       row.FollowsCount,
+		CoFollows:          row.CoFollows,
+		CollaborativeScore: row.CollaborativeScore,
+		SimilarityScore:    row.SimilarityScore,
+		Score:              row.Score,
+	}
+}
+
+// FeedFollow 是一条关注记录
+type FeedFollow struct {
+	ID        uuid.UUID `json:"id"`
+	FeedID    uuid.UUID `json:"feed_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewFeedFollow(f db.FeedFollow) FeedFollow {
+	return FeedFollow{
+		ID:        f.ID,
+		FeedID:    f.FeedID,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
+
+// FollowedFeed 是关注列表项，附带订阅源名称和地址
+type FollowedFeed struct {
+	FeedFollow
+	FeedName string `json:"feed_name"`
+	FeedURL  string `json:"feed_url"`
+}
+
+func NewFollowedFeed(row db.GetFeedFollowsByUserIDRow) FollowedFeed {
+	return FollowedFeed{
+		FeedFollow: FeedFollow{
+			ID:        row.ID,
+			FeedID:    row.FeedID,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		},
+		FeedName: row.FeedName,
+		FeedURL:  row.FeedUrl,
+	}
+}
+
+// DeleteFeedResult 删除订阅源的结果，仍有关注者时所有权转给 TransferredTo
+type DeleteFeedResult struct {
+	Deleted       bool       `json:"deleted"`
+	TransferredTo *uuid.UUID `json:"transferred_to"`
+}
+
+// FeedTransfer 是待接收人接受的订阅源转让请求
+type FeedTransfer struct {
+	FeedID     uuid.UUID `json:"feed_id"`
+	FromUserID uuid.UUID `json:"from_user_id"`
+	ToUserID   uuid.UUID `json:"to_user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewFeedTransfer(t db.FeedTransfer) FeedTransfer {
+	return FeedTransfer{
+		FeedID:     t.FeedID,
+		FromUserID: t.FromUserID,
+		ToUserID:   t.ToUserID,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+// IncomingFeedTransfer 是收到的转让请求，附带订阅源和发起人信息
+type IncomingFeedTransfer struct {
+	FeedTransfer
+	FeedName     string `json:"feed_name"`
+	FeedURL      string `json:"feed_url"`
+	FromUsername string `json:"from_username"`
+}
+
+func NewIncomingFeedTransfer(row db.GetIncomingFeedTransfersRow) IncomingFeedTransfer {
+	return IncomingFeedTransfer{
+		FeedTransfer: NewFeedTransfer(db.FeedTransfer{
+			FeedID:     row.FeedID,
+			FromUserID: row.FromUserID,
+			ToUserID:   row.ToUserID,
+			CreatedAt:  row.CreatedAt,
+		}),
+		FeedName:     row.FeedName,
+		FeedURL:      row.FeedUrl,
+		FromUsername: row.FromUsername,
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// Folder 是用户的文件夹
+type Folder struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Position  int32     `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewFolder(f db.Folder) Folder {
+	return Folder{
+		ID:        f.ID,
+		Name:      f.Name,
+		Position:  f.Position,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
+
+// FolderWithFeeds 是文件夹列表项，附带其中的订阅源和未读数
+type FolderWithFeeds struct {
+	Folder
+	UnreadCount int64            `json:"unread_count"`
+	Feeds       []FolderFeedItem `json:"feeds"`
+}
+
+func NewFolderWithFeeds(row db.GetFoldersByUserIDRow, feeds []db.GetFolderFeedsByUserIDRow) FolderWithFeeds {
+	return FolderWithFeeds{
+		Folder: Folder{
+			ID:        row.ID,
+			Name:      row.Name,
+			Position:  row.Position,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		},
+		UnreadCount: row.UnreadCount,
+		Feeds:       List(feeds, NewFolderFeedItem),
+	}
+}
+
+// FolderFeedItem 是文件夹中的订阅源
+type FolderFeedItem struct {
+	FeedID      uuid.UUID `json:"feed_id"`
+	FeedName    string    `json:"feed_name"`
+	FeedURL     string    `json:"feed_url"`
+	Position    int32     `json:"position"`
+	UnreadCount int64     `json:"unread_count"`
+}
+
+func NewFolderFeedItem(row db.GetFolderFeedsByUserIDRow) FolderFeedItem {
+	return FolderFeedItem{
+		FeedID:      row.FeedID,
+		FeedName:    row.FeedName,
+		FeedURL:     row.FeedUrl,
+		Position:    row.Position,
+		UnreadCount: row.UnreadCount,
+	}
+}
+
+// FolderFeed 是订阅源加入文件夹的记录
+type FolderFeed struct {
+	FolderID     uuid.UUID `json:"folder_id"`
+	FeedFollowID uuid.UUID `json:"feed_follow_id"`
+	Position     int32     `json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func NewFolderFeed(f db.FolderFeed) FolderFeed {
+	return FolderFeed{
+		FolderID:     f.FolderID,
+		FeedFollowID: f.FeedFollowID,
+		Position:     f.Position,
+		CreatedAt:    f.CreatedAt,
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// Invite 不包含哈希，Code 明文只在创建时返回
+type Invite struct {
+	ID                uuid.UUID  `json:"id"`
+	Prefix            string     `json:"prefix"`
+	Note              string     `json:"note"`
+	MaxUses           int32      `json:"max_uses"`
+	Uses              int32      `json:"uses"`
+	CreatedBy         *uuid.UUID `json:"created_by"`
+	CreatedByUsername *string    `json:"created_by_username,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	Code              string     `json:"code,omitempty"`
+}
+
+func NewInvite(i db.Invite) Invite {
+	return Invite{
+		ID:        i.ID,
+		Prefix:    i.Prefix,
+		Note:      i.Note,
+		MaxUses:   i.MaxUses,
+		Uses:      i.Uses,
+		CreatedBy: nullUUID(i.CreatedBy),
+		CreatedAt: i.CreatedAt,
+		ExpiresAt: nullTime(i.ExpiresAt),
+		RevokedAt: nullTime(i.RevokedAt),
+	}
+}
+
+func NewInviteListItem(row db.ListInvitesRow) Invite {
+	invite := NewInvite(db.Invite{
+		ID:        row.ID,
+		CodeHash:  row.CodeHash,
+		Prefix:    row.Prefix,
+		Note:      row.Note,
+		MaxUses:   row.MaxUses,
+		Uses:      row.Uses,
+		CreatedBy: row.CreatedBy,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: row.ExpiresAt,
+		RevokedAt: row.RevokedAt,
+	})
+	invite.CreatedByUsername = nullString(row.CreatedByUsername)
+	return invite
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// Post 是文章的公开信息，Description 为订阅源提供的原始 HTML
+type Post struct {
+	ID          uuid.UUID `json:"id"`
+	FeedID      uuid.UUID `json:"feed_id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Description *string   `json:"description"`
+	PublishedAt time.Time `json:"published_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func NewRecentPost(row db.GetRecentPostsByFeedRow) Post {
+	return Post{
+		ID:          row.ID,
+		FeedID:      row.FeedID,
+		Title:       row.Title,
+		URL:         row.Url,
+		Description: nullString(row.Description),
+		PublishedAt: row.PublishedAt,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+// TimelinePost 是用户时间线中的文章，附带阅读状态
+type TimelinePost struct {
+	Post
+	FeedName  string     `json:"feed_name"`
+	ReadAt    *time.Time `json:"read_at"`
+	StarredAt *time.Time `json:"starred_at"`
+	Tags      []string   `json:"tags"`
+}
+
+func NewTimelinePost(row db.GetPostsForUserRow) TimelinePost {
+	return TimelinePost{
+		Post: Post{
+			ID:          row.ID,
+			FeedID:      row.FeedID,
+			Title:       row.Title,
+			URL:         row.Url,
+			Description: nullString(row.Description),
+			PublishedAt: row.PublishedAt,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		},
+		FeedName:  row.FeedName,
+		ReadAt:    nullTime(row.ReadAt),
+		StarredAt: nullTime(row.StarredAt),
+		Tags:      stringSlice(row.Tags),
+	}
+}
+
+// SearchResult 是全文检索结果，TitleHighlight 和 Snippet 中的命中词以 <mark> 标出
+type SearchResult struct {
+	Post
+	FeedName       string  `json:"feed_name"`
+	Rank           float32 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+func NewSearchResult(row db.SearchPostsRow, titleHighlight, snippet string) SearchResult {
+	return SearchResult{
+		Post: Post{
+			ID:          row.ID,
+			FeedID:      row.FeedID,
+			Title:       row.Title,
+			URL:         row.Url,
+			Description: nullString(row.Description),
+			PublishedAt: row.PublishedAt,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		},
+		FeedName:       row.FeedName,
+		Rank:           row.Rank,
+		TitleHighlight: titleHighlight,
+		Snippet:        snippet,
+	}
+}
+
+// StreamPost 是实时推送的新文章
+type StreamPost struct {
+	Post
+	Author   *string `json:"author"`
+	FeedName string  `json:"feed_name"`
+}
+
+func NewStreamPost(row db.GetStreamPostsRow) StreamPost {
+	return StreamPost{
+		Post: Post{
+			ID:          row.ID,
+			FeedID:      row.FeedID,
+			Title:       row.Title,
+			URL:         row.Url,
+			Description: nullString(row.Description),
+			PublishedAt: row.PublishedAt,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		},
+		Author:   nullString(row.Author),
+		FeedName: row.FeedName,
+	}
+}
+
+// PostState 是导出的文章阅读状态，附带文章和订阅源地址
+type PostState struct {
+	PostID    uuid.UUID  `json:"post_id"`
+	Title     string     `json:"title"`
+	URL       string     `json:"url"`
+	FeedURL   string     `json:"feed_url"`
+	ReadAt    *time.Time `json:"read_at"`
+	StarredAt *time.Time `json:"starred_at"`
+	HiddenAt  *time.Time `json:"hidden_at"`
+	Tags      []string   `json:"tags"`
+}
+
+func NewPostState(row db.GetPostStatesForExportRow) PostState {
+	return PostState{
+		PostID:    row.PostID,
+		Title:     row.Title,
+		URL:       row.Url,
+		FeedURL:   row.FeedUrl,
+		ReadAt:    nullTime(row.ReadAt),
+		StarredAt: nullTime(row.StarredAt),
+		HiddenAt:  nullTime(row.HiddenAt),
+		Tags:      stringSlice(row.Tags),
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+)
+
+// Quota 是可拥有的订阅源、关注和 Webhook 数量上限，null 表示不限制
+type Quota struct {
+	MaxFeeds    *int32 `json:"max_feeds"`
+	MaxFollows  *int32 `json:"max_follows"`
+	MaxWebhooks *int32 `json:"max_webhooks"`
+}
+
+// NewUserQuotaOverride 返回单独为用户设置的配额
+func NewUserQuotaOverride(u db.User) Quota {
+	return Quota{
+		MaxFeeds:    nullInt32(u.MaxFeeds),
+		MaxFollows:  nullInt32(u.MaxFollows),
+		MaxWebhooks: nullInt32(u.MaxWebhooks),
+	}
+}
+
+// RoleQuota 是角色的默认配额
+type RoleQuota struct {
+	Role string `json:"role"`
+	Quota
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewRoleQuota(q db.RoleQuota) RoleQuota {
+	return RoleQuota{
+		Role: q.Role,
+		Quota: Quota{
+			MaxFeeds:    nullInt32(q.MaxFeeds),
+			MaxFollows:  nullInt32(q.MaxFollows),
+			MaxWebhooks: nullInt32(q.MaxWebhooks),
+		},
+		UpdatedAt: q.UpdatedAt,
+	}
+}
+
+// QuotaUsage 是用户的有效配额和当前用量
+type QuotaUsage struct {
+	Quota
+	Feeds    int64 `json:"feeds"`
+	Follows  int64 `json:"follows"`
+	Webhooks int64 `json:"webhooks"`
+}
+
+func NewQuotaUsage(row db.GetUserQuotaRow) QuotaUsage {
+	return QuotaUsage{
+		Quota: Quota{
+			MaxFeeds:    nullInt32(row.MaxFeeds),
+			MaxFollows:  nullInt32(row.MaxFollows),
+			MaxWebhooks: nullInt32(row.MaxWebhooks),
+		},
+		Feeds:    row.Feeds,
+		Follows:  row.Follows,
+		Webhooks: row.Webhooks,
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// Rule 是过滤规则，Tag 仅在 action 为 tag 时有值
+type Rule struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Field     string    `json:"field"`
+	MatchType string    `json:"match_type"`
+	Pattern   string    `json:"pattern"`
+	Action    string    `json:"action"`
+	Tag       *string   `json:"tag"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewRule(r db.Rule) Rule {
+	return Rule{
+		ID:        r.ID,
+		Name:      r.Name,
+		Field:     r.Field,
+		MatchType: r.MatchType,
+		Pattern:   r.Pattern,
+		Action:    r.Action,
+		Tag:       nullString(r.Tag),
+		Enabled:   r.Enabled,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+// AppliedRule 是创建或修改规则的结果，Applied 为本次回溯命中的文章数
+type AppliedRule struct {
+	Rule
+	Applied int `json:"applied"`
+}
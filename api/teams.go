@@ -0,0 +1,249 @@
+package api
+
+import (
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// Team 是团队信息，Role 为请求者在团队中的角色
+type Team struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewTeam(t db.Team, role string) Team {
+	return Team{
+		ID:        t.ID,
+		Name:      t.Name,
+		Role:      role,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+// TeamListItem 是团队列表项，附带成员数
+type TeamListItem struct {
+	Team
+	MembersCount int64 `json:"members_count"`
+}
+
+func NewTeamListItem(row db.GetTeamsByUserIDRow) TeamListItem {
+	return TeamListItem{
+		Team: NewTeam(db.Team{
+			ID:        row.ID,
+			Name:      row.Name,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		}, row.Role),
+		MembersCount: row.MembersCount,
+	}
+}
+
+// TeamMember 是团队成员
+type TeamMember struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Username    string    `json:"username"`
+	DisplayName *string   `json:"display_name"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func NewTeamMember(row db.GetTeamMembersRow) TeamMember {
+	return TeamMember{
+		UserID:      row.UserID,
+		Username:    row.Username,
+		DisplayName: nullString(row.DisplayName),
+		Role:        row.Role,
+		CreatedAt:   row.CreatedAt,
+	}
+}
+
+// TeamWithMembers 是团队详情
+type TeamWithMembers struct {
+	Team
+	Members []TeamMember `json:"members"`
+}
+
+func NewTeamWithMembers(t db.Team, role string, members []db.GetTeamMembersRow) TeamWithMembers {
+	return TeamWithMembers{
+		Team:    NewTeam(t, role),
+		Members: List(members, NewTeamMember),
+	}
+}
+
+// TeamMembership 是接受邀请或修改角色的结果
+type TeamMembership struct {
+	TeamID    uuid.UUID `json:"team_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewTeamMembership(m db.TeamMember) TeamMembership {
+	return TeamMembership{
+		TeamID:    m.TeamID,
+		UserID:    m.UserID,
+		Role:      m.Role,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// TeamInvitation 是待被邀请人接受的团队邀请
+type TeamInvitation struct {
+	TeamID    uuid.UUID  `json:"team_id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Role      string     `json:"role"`
+	InvitedBy *uuid.UUID `json:"invited_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func NewTeamInvitation(i db.TeamInvitation) TeamInvitation {
+	return TeamInvitation{
+		TeamID:    i.TeamID,
+		UserID:    i.UserID,
+		Role:      i.Role,
+		InvitedBy: nullUUID(i.InvitedBy),
+		CreatedAt: i.CreatedAt,
+	}
+}
+
+// PendingTeamInvitation 是团队发出、尚未接受的邀请，附带被邀请人的用户名
+type PendingTeamInvitation struct {
+	TeamInvitation
+	Username string `json:"username"`
+}
+
+func NewPendingTeamInvitation(row db.GetTeamInvitationsRow) PendingTeamInvitation {
+	return PendingTeamInvitation{
+		TeamInvitation: NewTeamInvitation(db.TeamInvitation{
+			TeamID:    row.TeamID,
+			UserID:    row.UserID,
+			Role:      row.Role,
+			InvitedBy: row.InvitedBy,
+			CreatedAt: row.CreatedAt,
+		}),
+		Username: row.Username,
+	}
+}
+
+// IncomingTeamInvitation 是收到的团队邀请，附带团队名称和邀请人
+type IncomingTeamInvitation struct {
+	TeamInvitation
+	TeamName          string  `json:"team_name"`
+	InvitedByUsername *string `json:"invited_by_username"`
+}
+
+func NewIncomingTeamInvitation(row db.GetIncomingTeamInvitationsRow) IncomingTeamInvitation {
+	return IncomingTeamInvitation{
+		TeamInvitation: NewTeamInvitation(db.TeamInvitation{
+			TeamID:    row.TeamID,
+			UserID:    row.UserID,
+			Role:      row.Role,
+			InvitedBy: row.InvitedBy,
+			CreatedAt: row.CreatedAt,
+		}),
+		TeamName:          row.TeamName,
+		InvitedByUsername: nullString(row.InvitedByUsername),
+	}
+}
+
+// TeamFollow 是团队订阅的记录
+type TeamFollow struct {
+	ID        uuid.UUID  `json:"id"`
+	TeamID    uuid.UUID  `json:"team_id"`
+	FeedID    uuid.UUID  `json:"feed_id"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func NewTeamFollow(f db.TeamFollow) TeamFollow {
+	return TeamFollow{
+		ID:        f.ID,
+		TeamID:    f.TeamID,
+		FeedID:    f.FeedID,
+		CreatedBy: nullUUID(f.CreatedBy),
+		CreatedAt: f.CreatedAt,
+	}
+}
+
+// TeamFollowedFeed 是团队订阅的订阅源，未读数按请求者自己的阅读状态计算
+type TeamFollowedFeed struct {
+	TeamFollow
+	FeedName          string  `json:"feed_name"`
+	FeedURL           string  `json:"feed_url"`
+	CreatedByUsername *string `json:"created_by_username"`
+	UnreadCount       int64   `json:"unread_count"`
+}
+
+func NewTeamFollowedFeed(row db.GetTeamFollowsRow) TeamFollowedFeed {
+	return TeamFollowedFeed{
+		TeamFollow: NewTeamFollow(db.TeamFollow{
+			ID:        row.ID,
+			TeamID:    row.TeamID,
+			FeedID:    row.FeedID,
+			CreatedBy: row.CreatedBy,
+			CreatedAt: row.CreatedAt,
+		}),
+		FeedName:          row.FeedName,
+		FeedURL:           row.FeedUrl,
+		CreatedByUsername: nullString(row.CreatedByUsername),
+		UnreadCount:       row.UnreadCount,
+	}
+}
+
+func NewTeamFolder(f db.TeamFolder) Folder {
+	return Folder{
+		ID:        f.ID,
+		Name:      f.Name,
+		Position:  f.Position,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
+
+// NewTeamFolderWithFeeds 团队文件夹与个人文件夹的返回结构相同
+func NewTeamFolderWithFeeds(row db.GetTeamFoldersRow, feeds []db.GetTeamFolderFeedsRow) FolderWithFeeds {
+	return FolderWithFeeds{
+		Folder: NewTeamFolder(db.TeamFolder{
+			ID:        row.ID,
+			TeamID:    row.TeamID,
+			Name:      row.Name,
+			Position:  row.Position,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		}),
+		UnreadCount: row.UnreadCount,
+		Feeds:       List(feeds, NewTeamFolderFeedItem),
+	}
+}
+
+func NewTeamFolderFeedItem(row db.GetTeamFolderFeedsRow) FolderFeedItem {
+	return NewFolderFeedItem(db.GetFolderFeedsByUserIDRow(row))
+}
+
+// TeamFolderFeed 是订阅源加入团队文件夹的记录
+type TeamFolderFeed struct {
+	FolderID     uuid.UUID `json:"folder_id"`
+	TeamFollowID uuid.UUID `json:"team_follow_id"`
+	Position     int32     `json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func NewTeamFolderFeed(f db.TeamFolderFeed) TeamFolderFeed {
+	return TeamFolderFeed{
+		FolderID:     f.FolderID,
+		TeamFollowID: f.TeamFollowID,
+		Position:     f.Position,
+		CreatedAt:    f.CreatedAt,
+	}
+}
+
+// NewTeamTimelinePost 团队时间线与个人时间线的列相同
+func NewTeamTimelinePost(row db.GetTeamPostsRow) TimelinePost {
+	return NewTimelinePost(db.GetPostsForUserRow(row))
+}
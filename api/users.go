@@ -0,0 +1,101 @@
+package api
+
+import (
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// User 是用户的公开信息，密码哈希、订阅输出令牌和 Fever 密钥只以是否启用的形式体现
+type User struct {
+	ID               uuid.UUID `json:"id"`
+	Username         string    `json:"username"`
+	DisplayName      *string   `json:"display_name"`
+	Email            *string   `json:"email"`
+	Role             string    `json:"role"`
+	FeedTokenEnabled bool      `json:"feed_token_enabled"`
+	FeverEnabled     bool      `json:"fever_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func NewUser(u db.User) User {
+	return User{
+		ID:               u.ID,
+		Username:         u.Username,
+		DisplayName:      nullString(u.DisplayName),
+		Email:            nullString(u.Email),
+		Role:             u.Role,
+		FeedTokenEnabled: u.FeedTokenHash.Valid,
+		FeverEnabled:     u.FeverApiKey.Valid,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+	}
+}
+
+// Session 是登录、注册和刷新会话的响应
+type Session struct {
+	User        User      `json:"user"`
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CsrfToken   string    `json:"csrf_token"`
+}
+
+// SessionInfo 是会话列表项，不包含令牌哈希
+type SessionInfo struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current 标记发起请求的会话
+	Current bool `json:"current"`
+}
+
+func NewSessionInfo(s db.Session, current bool) SessionInfo {
+	return SessionInfo{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IP:         s.Ip,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    current,
+	}
+}
+
+// APIKey 不包含哈希，Key 明文只在创建时返回
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scope      string     `json:"scope"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Key        string     `json:"key,omitempty"`
+}
+
+func NewAPIKey(k db.ApiKey) APIKey {
+	return APIKey{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scope:      k.Scope,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: nullTime(k.LastUsedAt),
+		ExpiresAt:  nullTime(k.ExpiresAt),
+	}
+}
+
+// AuthMethods 是可用的登录方式
+type AuthMethods struct {
+	Password     bool   `json:"password"`
+	OIDC         bool   `json:"oidc"`
+	OIDCLoginURL string `json:"oidc_login_url,omitempty"`
+	// Registration 为注册方式：open、invite 或 closed
+	Registration string `json:"registration"`
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// Webhook 隐藏签名密钥，密钥只在创建时返回一次
+type Webhook struct {
+	ID        uuid.UUID  `json:"id"`
+	URL       string     `json:"url"`
+	FeedID    *uuid.UUID `json:"feed_id"`
+	FolderID  *uuid.UUID `json:"folder_id"`
+	RuleID    *uuid.UUID `json:"rule_id"`
+	Secret    string     `json:"secret,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func NewWebhook(h db.Webhook) Webhook {
+	return Webhook{
+		ID:        h.ID,
+		URL:       h.Url,
+		FeedID:    nullUUID(h.FeedID),
+		FolderID:  nullUUID(h.FolderID),
+		RuleID:    nullUUID(h.RuleID),
+		CreatedAt: h.CreatedAt,
+		UpdatedAt: h.UpdatedAt,
+	}
+}
+
+// WebhookDelivery 是一次 webhook 投递及其重试状态
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id"`
+	WebhookID      uuid.UUID  `json:"webhook_id"`
+	PostID         uuid.UUID  `json:"post_id"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode *int32     `json:"last_status_code"`
+	LastError      *string    `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func NewWebhookDelivery(d db.WebhookDelivery) WebhookDelivery {
+	return WebhookDelivery{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		PostID:         d.PostID,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: nullInt32(d.LastStatusCode),
+		LastError:      nullString(d.LastError),
+		DeliveredAt:    nullTime(d.DeliveredAt),
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
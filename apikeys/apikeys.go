@@ -0,0 +1,60 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// API Key 的权限范围，高级别包含低级别的全部权限
+const (
+	ScopeReadOnly  = "read-only"  // 只能调用只读接口
+	ScopeReadWrite = "read-write" // 可以修改订阅、文章状态等数据
+	ScopeAdmin     = "admin"      // 额外可以管理 API Key 和账号凭据
+)
+
+// keyPrefix 便于在日志或代码仓库中识别泄露的 Key
+const keyPrefix = "rss_"
+
+var scopeLevels = map[string]int{
+	ScopeReadOnly:  1,
+	ScopeReadWrite: 2,
+	ScopeAdmin:     3,
+}
+
+// ValidScope 判断权限范围是否合法
+func ValidScope(scope string) bool {
+	_, ok := scopeLevels[scope]
+	return ok
+}
+
+// Allows 判断已授予的权限范围是否满足要求
+func Allows(granted, required string) bool {
+	return scopeLevels[granted] >= scopeLevels[required]
+}
+
+// ScopeForMethod 默认按请求方法决定所需权限：只读请求需要 read-only，其余需要 read-write
+func ScopeForMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeReadOnly
+	}
+	return ScopeReadWrite
+}
+
+// Generate 生成新的 API Key，返回明文、用于展示的前缀和保存到数据库的哈希
+func Generate() (key, prefix, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	key = keyPrefix + hex.EncodeToString(buf)
+	return key, key[:len(keyPrefix)+8], Hash(key), nil
+}
+
+// Hash 计算 API Key 的 SHA-256 哈希
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
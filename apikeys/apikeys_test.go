@@ -0,0 +1,108 @@
+package apikeys
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	key, prefix, hash, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !strings.HasPrefix(key, keyPrefix) || len(key) != len(keyPrefix)+64 {
+		t.Errorf("key = %q, want %q followed by 64 hex digits", key, keyPrefix)
+	}
+	if !strings.HasPrefix(key, prefix) || len(prefix) != len(keyPrefix)+8 {
+		t.Errorf("prefix = %q, want the first %d characters of the key", prefix, len(keyPrefix)+8)
+	}
+	if hash != Hash(key) {
+		t.Errorf("hash = %q, want Hash(key) = %q", hash, Hash(key))
+	}
+	other, _, _, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if other == key {
+		t.Errorf("Generate() returned the same key twice")
+	}
+}
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"rss_abc", "19aa48938463565a3d5fe937cb42427191ee8b39ca54f222c053bde4073c5959"},
+	}
+	for _, tt := range tests {
+		if got := Hash(tt.key); got != tt.want {
+			t.Errorf("Hash(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		granted  string
+		required string
+		want     bool
+	}{
+		{ScopeReadOnly, ScopeReadOnly, true},
+		{ScopeReadOnly, ScopeReadWrite, false},
+		{ScopeReadOnly, ScopeAdmin, false},
+		{ScopeReadWrite, ScopeReadOnly, true},
+		{ScopeReadWrite, ScopeReadWrite, true},
+		{ScopeReadWrite, ScopeAdmin, false},
+		{ScopeAdmin, ScopeReadOnly, true},
+		{ScopeAdmin, ScopeAdmin, true},
+		{"", ScopeReadOnly, false},
+		{"superuser", ScopeReadOnly, false},
+	}
+	for _, tt := range tests {
+		if got := Allows(tt.granted, tt.required); got != tt.want {
+			t.Errorf("Allows(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestValidScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{ScopeReadOnly, true},
+		{ScopeReadWrite, true},
+		{ScopeAdmin, true},
+		{"", false},
+		{"Read-Only", false},
+		{"write", false},
+	}
+	for _, tt := range tests {
+		if got := ValidScope(tt.scope); got != tt.want {
+			t.Errorf("ValidScope(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestScopeForMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{http.MethodGet, ScopeReadOnly},
+		{http.MethodHead, ScopeReadOnly},
+		{http.MethodOptions, ScopeReadOnly},
+		{http.MethodPost, ScopeReadWrite},
+		{http.MethodPut, ScopeReadWrite},
+		{http.MethodPatch, ScopeReadWrite},
+		{http.MethodDelete, ScopeReadWrite},
+	}
+	for _, tt := range tests {
+		if got := ScopeForMethod(tt.method); got != tt.want {
+			t.Errorf("ScopeForMethod(%q) = %q, want %q", tt.method, got, tt.want)
+		}
+	}
+}
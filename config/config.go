@@ -2,7 +2,10 @@ package config
 
 import (
 	"log"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -10,6 +13,34 @@ import (
 type Config struct {
 	DBUrl string
 	Port  string
+	// BaseURL 对外访问地址，用于生成输出订阅等绝对链接
+	BaseURL string
+	// SMTP 用于发送邮件摘要，SMTPHost 为空时不发送
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	// SessionSecret 用于签名访问令牌，多实例部署时必须一致
+	SessionSecret string
+	// OIDC 单点登录，OIDCIssuer 为空时不启用
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
+	OIDCGroupsClaim  string
+	OIDCAdminGroup   string
+	// PasswordLoginDisabled 为 true 时只能通过单点登录注册和登录
+	PasswordLoginDisabled bool
+	// AdminUsers 启动时设为管理员的用户名
+	AdminUsers []string
+	// RegistrationMode 注册方式：open 开放注册，invite 需要邀请码，closed 不允许注册
+	RegistrationMode string
+	// TrustedProxies 反向代理的地址或网段，只有来自这些地址的请求才采信 X-Forwarded-For
+	TrustedProxies []netip.Prefix
+	// RateLimits 覆盖内置限流策略，键如 auth.ip、read.user，值如 10/6s
+	RateLimits map[string]string
 }
 
 func LoadConfig() Config {
@@ -17,8 +48,83 @@ func LoadConfig() Config {
 	if err != nil {
 		log.Fatal("Error loading .env file")
 	}
+	port := os.Getenv("PORT")
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:" + port
+	}
+	smtpPort := 587
+	if s := os.Getenv("SMTP_PORT"); s != "" {
+		smtpPort, err = strconv.Atoi(s)
+		if err != nil {
+			log.Fatal("Invalid SMTP_PORT:", err)
+		}
+	}
+	redirectURL := os.Getenv("OIDC_REDIRECT_URL")
+	if redirectURL == "" {
+		redirectURL = strings.TrimRight(baseURL, "/") + "/v1/auth/oidc/callback"
+	}
+	groupsClaim := os.Getenv("OIDC_GROUPS_CLAIM")
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+	passwordLoginDisabled := false
+	if s := os.Getenv("PASSWORD_LOGIN_DISABLED"); s != "" {
+		passwordLoginDisabled, err = strconv.ParseBool(s)
+		if err != nil {
+			log.Fatal("Invalid PASSWORD_LOGIN_DISABLED:", err)
+		}
+	}
+	registrationMode := strings.ToLower(os.Getenv("REGISTRATION_MODE"))
+	switch registrationMode {
+	case "":
+		registrationMode = "open"
+	case "open", "invite", "closed":
+	default:
+		log.Fatal("Invalid REGISTRATION_MODE: ", registrationMode)
+	}
+	var trustedProxies []netip.Prefix
+	for _, s := range strings.FieldsFunc(os.Getenv("TRUSTED_PROXIES"), func(r rune) bool { return r == ',' || r == ' ' }) {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(s)
+			if addrErr != nil {
+				log.Fatal("Invalid TRUSTED_PROXIES entry: ", s)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		trustedProxies = append(trustedProxies, prefix.Masked())
+	}
+	rateLimits := map[string]string{}
+	for _, policy := range []string{"auth", "read", "write"} {
+		for _, target := range []string{"ip", "user"} {
+			if s := os.Getenv("RATE_LIMIT_" + strings.ToUpper(policy+"_"+target)); s != "" {
+				rateLimits[policy+"."+target] = s
+			}
+		}
+	}
 	return Config{
-		DBUrl: os.Getenv("DB_URL"),
-		Port:  os.Getenv("PORT"),
+		DBUrl:         os.Getenv("DB_URL"),
+		Port:          port,
+		BaseURL:       strings.TrimRight(baseURL, "/"),
+		SMTPHost:      os.Getenv("SMTP_HOST"),
+		SMTPPort:      smtpPort,
+		SMTPUsername:  os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:  os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:      os.Getenv("SMTP_FROM"),
+		SessionSecret: os.Getenv("SESSION_SECRET"),
+
+		OIDCIssuer:            os.Getenv("OIDC_ISSUER"),
+		OIDCClientID:          os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret:      os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:       redirectURL,
+		OIDCScopes:            strings.Fields(os.Getenv("OIDC_SCOPES")),
+		OIDCGroupsClaim:       groupsClaim,
+		OIDCAdminGroup:        os.Getenv("OIDC_ADMIN_GROUP"),
+		PasswordLoginDisabled: passwordLoginDisabled,
+		AdminUsers:            strings.FieldsFunc(os.Getenv("ADMIN_USERS"), func(r rune) bool { return r == ',' || r == ' ' }),
+		RegistrationMode:      registrationMode,
+		TrustedProxies:        trustedProxies,
+		RateLimits:            rateLimits,
 	}
 }
@@ -0,0 +1,162 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	// 内置时区数据库，部署环境没有 tzdata 时也能解析用户时区
+	_ "time/tzdata"
+	"unicode/utf8"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/search"
+	"github.com/google/uuid"
+)
+
+// 摘要频率
+const (
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+// maxPosts 单封摘要最多包含的文章数
+const maxPosts = 100
+
+const snippetLength = 200
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(htmltemplate.FuncMap{"date": formatDate}).ParseFS(templateFS, "templates/digest.html"))
+	textTemplate = texttemplate.Must(texttemplate.New("digest.txt").Funcs(texttemplate.FuncMap{"date": formatDate}).ParseFS(templateFS, "templates/digest.txt"))
+)
+
+// Digest 是一封摘要邮件的内容
+type Digest struct {
+	Username       string
+	Frequency      string
+	Since          time.Time
+	Location       *time.Location
+	Groups         []Group
+	Total          int
+	AppURL         string
+	UnsubscribeURL string
+}
+
+// Group 是同一订阅源的文章
+type Group struct {
+	FeedName string
+	Posts    []Post
+}
+
+type Post struct {
+	Title       string
+	URL         string
+	Snippet     string
+	PublishedAt time.Time
+}
+
+// Load 读取 since 之后用户未读的文章，按订阅源分组
+func Load(ctx context.Context, query *db.Queries, settings db.DigestSetting, user db.User, since time.Time, baseURL string) (Digest, error) {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	folderIDs := settings.FolderIds
+	if folderIDs == nil {
+		folderIDs = []uuid.UUID{}
+	}
+	rows, err := query.GetDigestPosts(ctx, db.GetDigestPostsParams{
+		UserID:    user.ID,
+		Since:     since,
+		FolderIds: folderIDs,
+		PageLimit: maxPosts,
+	})
+	if err != nil {
+		return Digest{}, err
+	}
+
+	digest := Digest{
+		Username:       user.Username,
+		Frequency:      settings.Frequency,
+		Since:          since,
+		Location:       loc,
+		Total:          len(rows),
+		AppURL:         baseURL + "/",
+		UnsubscribeURL: fmt.Sprintf("%s/digest/unsubscribe/%s", baseURL, settings.UnsubscribeToken),
+	}
+	for _, row := range rows {
+		if len(digest.Groups) == 0 || digest.Groups[len(digest.Groups)-1].FeedName != row.FeedName {
+			digest.Groups = append(digest.Groups, Group{FeedName: row.FeedName})
+		}
+		group := &digest.Groups[len(digest.Groups)-1]
+		group.Posts = append(group.Posts, Post{
+			Title:       row.Title,
+			URL:         row.Url,
+			Snippet:     snippet(search.PlainText(row.Description.String)),
+			PublishedAt: row.PublishedAt,
+		})
+	}
+	return digest, nil
+}
+
+// Subject 邮件标题
+func (d Digest) Subject() string {
+	if d.Frequency == FrequencyWeekly {
+		return fmt.Sprintf("Go RSS 每周摘要：%d 篇未读文章", d.Total)
+	}
+	return fmt.Sprintf("Go RSS 每日摘要：%d 篇未读文章", d.Total)
+}
+
+// Render 渲染 HTML 和纯文本两种格式的正文
+func (d Digest) Render() (string, string, error) {
+	var html, text bytes.Buffer
+	if err := htmlTemplate.Execute(&html, d); err != nil {
+		return "", "", err
+	}
+	if err := textTemplate.Execute(&text, d); err != nil {
+		return "", "", err
+	}
+	return html.String(), text.String(), nil
+}
+
+// NextSendAt 计算 after 之后的下一次发送时间
+func NextSendAt(after time.Time, frequency string, sendHour, weekday int, loc *time.Location) time.Time {
+	local := after.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), sendHour, 0, 0, 0, loc)
+	step := 1
+	if frequency == FrequencyWeekly {
+		step = 7
+		next = next.AddDate(0, 0, (weekday-int(next.Weekday())+7)%7)
+	}
+	for !next.After(after) {
+		next = next.AddDate(0, 0, step)
+	}
+	return next.UTC()
+}
+
+// Period 没有发送记录时摘要覆盖的时间范围
+func Period(frequency string) time.Duration {
+	if frequency == FrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+func snippet(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= snippetLength {
+		return s
+	}
+	return string([]rune(s)[:snippetLength]) + "…"
+}
+
+func formatDate(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01-02 15:04")
+}
@@ -0,0 +1,71 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextSendAt(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		name      string
+		after     string
+		frequency string
+		hour      int
+		weekday   int
+		loc       *time.Location
+		want      string
+	}{
+		// 2026-10-19 是星期一
+		{"daily later today", "2026-10-19T07:30:00Z", FrequencyDaily, 8, 0, time.UTC, "2026-10-19T08:00:00Z"},
+		{"daily exactly at send time", "2026-10-19T08:00:00Z", FrequencyDaily, 8, 0, time.UTC, "2026-10-20T08:00:00Z"},
+		{"daily tomorrow", "2026-10-19T09:00:00Z", FrequencyDaily, 8, 0, time.UTC, "2026-10-20T08:00:00Z"},
+		{"weekly same day", "2026-10-19T07:00:00Z", FrequencyWeekly, 8, 1, time.UTC, "2026-10-19T08:00:00Z"},
+		{"weekly next week", "2026-10-19T09:00:00Z", FrequencyWeekly, 8, 1, time.UTC, "2026-10-26T08:00:00Z"},
+		{"weekly sunday", "2026-10-19T09:00:00Z", FrequencyWeekly, 8, 0, time.UTC, "2026-10-25T08:00:00Z"},
+		{"weekly saturday", "2026-10-19T09:00:00Z", FrequencyWeekly, 0, 6, time.UTC, "2026-10-24T00:00:00Z"},
+		// 按用户时区计算：上海 08:00 为 UTC 00:00
+		{"timezone", "2026-10-19T01:00:00Z", FrequencyDaily, 8, 0, shanghai, "2026-10-20T00:00:00Z"},
+		{"timezone date boundary", "2026-10-18T23:00:00Z", FrequencyDaily, 8, 0, shanghai, "2026-10-19T00:00:00Z"},
+		// 夏令时结束后本地 08:00 从 UTC 12:00 变为 13:00
+		{"daylight saving", "2026-10-31T13:00:00Z", FrequencyDaily, 8, 0, newYork, "2026-11-01T13:00:00Z"},
+		{"weekly across daylight saving", "2026-10-26T13:00:00Z", FrequencyWeekly, 8, 1, newYork, "2026-11-02T13:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextSendAt(utc(tt.after), tt.frequency, tt.hour, tt.weekday, tt.loc)
+			if want := utc(tt.want); !got.Equal(want) {
+				t.Errorf("NextSendAt = %s, want %s", got.Format(time.RFC3339), want.Format(time.RFC3339))
+			}
+			if got.Location() != time.UTC {
+				t.Errorf("location = %s, want UTC", got.Location())
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	if got := snippet("  a\n\tb  "); got != "a b" {
+		t.Errorf("snippet = %q", got)
+	}
+	long := strings.Repeat("字", snippetLength+10)
+	if got := []rune(snippet(long)); len(got) != snippetLength+1 {
+		t.Errorf("snippet length = %d, want %d", len(got), snippetLength+1)
+	}
+}
@@ -0,0 +1,132 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer 是只实现 SendMail 所需命令的 SMTP 服务，记录收到的命令和邮件内容
+type fakeSMTPServer struct {
+	listener net.Listener
+	commands chan string
+	data     chan string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{listener: l, commands: make(chan string, 16), data: make(chan string, 1)}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		s.commands <- line
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "DATA":
+			reply("354 go ahead")
+			var body strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				body.WriteString(l)
+			}
+			s.data <- body.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestSMTPMailerSendUsesBareEnvelopeSender(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	mailer := SMTPMailer{Host: "127.0.0.1", Port: server.port(), From: "Go RSS <rss@example.com>"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := mailer.Send(ctx, Message{
+		To:      "alice@example.com",
+		Subject: "每日摘要",
+		HTML:    "<p>hi</p>",
+		Text:    "hi",
+		Headers: map[string]string{"List-Unsubscribe-Post": "List-Unsubscribe=One-Click"},
+	})
+	if err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+
+	var mailFrom string
+	for len(server.commands) > 0 {
+		if cmd := <-server.commands; strings.HasPrefix(strings.ToUpper(cmd), "MAIL FROM:") {
+			mailFrom = cmd
+		}
+	}
+	if !strings.HasPrefix(mailFrom, "MAIL FROM:<rss@example.com>") {
+		t.Errorf("MAIL FROM command = %q, want bare address", mailFrom)
+	}
+	data := <-server.data
+	for _, header := range []string{
+		`From: "Go RSS" <rss@example.com>`,
+		"To: alice@example.com",
+		"List-Unsubscribe-Post: List-Unsubscribe=One-Click",
+		"Content-Type: multipart/alternative",
+	} {
+		if !strings.Contains(data, header) {
+			t.Errorf("message does not contain %q:\n%s", header, data)
+		}
+	}
+}
+
+func TestSMTPMailerSendInvalidSender(t *testing.T) {
+	mailer := SMTPMailer{Host: "127.0.0.1", Port: 1, From: "not an address"}
+	err := mailer.Send(context.Background(), Message{To: "alice@example.com"})
+	if err == nil || !strings.Contains(err.Error(), "invalid sender") {
+		t.Errorf("Send error = %v, want invalid sender", err)
+	}
+}
+
+func TestBuildMessageEncodesSubject(t *testing.T) {
+	data, err := buildMessage("rss@example.com", Message{To: "a@example.com", Subject: "每日摘要", Text: "t", HTML: "h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Subject: =?utf-8?q?") {
+		t.Errorf("subject is not Q-encoded:\n%s", data)
+	}
+}
@@ -0,0 +1,106 @@
+package digest
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+)
+
+// retryDelay 发送失败后的重试间隔
+const retryDelay = 15 * time.Minute
+
+// StartScheduler 每分钟检查到期的摘要并发送
+// 参数：
+//   - query: 数据库查询接口
+//   - mailer: 邮件投递实现
+//   - baseURL: 对外访问地址，用于生成退订链接
+func StartScheduler(query *db.Queries, mailer Mailer, baseURL string) {
+	log.Println("Digest scheduler started")
+	ticker := time.NewTicker(time.Minute)
+	for ; ; <-ticker.C {
+		due, err := query.GetDueDigestSettings(context.Background(), 50)
+		if err != nil {
+			log.Println("Error loading due digests:", err)
+			continue
+		}
+		for _, settings := range due {
+			sendDigest(query, mailer, baseURL, settings)
+		}
+	}
+}
+
+// sendDigest 发送一封摘要并安排下一次发送，没有未读文章时跳过本次
+func sendDigest(query *db.Queries, mailer Mailer, baseURL string, settings db.DigestSetting) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	next := NextSendAt(now, settings.Frequency, int(settings.SendHour), int(settings.Weekday), loc)
+
+	user, err := query.GetUserByID(ctx, settings.UserID)
+	if err != nil {
+		log.Printf("Error loading user %s for digest: %v\n", settings.UserID, err)
+		return
+	}
+	since := now.Add(-Period(settings.Frequency))
+	if settings.LastSentAt.Valid {
+		since = settings.LastSentAt.Time
+	}
+	digest, err := Load(ctx, query, settings, user, since, baseURL)
+	if err != nil {
+		log.Printf("Error loading digest for %s: %v\n", user.Username, err)
+		postpone(query, settings, now)
+		return
+	}
+
+	if digest.Total > 0 {
+		html, text, err := digest.Render()
+		if err != nil {
+			log.Printf("Error rendering digest for %s: %v\n", user.Username, err)
+			postpone(query, settings, now)
+			return
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err = mailer.Send(sendCtx, Message{
+			To:      settings.Email,
+			Subject: digest.Subject(),
+			HTML:    html,
+			Text:    text,
+			Headers: map[string]string{
+				"List-Unsubscribe":      "<" + digest.UnsubscribeURL + ">",
+				"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+			},
+		})
+		cancel()
+		if err != nil {
+			log.Printf("Error sending digest to %s: %v\n", settings.Email, err)
+			postpone(query, settings, now)
+			return
+		}
+		log.Printf("==> 📬 Digest sent to %s with %v posts", user.Username, digest.Total)
+	}
+
+	err = query.MarkDigestSent(ctx, db.MarkDigestSentParams{
+		UserID:     settings.UserID,
+		LastSentAt: sql.NullTime{Time: now, Valid: true},
+		NextSendAt: next,
+	})
+	if err != nil {
+		log.Println("Error marking digest sent:", err)
+	}
+}
+
+func postpone(query *db.Queries, settings db.DigestSetting, now time.Time) {
+	err := query.PostponeDigest(context.Background(), db.PostponeDigestParams{
+		UserID:     settings.UserID,
+		NextSendAt: now.Add(retryDelay),
+	})
+	if err != nil {
+		log.Println("Error postponing digest:", err)
+	}
+}
@@ -23,52 +23,95 @@ function showContent(id) {
     $(`#${id}`).show();
 }
 
+// 当前会话，访问令牌只保存在内存中，刷新令牌由 HttpOnly Cookie 保存
+let currentSession = null;
+// 服务端禁用密码登录时只能通过单点登录注册和登录
+let passwordLoginEnabled = true;
+// 注册方式：open 开放注册，invite 需要邀请码，closed 不允许注册
+let registrationMode = 'open';
+
 function setAuthState(isAuthenticated, userInfo = null) {
     if (isAuthenticated && userInfo) {
         $('#login-link, #register-link').hide();
         $('#user-info, #logout-link').show();
-        $('#welcome-message').text(`欢迎, ${userInfo.Username}`);
-        localStorage.setItem('user', JSON.stringify(userInfo));
+        $('#welcome-message').text(`欢迎, ${userInfo.user.username}`);
+        currentSession = userInfo;
     } else {
-        $('#login-link, #register-link').show();
+        $('#login-link').show();
+        $('#register-link').toggle(passwordLoginEnabled && registrationMode !== 'closed');
         $('#user-info, #logout-link').hide();
-        localStorage.removeItem('user');
+        currentSession = null;
     }
 }
 
+// 读取 CSRF Cookie，刷新和退出会话时需要通过请求头回传
+function getCsrfToken() {
+    const match = document.cookie.match(/(?:^|;\s*)csrf_token=([^;]*)/);
+    return match ? decodeURIComponent(match[1]) : '';
+}
+
+// 使用刷新令牌 Cookie 换取新的访问令牌
+function refreshSession() {
+    return fetch(`${API_BASE_URL}/v1/sessions/refresh`, {
+        method: 'POST',
+        credentials: 'same-origin',
+        headers: { 'X-CSRF-Token': getCsrfToken() }
+    }).then(response => {
+        if (!response.ok) {
+            throw new Error(`API错误: ${response.status}`);
+        }
+        return response.json();
+    }).then(body => {
+        setAuthState(true, body.data);
+        return body.data;
+    });
+}
+
+// 按服务端配置显示密码登录或单点登录
+function loadAuthMethods() {
+    apiCall('GET', '/v1/auth/methods', null, false)
+        .then(methods => {
+            if (methods.oidc) {
+                $('#oidc-login-btn').attr('href', `${API_BASE_URL}${methods.oidc_login_url}`).show();
+            }
+            registrationMode = methods.registration || 'open';
+            $('#register-invite-field').toggle(registrationMode === 'invite');
+            if (registrationMode === 'closed') {
+                $('#register-link').hide();
+            }
+            if (!methods.password) {
+                passwordLoginEnabled = false;
+                $('#login-form .field, #login-btn, #register-link').hide();
+            }
+        })
+        .catch(error => console.error('获取登录方式失败:', error));
+}
+
 // 检查用户认证状态
 function checkAuthStatus() {
-    const user = localStorage.getItem('user');
-    if (user) {
-        try {
-            const userInfo = JSON.parse(user);
-            setAuthState(true, userInfo);
+    refreshSession()
+        .then(() => {
             showContent('home-content');
             loadArticles();
-        } catch (e) {
-            localStorage.removeItem('user');
+        })
+        .catch(() => {
+            setAuthState(false);
             showContent('login-form');
-        }
-    } else {
-        showContent('login-form');
-    }
+        });
 }
 
-// API调用函数
-function apiCall(method, endpoint, data = null, requiresAuth = true) {
+// API调用函数，访问令牌过期时自动刷新一次后重试
+function apiCall(method, endpoint, data = null, requiresAuth = true, retried = false) {
     const options = {
         method: method,
+        credentials: 'same-origin',
         headers: {
             'Content-Type': 'application/json'
         }
     };
     
-    if (requiresAuth) {
-        const user = localStorage.getItem('user');
-        if (user) {
-            const userInfo = JSON.parse(user);
-            options.headers['Authorization'] = `${userInfo.ApiKey}`;
-        }
+    if (requiresAuth && currentSession) {
+        options.headers['Authorization'] = `Bearer ${currentSession.access_token}`;
     }
     
     if (data) {
@@ -77,10 +120,14 @@ function apiCall(method, endpoint, data = null, requiresAuth = true) {
     
     return fetch(`${API_BASE_URL}${endpoint}`, options)
         .then(response => {
+            if (response.status === 401 && requiresAuth && !retried) {
+                return refreshSession().then(() => apiCall(method, endpoint, data, requiresAuth, true));
+            }
             if (!response.ok) {
                 throw new Error(`API错误: ${response.status}`);
             }
-            return response.json();
+            // 接口统一返回 {"data": ...}，这里直接取出 data
+            return response.json().then(body => body.data);
         });
 }
 
@@ -94,9 +141,9 @@ function loadArticles() {
             if (data && data.length > 0) {
                 data.forEach(post => {
                     const articleItem = $('<div class="article-item"></div>');
-                    const title = $(`<div class="article-title"><a href="${post.Url}" target="_blank">${post.Title}</a></div>`);
-                    const meta = $(`<div class="article-meta">来源: ${post.FeedName} | 发布时间: ${new Date(post.PublishedAt).toLocaleString()}</div>`);
-                    const content = $(`<div class="article-content article-content-expanded" style="color: grey; font-size: 12px;">${post.Description.String || '暂无内容'}</div>`);
+                    const title = $(`<div class="article-title"><a href="${post.url}" target="_blank">${post.title}</a></div>`);
+                    const meta = $(`<div class="article-meta">来源: ${post.feed_name} | 发布时间: ${new Date(post.published_at).toLocaleString()}</div>`);
+                    const content = $(`<div class="article-content article-content-expanded" style="color: grey; font-size: 12px;">${post.description || '暂无内容'}</div>`);
                     const divider = $('<hr class="article-divider">');
                     
                     articleItem.append(title).append(meta).append(content).append(divider);
@@ -122,13 +169,13 @@ function loadFeeds() {
             if (data && data.length > 0) {
                 data.forEach(feed => {
                     const feedItem = $('<div class="feed-item ui segment"></div>');
-                    const title = $(`<h4 class="ui header">${feed.FeedName}</h4>`);
-                    const url = $(`<p><a href="${feed.FeedUrl}" target="_blank">${feed.FeedUrl}</a></p>`);
+                    const title = $(`<h4 class="ui header">${feed.feed_name}</h4>`);
+                    const url = $(`<p><a href="${feed.feed_url}" target="_blank">${feed.feed_url}</a></p>`);
                     
                     // 取消订阅按钮
                     const unfollowBtn = $('<button class="ui button negative mini">取消订阅</button>');
                     unfollowBtn.click(function() {
-                        unfollowFeed(feed.FeedID);
+                        unfollowFeed(feed.feed_id);
                     });
                     
                     feedItem.append(title).append(url).append(unfollowBtn);
@@ -144,31 +191,53 @@ function loadFeeds() {
         });
 }
 
-// 加载广场订阅源（按订阅数排序）
-function loadSquareFeeds() {
-    apiCall('GET', '/v1/feeds', null, false)
+// 加载广场订阅源，append 为 true 时追加下一页
+const SQUARE_PAGE_SIZE = 20;
+let squareOffset = 0;
+
+function loadSquareFeeds(append = false) {
+    if (!append) {
+        squareOffset = 0;
+    }
+    const params = new URLSearchParams({
+        q: $('#square-search').val() || '',
+        sort: $('#square-sort').val() || 'popular',
+        limit: SQUARE_PAGE_SIZE,
+        offset: squareOffset
+    });
+    apiCall('GET', `/v1/feeds?${params.toString()}`)
         .then(data => {
             const container = $('#square-feeds-container');
-            container.empty();
+            if (!append) {
+                container.empty();
+            }
             
             if (data && data.length > 0) {
                 data.forEach(feed => {
                     const feedItem = $('<div class="square-feed-item ui segment"></div>');
-                    const title = $(`<h4 class="ui header">${feed.Name}</h4>`);
-                    const url = $(`<p><a href="${feed.Url}" target="_blank">${feed.Url}</a></p>`);
-                    const meta = $(`<p class="meta">订阅数: ${feed.FollowsCount.Int64 || 0} | 更新时间: ${new Date(feed.LastFetchedAt.Time).toLocaleDateString()}</p>`);
+                    const title = $(`<h4 class="ui header">${feed.name}</h4>`);
+                    const url = $(`<p><a href="${feed.url}" target="_blank">${feed.url}</a></p>`);
+                    const lastPost = feed.last_post_at ? new Date(feed.last_post_at).toLocaleDateString() : '暂无';
+                    const meta = $(`<p class="meta">订阅数: ${feed.follows_count || 0} | 最近更新: ${lastPost}</p>`);
                     
                     // 订阅按钮
-                    const followBtn = $('<button class="ui button primary mini">订阅</button>');
+                    const followBtn = feed.is_following
+                        ? $('<button class="ui button mini" disabled>已订阅</button>')
+                        : $('<button class="ui button primary mini">订阅</button>');
                     followBtn.click(function() {
-                        followFeed(feed.ID);
+                        followFeed(feed.id);
                     });
                     
                     feedItem.append(title).append(url).append(meta).append(followBtn);
                     container.append(feedItem);
                 });
+                squareOffset += data.length;
+                $('#square-more-btn').toggle(data.length === SQUARE_PAGE_SIZE);
             } else {
-                container.append('<p>暂无订阅源。</p>');
+                if (!append) {
+                    container.append('<p>暂无订阅源。</p>');
+                }
+                $('#square-more-btn').hide();
             }
         })
         .catch(error => {
@@ -252,6 +321,19 @@ $(document).ready(function() {
         loadSquareFeeds();
     });
     
+    // 广场搜索与排序
+    let squareSearchTimer = null;
+    $('#square-search').on('input', function() {
+        clearTimeout(squareSearchTimer);
+        squareSearchTimer = setTimeout(() => loadSquareFeeds(), 300);
+    });
+    $('#square-sort').change(function() {
+        loadSquareFeeds();
+    });
+    $('#square-more-btn').click(function() {
+        loadSquareFeeds(true);
+    });
+    
     // 登录按钮
     $('#login-btn').click(function() {
         const username = $('#login-username').val();
@@ -281,13 +363,22 @@ $(document).ready(function() {
     $('#register-btn').click(function() {
         const username = $('#register-username').val();
         const password = $('#register-password').val();
+        const inviteCode = $('#register-invite-code').val().trim();
         
         if (!username || !password) {
             showNotification('请输入用户名和密码', true);
             return;
         }
+        if (registrationMode === 'invite' && !inviteCode) {
+            showNotification('请输入邀请码', true);
+            return;
+        }
         
-        apiCall('POST', '/v1/users', { username: username, password: password }, false)
+        const body = { username: username, password: password };
+        if (inviteCode) {
+            body.invite_code = inviteCode;
+        }
+        apiCall('POST', '/v1/users', body, false)
             .then(data => {
                 if (data) {
                     setAuthState(true, data);
@@ -305,9 +396,15 @@ $(document).ready(function() {
     // 退出按钮
     $('#logout-link').click(function(e) {
         e.preventDefault();
-        setAuthState(false);
-        showContent('login-form');
-        showNotification('已退出登录');
+        fetch(`${API_BASE_URL}/v1/sessions/logout`, {
+            method: 'POST',
+            credentials: 'same-origin',
+            headers: { 'X-CSRF-Token': getCsrfToken() }
+        }).finally(() => {
+            setAuthState(false);
+            showContent('login-form');
+            showNotification('已退出登录');
+        });
     });
     
     // 添加订阅源按钮
@@ -322,5 +419,6 @@ $(document).ready(function() {
     });
     
     // 检查认证状态
+    loadAuthMethods();
     checkAuthStatus();
 });
\ No newline at end of file
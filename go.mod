@@ -14,4 +14,4 @@ require (
 
 require github.com/lib/pq v1.10.9
 
-require golang.org/x/crypto v0.42.0 // indirect
+require golang.org/x/crypto v0.42.0
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/opml"
+	"github.com/djchanahcjd/go-rss/ratelimit"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+// reauthWindow 没有密码的账号需要在这段时间内通过单点登录重新登录，才能执行敏感操作
+const reauthWindow = 5 * time.Minute
+
+const errRecentSignInRequired = "Recent sign-in required, sign in again with single sign-on (GET /v1/auth/oidc/login?reauth=1)"
+
+// verifyPassword 校验当前用户的密码，失败同样计入登录锁定，失败时已写入响应
+// 单点登录创建的账号没有密码，改为要求当前会话是刚通过单点登录建立的
+func (apiCfg *ApiConfig) verifyPassword(w http.ResponseWriter, r *http.Request, user db.User, password string) bool {
+	if user.Password == "" {
+		return apiCfg.verifyRecentLogin(w, r, user)
+	}
+	lockKey := ratelimit.LoginKey(user.Username, apiCfg.clientIP(r))
+	if apiCfg.loginLocked(w, r, lockKey) {
+		respondWithError(w, 429, "Too many failed password attempts, try again later")
+		return false
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		apiCfg.loginFailed(r, lockKey)
+		respondWithError(w, 403, "Incorrect password")
+		return false
+	}
+	return true
+}
+
+// verifyRecentLogin 要求请求使用 reauthWindow 内创建的会话，API Key 和较早的会话都需要先通过
+// GET /v1/auth/oidc/login?reauth=1 重新登录，失败时已写入响应
+func (apiCfg *ApiConfig) verifyRecentLogin(w http.ResponseWriter, r *http.Request, user db.User) bool {
+	sessionID := apiCfg.currentSessionID(r)
+	if !sessionID.Valid {
+		respondWithError(w, 403, errRecentSignInRequired)
+		return false
+	}
+	session, err := apiCfg.DB.GetSessionByID(r.Context(), db.GetSessionByIDParams{
+		ID:     sessionID.UUID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 403, errRecentSignInRequired)
+		return false
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error getting session: %v", err))
+		return false
+	}
+	if time.Since(session.CreatedAt) > reauthWindow {
+		respondWithError(w, 403, errRecentSignInRequired)
+		return false
+	}
+	return true
+}
+
+// UpdateProfile 修改用户名、显示名称或邮箱，未传的字段保持不变，显示名称和邮箱传空字符串时清除
+// PATCH /v1/users {"username": "...", "display_name": "...", "email": "..."}
+func (apiCfg *ApiConfig) UpdateProfile(w http.ResponseWriter, r *http.Request, user db.User) {
+	type parameters struct {
+		Username    *string `json:"username"`
+		DisplayName *string `json:"display_name"`
+		Email       *string `json:"email"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+
+	arg := db.UpdateUserProfileParams{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Email:       user.Email,
+	}
+	if params.Username != nil {
+		arg.Username = strings.TrimSpace(*params.Username)
+		if arg.Username == "" {
+			respondWithError(w, 400, "Username is required")
+			return
+		}
+	}
+	if params.DisplayName != nil {
+		arg.DisplayName = nullString(strings.TrimSpace(*params.DisplayName))
+	}
+	if params.Email != nil {
+		arg.Email = sql.NullString{}
+		if email := strings.TrimSpace(*params.Email); email != "" {
+			address, err := mail.ParseAddress(email)
+			if err != nil {
+				respondWithError(w, 400, fmt.Sprintf("Invalid email: %v", err))
+				return
+			}
+			arg.Email = nullString(address.Address)
+		}
+	}
+
+	updated, err := apiCfg.DB.UpdateUserProfile(r.Context(), arg)
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "Username is already taken")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error updating user: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewUser(updated))
+}
+
+// ChangePassword 修改密码，需要提供当前密码
+// PUT /v1/users/password {"current_password": "...", "new_password": "..."}
+// 修改后吊销除当前会话外的全部会话和全部 API Key，Fever 凭据需要重新设置
+func (apiCfg *ApiConfig) ChangePassword(w http.ResponseWriter, r *http.Request, user db.User) {
+	if apiCfg.PasswordLoginDisabled {
+		respondWithError(w, 403, errPasswordLoginDisabled)
+		return
+	}
+	type parameters struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	if !apiCfg.verifyPassword(w, r, user, params.CurrentPassword) {
+		return
+	}
+	if len(params.NewPassword) < minPasswordLength {
+		respondWithError(w, 400, fmt.Sprintf("Password must be at least %d characters", minPasswordLength))
+		return
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(params.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error hashing password: %v", err))
+		return
+	}
+	var revokedSessions int64
+	var keys []db.ApiKey
+	// 密码和吊销在同一个事务中完成，吊销失败时旧密码、旧会话和 API Key 都保持不变，可以重试
+	err = apiCfg.withTx(r.Context(), func(q *db.Queries) error {
+		if _, err := q.UpdateUserPassword(r.Context(), db.UpdateUserPasswordParams{
+			Password: string(hashedPassword),
+			ID:       user.ID,
+		}); err != nil {
+			return fmt.Errorf("Error updating password: %v", err)
+		}
+		revokedSessions, err = q.RevokeOtherSessions(r.Context(), db.RevokeOtherSessionsParams{
+			UserID: user.ID,
+			KeepID: apiCfg.currentSessionID(r),
+		})
+		if err != nil {
+			return fmt.Errorf("Error revoking sessions: %v", err)
+		}
+		keys, err = q.DeleteAPIKeysByUserID(r.Context(), user.ID)
+		if err != nil {
+			return fmt.Errorf("Error revoking API keys: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, 500, err.Error())
+		return
+	}
+	revokedKeys := int64(len(keys))
+	log.Printf("[User] %s changed password, revoked %d sessions and %d API keys", user.Username, revokedSessions, revokedKeys)
+	apiCfg.audit(r, user, auditEvent{
+		Action:     auditPasswordChange,
+		TargetType: auditTargetUser,
+		TargetID:   user.ID,
+		Details:    map[string]any{"revoked_sessions": revokedSessions, "revoked_api_keys": revokedKeys},
+	})
+	for _, key := range keys {
+		apiCfg.audit(r, user, auditEvent{
+			Action:     auditAPIKeyRevoke,
+			TargetType: auditTargetAPIKey,
+			TargetID:   key.ID,
+			Details:    map[string]any{"name": key.Name, "prefix": key.Prefix, "reason": "password_change"},
+		})
+	}
+
+	type response struct {
+		RevokedSessions int64 `json:"revoked_sessions"`
+		RevokedAPIKeys  int64 `json:"revoked_api_keys"`
+	}
+	respondWithJSON(w, 200, response{
+		RevokedSessions: revokedSessions,
+		RevokedAPIKeys:  revokedKeys,
+	})
+}
+
+// DeleteAccount 删除账号，需要提供密码确认
+// DELETE /v1/users {"password": "..."}
+// 仍有其他用户关注的订阅源转给最早关注且能接手的用户，其余订阅源连同文章一起删除
+// 作为唯一 owner 的团队交给其他成员，没有其他成员的团队一并删除
+func (apiCfg *ApiConfig) DeleteAccount(w http.ResponseWriter, r *http.Request, user db.User) {
+	type parameters struct {
+		Password string `json:"password"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	if !apiCfg.verifyPassword(w, r, user, params.Password) {
+		return
+	}
+
+	type response struct {
+		TransferredFeeds int `json:"transferred_feeds"`
+		DeletedFeeds     int `json:"deleted_feeds"`
+	}
+	resp := response{}
+	// 转让订阅源、团队和删除用户在同一个事务中完成，中途出错不会留下只删了一半的账号
+	err := apiCfg.withTx(r.Context(), func(q *db.Queries) error {
+		// 先交出团队，团队仍关注的订阅源才能转给新的团队 owner
+		if err := q.LockUserTeamOwners(r.Context(), user.ID); err != nil {
+			return fmt.Errorf("Error locking teams: %v", err)
+		}
+		if err := q.PromoteNextTeamOwners(r.Context(), user.ID); err != nil {
+			return fmt.Errorf("Error transferring teams: %v", err)
+		}
+		if _, err := q.DeleteSoleMemberTeams(r.Context(), user.ID); err != nil {
+			return fmt.Errorf("Error deleting teams: %v", err)
+		}
+
+		feeds, err := q.GetFeedsByUserID(r.Context(), user.ID)
+		if err != nil {
+			return fmt.Errorf("Error getting feeds: %v", err)
+		}
+		for _, feed := range feeds {
+			next, _, err := transferFeedToFollower(r.Context(), q, feed, user.ID)
+			if err != nil {
+				return fmt.Errorf("Error transferring feed: %v", err)
+			}
+			// 没有人能接手（配额已满或已有同名、同 URL 的订阅源）时随账号一起删除
+			if !next.Valid {
+				resp.DeletedFeeds++
+				continue
+			}
+			resp.TransferredFeeds++
+		}
+
+		n, err := q.DeleteUser(r.Context(), user.ID)
+		if err != nil {
+			return fmt.Errorf("Error deleting user: %v", err)
+		}
+		if n == 0 {
+			return errUserNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, errUserNotFound) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, err.Error())
+		return
+	}
+	log.Printf("[User] %s deleted account, %d feeds transferred, %d deleted", user.Username, resp.TransferredFeeds, resp.DeletedFeeds)
+	apiCfg.audit(r, user, auditEvent{
+		Action:     auditAccountDelete,
+		TargetType: auditTargetUser,
+		TargetID:   user.ID,
+		Details:    map[string]any{"transferred_feeds": resp.TransferredFeeds, "deleted_feeds": resp.DeletedFeeds},
+	})
+	apiCfg.clearSessionCookies(w)
+	respondWithJSON(w, 200, resp)
+}
+
+// ExportAccount 把账号数据导出为 ZIP
+// GET /v1/users/export
+// subscriptions.opml 为订阅和文件夹，account.json 为资料与设置，post_states.json 为阅读、收藏和标签状态
+func (apiCfg *ApiConfig) ExportAccount(w http.ResponseWriter, r *http.Request, user db.User) {
+	type accountExport struct {
+		ExportedAt time.Time             `json:"exported_at"`
+		User       api.User              `json:"user"`
+		Follows    []api.FollowedFeed    `json:"follows"`
+		Folders    []api.FolderWithFeeds `json:"folders"`
+		Rules      []api.Rule            `json:"rules"`
+		Webhooks   []api.Webhook         `json:"webhooks"`
+		Digest     *api.DigestSettings   `json:"digest"`
+	}
+	ctx := r.Context()
+	account := accountExport{
+		ExportedAt: time.Now().UTC(),
+		User:       api.NewUser(user),
+	}
+	follows, err := apiCfg.DB.GetFeedFollowsByUserID(ctx, user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting feed follows: %v", err))
+		return
+	}
+	account.Follows = api.List(follows, api.NewFollowedFeed)
+	account.Folders, err = apiCfg.userFolders(ctx, user.ID)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	userRules, err := apiCfg.DB.GetRulesByUserID(ctx, user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting rules: %v", err))
+		return
+	}
+	account.Rules = api.List(userRules, api.NewRule)
+	hooks, err := apiCfg.DB.GetWebhooksByUserID(ctx, user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting webhooks: %v", err))
+		return
+	}
+	account.Webhooks = api.List(hooks, api.NewWebhook)
+	settings, err := apiCfg.DB.GetDigestSettings(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 400, fmt.Sprintf("Error getting digest settings: %v", err))
+		return
+	}
+	if err == nil {
+		digest := api.NewDigestSettings(settings)
+		account.Digest = &digest
+	}
+	states, err := apiCfg.DB.GetPostStatesForExport(ctx, user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting post states: %v", err))
+		return
+	}
+	folders, ungrouped, err := apiCfg.userSubscriptions(r, user)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	// 先在内存中生成完整的 ZIP，出错时仍能返回 JSON 错误
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	err = writeZipFile(zw, "subscriptions.opml", func(f io.Writer) error {
+		return opml.Render(f, fmt.Sprintf("%s 的订阅", user.Username), folders, ungrouped)
+	})
+	if err == nil {
+		err = writeZipJSON(zw, "account.json", account)
+	}
+	if err == nil {
+		err = writeZipJSON(zw, "post_states.json", api.List(states, api.NewPostState))
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error creating export: %v", err))
+		return
+	}
+
+	filename := fmt.Sprintf("go-rss-%s-%s.zip", user.Username, account.ExportedAt.Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(200)
+	w.Write(buf.Bytes())
+}
+
+func writeZipFile(zw *zip.Writer, name string, render func(io.Writer) error) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return render(f)
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	return writeZipFile(zw, name, func(f io.Writer) error {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	})
+}
@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/apikeys"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/rss"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type adminUserKey struct{}
+
+// feedHealths 是可用于筛选的订阅源健康状态
+var feedHealths = map[string]bool{
+	rss.FeedHealthPending: true,
+	rss.FeedHealthHealthy: true,
+	rss.FeedHealthFailing: true,
+	rss.FeedHealthDead:    true,
+}
+
+// AdminMiddleware 要求请求者是管理员，并使用会话或 admin 权限的 API Key
+// 通过后把当前用户放入请求上下文，由 AdminHandler 取出
+func (apiCfg *ApiConfig) AdminMiddleware(next http.Handler) http.Handler {
+	return apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, func(w http.ResponseWriter, r *http.Request, user db.User) {
+		if user.Role != roleAdmin {
+			respondWithError(w, 403, "Admin role required")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminUserKey{}, user)))
+	})
+}
+
+// AdminHandler 把 AdminMiddleware 认证的管理员传给处理函数
+func (apiCfg *ApiConfig) AdminHandler(handler authedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(adminUserKey{}).(db.User)
+		if !ok {
+			respondWithError(w, 403, "Admin role required")
+			return
+		}
+		handler(w, r, user)
+	}
+}
+
+// AdminGetUsers 列出用户，总数通过 X-Total-Count 响应头返回
+// GET /v1/admin/users?q=&role=user|admin&disabled=true|false&limit=&offset=
+func (apiCfg *ApiConfig) AdminGetUsers(w http.ResponseWriter, r *http.Request, admin db.User) {
+	query := r.URL.Query()
+	role := query.Get("role")
+	if role != "" && role != roleUser && role != roleAdmin {
+		respondWithError(w, 400, fmt.Sprintf("Invalid role: %q", role))
+		return
+	}
+	disabled := sql.NullBool{}
+	if s := query.Get("disabled"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Invalid disabled: %q", s))
+			return
+		}
+		disabled = sql.NullBool{Bool: b, Valid: true}
+	}
+	limit, offset, err := parsePagination(r, 50, 200)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	users, err := apiCfg.DB.AdminListUsers(r.Context(), db.AdminListUsersParams{
+		Query:      nullString(strings.TrimSpace(query.Get("q"))),
+		Role:       nullString(role),
+		Disabled:   disabled,
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting users: %v", err))
+		return
+	}
+	total := int64(0)
+	if len(users) > 0 {
+		total = users[0].TotalCount
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	respondWithJSON(w, 200, api.List(users, api.NewAdminUserListItem))
+}
+
+// AdminUpdateUser 修改用户角色或停用、启用用户，停用时吊销其全部会话
+// PATCH /v1/admin/users/{userID} {"role": "user|admin", "disabled": true}
+// 配置了 OIDC_ADMIN_GROUP 时，单点登录用户的角色会在下次登录时按用户组重新同步
+func (apiCfg *ApiConfig) AdminUpdateUser(w http.ResponseWriter, r *http.Request, admin db.User) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing user_id: %v", err))
+		return
+	}
+	type parameters struct {
+		Role     *string `json:"role"`
+		Disabled *bool   `json:"disabled"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	if params.Role != nil && *params.Role != roleUser && *params.Role != roleAdmin {
+		respondWithError(w, 400, fmt.Sprintf("Invalid role: %q", *params.Role))
+		return
+	}
+	// 防止管理员把自己锁在门外
+	if userID == admin.ID && ((params.Role != nil && *params.Role != roleAdmin) || (params.Disabled != nil && *params.Disabled)) {
+		respondWithError(w, 400, "You cannot demote or disable yourself")
+		return
+	}
+
+	user, err := apiCfg.DB.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting user: %v", err))
+		return
+	}
+	changes := map[string]any{}
+	// 停用和吊销会话在同一个事务中完成，不会出现已停用但会话仍然有效的用户
+	err = apiCfg.withTx(r.Context(), func(q *db.Queries) error {
+		if params.Role != nil && *params.Role != user.Role {
+			user, err = q.SetUserRole(r.Context(), db.SetUserRoleParams{Role: *params.Role, ID: user.ID})
+			if err != nil {
+				return fmt.Errorf("Error updating role: %v", err)
+			}
+			changes["role"] = user.Role
+		}
+		if params.Disabled != nil && *params.Disabled != user.DisabledAt.Valid {
+			disabledAt := sql.NullTime{}
+			if *params.Disabled {
+				disabledAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+			}
+			user, err = q.SetUserDisabled(r.Context(), db.SetUserDisabledParams{DisabledAt: disabledAt, ID: user.ID})
+			if err != nil {
+				return fmt.Errorf("Error updating user: %v", err)
+			}
+			if *params.Disabled {
+				if _, err := q.RevokeOtherSessions(r.Context(), db.RevokeOtherSessionsParams{UserID: user.ID}); err != nil {
+					return fmt.Errorf("Error revoking sessions: %v", err)
+				}
+			}
+			changes["disabled"] = *params.Disabled
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, 500, err.Error())
+		return
+	}
+	if role, ok := changes["role"]; ok {
+		log.Printf("[Admin] %s set role of %s to %s", admin.Username, user.Username, role)
+	}
+	if disabled, ok := changes["disabled"]; ok {
+		log.Printf("[Admin] %s set disabled of %s to %t", admin.Username, user.Username, disabled)
+	}
+	if len(changes) > 0 {
+		apiCfg.audit(r, admin, auditEvent{
+			Action:     auditAdminUserUpdate,
+			TargetType: auditTargetUser,
+			TargetID:   user.ID,
+			Details:    changes,
+		})
+	}
+	respondWithJSON(w, 200, api.NewAdminUser(user))
+}
+
+// AdminGetFeeds 列出所有用户的订阅源，默认连续失败次数多的排在前面，总数通过 X-Total-Count 响应头返回
+// GET /v1/admin/feeds?q=&owner_id=&health=pending|healthy|failing|dead&limit=&offset=
+func (apiCfg *ApiConfig) AdminGetFeeds(w http.ResponseWriter, r *http.Request, admin db.User) {
+	query := r.URL.Query()
+	health := query.Get("health")
+	if health != "" && !feedHealths[health] {
+		respondWithError(w, 400, fmt.Sprintf("Invalid health: %q", health))
+		return
+	}
+	ownerID := uuid.NullUUID{}
+	if s := query.Get("owner_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Error parsing owner_id: %v", err))
+			return
+		}
+		ownerID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	limit, offset, err := parsePagination(r, 50, 200)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	feeds, err := apiCfg.DB.AdminListFeeds(r.Context(), db.AdminListFeedsParams{
+		Query:          nullString(strings.TrimSpace(query.Get("q"))),
+		OwnerID:        ownerID,
+		Health:         nullString(health),
+		DeadErrorCount: rss.DeadFeedErrorCount,
+		PageLimit:      limit,
+		PageOffset:     offset,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting feeds: %v", err))
+		return
+	}
+	total := int64(0)
+	if len(feeds) > 0 {
+		total = feeds[0].TotalCount
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	respondWithJSON(w, 200, api.List(feeds, api.NewAdminFeed))
+}
+
+// AdminUpdateFeed 修改任意订阅源的名称、URL 或分类，与所有者一样不能修改仍有其他人关注的订阅源的 URL
+// PUT /v1/admin/feeds/{feedID}
+func (apiCfg *ApiConfig) AdminUpdateFeed(w http.ResponseWriter, r *http.Request, admin db.User) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+	log.Printf("[Admin] %s updating feed %s", admin.Username, feed.ID)
+	apiCfg.updateFeed(w, r, admin, feed, auditAdminFeedUpdate)
+}
+
+// AdminDeleteFeed 删除任意订阅源，关注和文章一并删除，不会转让给其他关注者
+// DELETE /v1/admin/feeds/{feedID}
+func (apiCfg *ApiConfig) AdminDeleteFeed(w http.ResponseWriter, r *http.Request, admin db.User) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+	if err := apiCfg.DB.DeleteFeed(r.Context(), feed.ID); err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting feed: %v", err))
+		return
+	}
+	apiCfg.auditFeed(r, admin, auditAdminFeedDelete, feed, nil)
+	log.Printf("[Admin] %s deleted feed %s (%s)", admin.Username, feed.ID, feed.Url)
+	respondWithJSON(w, 200, api.DeleteFeedResult{Deleted: true})
+}
+
+// AdminRefetchFeed 让抓取器在下一轮优先重新抓取订阅源，返回排队后的状态
+// POST /v1/admin/feeds/{feedID}/refetch
+func (apiCfg *ApiConfig) AdminRefetchFeed(w http.ResponseWriter, r *http.Request, admin db.User) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+	feed, err := apiCfg.DB.QueueFeedForRefetch(r.Context(), feed.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Feed not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error queueing feed: %v", err))
+		return
+	}
+	apiCfg.auditFeed(r, admin, auditAdminFeedRefetch, feed, nil)
+	log.Printf("[Admin] %s queued feed %s for refetch", admin.Username, feed.ID)
+	respondWithJSON(w, 202, api.NewFeed(feed))
+}
+
+// AdminQueueRefetch 让抓取器在下一轮优先重新抓取指定健康状态的订阅源，不传 health 时全部重新抓取
+// POST /v1/admin/feeds/refetch?health=failing|dead
+func (apiCfg *ApiConfig) AdminQueueRefetch(w http.ResponseWriter, r *http.Request, admin db.User) {
+	health := r.URL.Query().Get("health")
+	if health != "" && !feedHealths[health] {
+		respondWithError(w, 400, fmt.Sprintf("Invalid health: %q", health))
+		return
+	}
+	n, err := apiCfg.DB.QueueFeedsForRefetch(r.Context(), db.QueueFeedsForRefetchParams{
+		Health:         nullString(health),
+		DeadErrorCount: rss.DeadFeedErrorCount,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error queueing feeds: %v", err))
+		return
+	}
+	apiCfg.audit(r, admin, auditEvent{
+		Action:  auditAdminFeedsQueue,
+		Details: map[string]any{"health": health, "queued": n},
+	})
+	log.Printf("[Admin] %s queued %d feeds for refetch", admin.Username, n)
+	type response struct {
+		Queued int64 `json:"queued"`
+	}
+	respondWithJSON(w, 202, response{Queued: n})
+}
+
+// AdminGetScraperHealth 汇总全部订阅源的抓取状态，并列出连续失败次数最多的订阅源
+// GET /v1/admin/scraper?limit=
+func (apiCfg *ApiConfig) AdminGetScraperHealth(w http.ResponseWriter, r *http.Request, admin db.User) {
+	limit, _, err := parsePagination(r, 20, 200)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	summary, err := apiCfg.DB.GetScraperHealth(r.Context(), db.GetScraperHealthParams{
+		DeadErrorCount: rss.DeadFeedErrorCount,
+		Since:          sql.NullTime{Time: time.Now().UTC().Add(-time.Hour), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error getting scraper health: %v", err))
+		return
+	}
+	unhealthy := []db.AdminListFeedsRow{}
+	for _, health := range []string{rss.FeedHealthDead, rss.FeedHealthFailing} {
+		feeds, err := apiCfg.DB.AdminListFeeds(r.Context(), db.AdminListFeedsParams{
+			Health:         nullString(health),
+			DeadErrorCount: rss.DeadFeedErrorCount,
+			PageLimit:      limit - int64(len(unhealthy)),
+		})
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error getting feeds: %v", err))
+			return
+		}
+		unhealthy = append(unhealthy, feeds...)
+		if int64(len(unhealthy)) >= limit {
+			break
+		}
+	}
+	respondWithJSON(w, 200, api.NewScraperHealth(summary, unhealthy))
+}
+
+// AdminGetStats 返回系统统计
+// GET /v1/admin/stats
+func (apiCfg *ApiConfig) AdminGetStats(w http.ResponseWriter, r *http.Request, admin db.User) {
+	stats, err := apiCfg.DB.GetSystemStats(r.Context(), time.Now().UTC().Add(-24*time.Hour))
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error getting stats: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewSystemStats(stats))
+}
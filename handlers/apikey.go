@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/apikeys"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// issueAPIKey 生成并保存新的 API Key，返回只此一次可见的明文
+func (apiCfg *ApiConfig) issueAPIKey(ctx context.Context, userID uuid.UUID, name, scope string, expiresAt sql.NullTime) (string, db.ApiKey, error) {
+	key, prefix, hash, err := apikeys.Generate()
+	if err != nil {
+		return "", db.ApiKey{}, err
+	}
+	apiKey, err := apiCfg.DB.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scope:     scope,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", db.ApiKey{}, err
+	}
+	return key, apiKey, nil
+}
+
+// CreateAPIKey 创建 API Key，scope 默认为 read-only
+// POST /v1/api-keys {"name": "...", "scope": "read-only|read-write|admin", "expires_at": "RFC3339"}
+func (apiCfg *ApiConfig) CreateAPIKey(w http.ResponseWriter, r *http.Request, user db.User) {
+	type parameters struct {
+		Name      string     `json:"name"`
+		Scope     string     `json:"scope"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" {
+		respondWithError(w, 400, "name is required")
+		return
+	}
+	if params.Scope == "" {
+		params.Scope = apikeys.ScopeReadOnly
+	}
+	if !apikeys.ValidScope(params.Scope) {
+		respondWithError(w, 400, fmt.Sprintf("Invalid scope: %q", params.Scope))
+		return
+	}
+	expiresAt := sql.NullTime{}
+	if params.ExpiresAt != nil {
+		if !params.ExpiresAt.After(time.Now()) {
+			respondWithError(w, 400, "expires_at must be in the future")
+			return
+		}
+		expiresAt = sql.NullTime{Time: params.ExpiresAt.UTC(), Valid: true}
+	}
+
+	key, apiKey, err := apiCfg.issueAPIKey(r.Context(), user.ID, params.Name, params.Scope, expiresAt)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error creating API key: %v", err))
+		return
+	}
+	apiCfg.auditAPIKeyCreate(r, user, apiKey)
+	resp := api.NewAPIKey(apiKey)
+	resp.Key = key
+	respondWithJSON(w, 201, resp)
+}
+
+// GetAPIKeys 列出用户的全部 API Key（不含明文）
+func (apiCfg *ApiConfig) GetAPIKeys(w http.ResponseWriter, r *http.Request, user db.User) {
+	keys, err := apiCfg.DB.GetAPIKeysByUserID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting API keys: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(keys, api.NewAPIKey))
+}
+
+// DeleteAPIKey 吊销 API Key，立即失效
+func (apiCfg *ApiConfig) DeleteAPIKey(w http.ResponseWriter, r *http.Request, user db.User) {
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing key_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.DeleteAPIKey(r.Context(), db.DeleteAPIKeyParams{
+		ID:     keyID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting API key: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "API key not found")
+		return
+	}
+	apiCfg.audit(r, user, auditEvent{
+		Action:     auditAPIKeyRevoke,
+		TargetType: auditTargetAPIKey,
+		TargetID:   keyID,
+	})
+	respondWithJSON(w, 200, struct{}{})
+}
+
+func (apiCfg *ApiConfig) auditAPIKeyCreate(r *http.Request, user db.User, apiKey db.ApiKey) {
+	apiCfg.audit(r, user, auditEvent{
+		Action:     auditAPIKeyCreate,
+		TargetType: auditTargetAPIKey,
+		TargetID:   apiKey.ID,
+		Details:    map[string]any{"name": apiKey.Name, "prefix": apiKey.Prefix, "scope": apiKey.Scope},
+	})
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// 审计日志的动作，按 . 分隔的前缀可用于筛选
+const (
+	auditLogin               = "auth.login"
+	auditLoginFailed         = "auth.login_failed"
+	auditPasswordChange      = "auth.password_change"
+	auditSessionRevoke       = "session.revoke"
+	auditAccountDelete       = "account.delete"
+	auditAPIKeyCreate        = "api_key.create"
+	auditAPIKeyRevoke        = "api_key.revoke"
+	auditFeedCreate          = "feed.create"
+	auditFeedUpdate          = "feed.update"
+	auditFeedDelete          = "feed.delete"
+	auditFeedTransfer        = "feed.transfer"
+	auditFeedTransferRequest = "feed.transfer_request"
+	auditFollowCreate        = "follow.create"
+	auditFollowDelete        = "follow.delete"
+	auditTeamFollowCreate    = "team_follow.create"
+	auditTeamFollowDelete    = "team_follow.delete"
+	auditAdminUserUpdate     = "admin.user.update"
+	auditAdminFeedUpdate     = "admin.feed.update"
+	auditAdminFeedDelete     = "admin.feed.delete"
+	auditAdminFeedRefetch    = "admin.feed.refetch"
+	auditAdminFeedsQueue     = "admin.feeds.refetch"
+	auditAdminInviteCreate   = "admin.invite.create"
+	auditAdminInviteRevoke   = "admin.invite.revoke"
+	auditAdminQuotaUpdate    = "admin.quota.update"
+)
+
+// 审计日志的目标类型
+const (
+	auditTargetUser    = "user"
+	auditTargetAPIKey  = "api_key"
+	auditTargetSession = "session"
+	auditTargetFeed    = "feed"
+	auditTargetTeam    = "team"
+	auditTargetInvite  = "invite"
+)
+
+// auditEvent 是一条待写入的审计记录，TargetID 为零值表示没有目标
+type auditEvent struct {
+	Action     string
+	TargetType string
+	TargetID   uuid.UUID
+	Details    map[string]any
+}
+
+// audit 写入审计日志，actor 为零值表示匿名请求（如用户名不存在的登录）
+// 写入失败只记录日志，不影响请求本身
+func (apiCfg *ApiConfig) audit(r *http.Request, actor db.User, event auditEvent) {
+	details := []byte("{}")
+	if len(event.Details) > 0 {
+		b, err := json.Marshal(event.Details)
+		if err != nil {
+			log.Printf("[Audit] Error encoding details of %s: %v", event.Action, err)
+		} else {
+			details = b
+		}
+	}
+	arg := db.CreateAuditEventParams{
+		ID:         uuid.New(),
+		Action:     event.Action,
+		TargetType: nullString(event.TargetType),
+		Details:    details,
+		Ip:         apiCfg.clientIP(r),
+		UserAgent:  r.UserAgent(),
+		CreatedAt:  time.Now().UTC(),
+	}
+	if actor.ID != uuid.Nil {
+		arg.ActorID = uuid.NullUUID{UUID: actor.ID, Valid: true}
+		arg.ActorUsername = nullString(actor.Username)
+	}
+	if event.TargetID != uuid.Nil {
+		arg.TargetID = uuid.NullUUID{UUID: event.TargetID, Valid: true}
+	}
+	// 请求被取消时仍然要留下记录
+	ctx := context.WithoutCancel(r.Context())
+	if err := apiCfg.DB.CreateAuditEvent(ctx, arg); err != nil {
+		log.Printf("[Audit] Error recording %s: %v", event.Action, err)
+	}
+}
+
+// auditLoginResult 记录登录结果，reason 为空表示成功
+// 登录失败时操作者未知，尝试的用户名记在 details 中，用户存在时以其为目标
+func (apiCfg *ApiConfig) auditLoginResult(r *http.Request, method, username string, user db.User, reason string) {
+	if reason == "" {
+		apiCfg.audit(r, user, auditEvent{
+			Action:     auditLogin,
+			TargetType: auditTargetUser,
+			TargetID:   user.ID,
+			Details:    map[string]any{"method": method},
+		})
+		return
+	}
+	event := auditEvent{
+		Action:  auditLoginFailed,
+		Details: map[string]any{"method": method, "username": username, "reason": reason},
+	}
+	if user.ID != uuid.Nil {
+		event.TargetType = auditTargetUser
+		event.TargetID = user.ID
+	}
+	apiCfg.audit(r, db.User{}, event)
+}
+
+// auditFeed 记录针对订阅源的操作，附带订阅源名称和 URL，删除后仍可查看
+func (apiCfg *ApiConfig) auditFeed(r *http.Request, actor db.User, action string, feed db.Feed, details map[string]any) {
+	if details == nil {
+		details = map[string]any{}
+	}
+	details["name"] = feed.Name
+	details["url"] = feed.Url
+	apiCfg.audit(r, actor, auditEvent{
+		Action:     action,
+		TargetType: auditTargetFeed,
+		TargetID:   feed.ID,
+		Details:    details,
+	})
+}
+
+// AdminGetAuditEvents 查询审计日志，总数通过 X-Total-Count 响应头返回
+// GET /v1/admin/audit?actor_id=&user_id=&action=&target_type=&target_id=&since=&until=&limit=&offset=
+// user_id 匹配该用户执行的以及以该用户为目标的操作，action 可以是前缀（如 admin）
+func (apiCfg *ApiConfig) AdminGetAuditEvents(w http.ResponseWriter, r *http.Request, admin db.User) {
+	arg, err := parseAuditFilter(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	query := r.URL.Query()
+	if arg.ActorID, err = parseNullUUID(query.Get("actor_id"), "actor_id"); err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	if arg.SubjectID, err = parseNullUUID(query.Get("user_id"), "user_id"); err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	arg.TargetType = nullString(query.Get("target_type"))
+	if arg.TargetID, err = parseNullUUID(query.Get("target_id"), "target_id"); err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	apiCfg.respondAuditEvents(w, r, arg)
+}
+
+// GetAuditEvents 查询当前用户的审计日志，包括自己的操作和针对自己账号的操作（如登录失败）
+// GET /v1/users/audit?action=&since=&until=&limit=&offset=
+func (apiCfg *ApiConfig) GetAuditEvents(w http.ResponseWriter, r *http.Request, user db.User) {
+	arg, err := parseAuditFilter(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	arg.SubjectID = uuid.NullUUID{UUID: user.ID, Valid: true}
+	apiCfg.respondAuditEvents(w, r, arg)
+}
+
+func (apiCfg *ApiConfig) respondAuditEvents(w http.ResponseWriter, r *http.Request, arg db.ListAuditEventsParams) {
+	events, err := apiCfg.DB.ListAuditEvents(r.Context(), arg)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting audit events: %v", err))
+		return
+	}
+	total := int64(0)
+	if len(events) > 0 {
+		total = events[0].TotalCount
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	respondWithJSON(w, 200, api.List(events, api.NewAuditEvent))
+}
+
+// parseAuditFilter 解析管理员和用户共用的筛选参数：action、since、until（RFC3339）和分页
+func parseAuditFilter(r *http.Request) (db.ListAuditEventsParams, error) {
+	query := r.URL.Query()
+	arg := db.ListAuditEventsParams{
+		Action: nullString(strings.TrimSpace(query.Get("action"))),
+	}
+	var err error
+	if arg.Since, err = parseNullTime(query.Get("since"), "since"); err != nil {
+		return arg, err
+	}
+	if arg.Until, err = parseNullTime(query.Get("until"), "until"); err != nil {
+		return arg, err
+	}
+	limit, offset, err := parsePagination(r, 50, 200)
+	if err != nil {
+		return arg, err
+	}
+	arg.PageLimit, arg.PageOffset = limit, offset
+	return arg, nil
+}
+
+func parseNullUUID(s, name string) (uuid.NullUUID, error) {
+	if s == "" {
+		return uuid.NullUUID{}, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("Error parsing %s: %v", name, err)
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+func parseNullTime(s, name string) (sql.NullTime, error) {
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("invalid %s: %q", name, s)
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
@@ -1,31 +1,61 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
+	"strconv"
 
+	"github.com/djchanahcjd/go-rss/api"
 	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/oidc"
+	"github.com/djchanahcjd/go-rss/ratelimit"
+	"github.com/djchanahcjd/go-rss/stream"
+	"github.com/lib/pq"
 )
 
 type ApiConfig struct {
 	DB *db.Queries
+	// Conn 是 DB 使用的连接池，用于开启事务
+	Conn    *sql.DB
+	BaseURL string
+	// Stream 为空时实时推送不可用
+	Stream *stream.Hub
+	// SessionSecret 访问令牌和 CSRF 令牌的签名密钥
+	SessionSecret []byte
+	// RateLimits 限流令牌桶的存储，为空时不限流
+	RateLimits ratelimit.Store
+	// OIDC 为空时不提供单点登录
+	OIDC *oidc.Provider
+	// PasswordLoginDisabled 为 true 时禁止使用密码注册和登录
+	PasswordLoginDisabled bool
+	// RegistrationMode 注册方式，为 registrationOpen、registrationInvite 或 registrationClosed
+	RegistrationMode string
+	// TrustedProxies 反向代理的网段，为空时不采信 X-Forwarded-For
+	TrustedProxies []netip.Prefix
 }
 
 func respondWithError(w http.ResponseWriter, code int, msg string) {
 	if code > 499 {
 		log.Println("Server error:", msg)
 	}
-	type ErrorResponse struct {
-		Error string `json:"error"`
-	}
-
-	respondWithJSON(w, code, ErrorResponse{
+	writeJSON(w, code, api.Error{
 		Error: msg,
 	})
 }
 
+// respondWithJSON 把响应数据包在 {"data": ...} 中返回
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	writeJSON(w, code, api.Envelope{Data: payload})
+}
+
+// writeJSON 原样输出 JSON，用于 Google Reader、Fever 等需要兼容既有协议格式的接口
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	if err := json.NewEncoder(w).Encode(payload); err != nil {
@@ -36,3 +66,56 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 func HealthzHandler(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, 200, struct{}{})
 }
+
+// parsePagination 解析 limit/offset 查询参数
+func parsePagination(r *http.Request, defaultLimit, maxLimit int64) (int64, int64, error) {
+	limit, offset := defaultLimit, int64(0)
+	query := r.URL.Query()
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("invalid limit: %q", s)
+		}
+		limit = min(n, maxLimit)
+	}
+	if s := query.Get("offset"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid offset: %q", s)
+		}
+		offset = n
+	}
+	return limit, offset, nil
+}
+
+// nullString 把空字符串转换为 SQL NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullInt32 把 nil 转换为 SQL NULL
+func nullInt32(n *int32) sql.NullInt32 {
+	if n == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: *n, Valid: true}
+}
+
+// withTx 在同一个事务中执行 fn，fn 返回错误时回滚
+func (apiCfg *ApiConfig) withTx(ctx context.Context, fn func(q *db.Queries) error) error {
+	tx, err := apiCfg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(apiCfg.DB.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// isUniqueViolation 判断是否违反唯一约束
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
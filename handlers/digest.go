@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/digest"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// GetDigestSettings 获取邮件摘要设置
+func (apiCfg *ApiConfig) GetDigestSettings(w http.ResponseWriter, r *http.Request, user db.User) {
+	settings, err := apiCfg.DB.GetDigestSettings(r.Context(), user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Digest not configured")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting digest settings: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewDigestSettings(settings))
+}
+
+// UpdateDigestSettings 创建或修改邮件摘要设置，并重新计算下一次发送时间
+func (apiCfg *ApiConfig) UpdateDigestSettings(w http.ResponseWriter, r *http.Request, user db.User) {
+	type parameters struct {
+		Email     string      `json:"email"`
+		Frequency string      `json:"frequency"`
+		SendHour  *int        `json:"send_hour"`
+		Weekday   *int        `json:"weekday"`
+		Timezone  string      `json:"timezone"`
+		FolderIDs []uuid.UUID `json:"folder_ids"`
+		Enabled   *bool       `json:"enabled"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+
+	address, err := mail.ParseAddress(params.Email)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Invalid email: %v", err))
+		return
+	}
+	switch params.Frequency {
+	case "":
+		params.Frequency = digest.FrequencyDaily
+	case digest.FrequencyDaily, digest.FrequencyWeekly:
+	default:
+		respondWithError(w, 400, fmt.Sprintf("Invalid frequency: %q", params.Frequency))
+		return
+	}
+	sendHour, weekday := 8, 1
+	if params.SendHour != nil {
+		sendHour = *params.SendHour
+	}
+	if params.Weekday != nil {
+		weekday = *params.Weekday
+	}
+	if sendHour < 0 || sendHour > 23 {
+		respondWithError(w, 400, "send_hour must be between 0 and 23")
+		return
+	}
+	if weekday < 0 || weekday > 6 {
+		respondWithError(w, 400, "weekday must be between 0 (Sunday) and 6")
+		return
+	}
+	if params.Timezone == "" {
+		params.Timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(params.Timezone)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Invalid timezone: %q", params.Timezone))
+		return
+	}
+	folderIDs := []uuid.UUID{}
+	for _, id := range params.FolderIDs {
+		_, err := apiCfg.DB.GetFolderByID(r.Context(), db.GetFolderByIDParams{
+			ID:     id,
+			UserID: user.ID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 404, fmt.Sprintf("Folder not found: %s", id))
+			return
+		}
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Error getting folder: %v", err))
+			return
+		}
+		folderIDs = append(folderIDs, id)
+	}
+
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error generating unsubscribe token: %v", err))
+		return
+	}
+	settings, err := apiCfg.DB.UpsertDigestSettings(r.Context(), db.UpsertDigestSettingsParams{
+		UserID:           user.ID,
+		Email:            address.Address,
+		Frequency:        params.Frequency,
+		SendHour:         int32(sendHour),
+		Weekday:          int32(weekday),
+		Timezone:         loc.String(),
+		FolderIds:        folderIDs,
+		Enabled:          params.Enabled == nil || *params.Enabled,
+		UnsubscribeToken: hex.EncodeToString(token),
+		NextSendAt:       digest.NextSendAt(time.Now(), params.Frequency, sendHour, weekday, loc),
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error saving digest settings: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewDigestSettings(settings))
+}
+
+// DeleteDigestSettings 删除邮件摘要设置
+func (apiCfg *ApiConfig) DeleteDigestSettings(w http.ResponseWriter, r *http.Request, user db.User) {
+	n, err := apiCfg.DB.DeleteDigestSettings(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting digest settings: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Digest not configured")
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// PreviewDigest 按当前设置渲染下一封摘要，不会发送也不影响发送记录
+// GET /v1/digest/preview?format=html|text
+func (apiCfg *ApiConfig) PreviewDigest(w http.ResponseWriter, r *http.Request, user db.User) {
+	settings, err := apiCfg.DB.GetDigestSettings(r.Context(), user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Digest not configured")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting digest settings: %v", err))
+		return
+	}
+	since := time.Now().UTC().Add(-digest.Period(settings.Frequency))
+	if settings.LastSentAt.Valid {
+		since = settings.LastSentAt.Time
+	}
+	d, err := digest.Load(r.Context(), apiCfg.DB, settings, user, since, apiCfg.BaseURL)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error loading digest: %v", err))
+		return
+	}
+	html, text, err := d.Render()
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error rendering digest: %v", err))
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(200)
+		w.Write([]byte(text))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write([]byte(html))
+}
+
+// unsubscribePage 是退订确认页面，表单提交到当前地址
+const unsubscribePage = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>退订 Go RSS 摘要邮件</title></head>
+<body style="font-family:sans-serif;max-width:480px;margin:48px auto;text-align:center;">
+  <p>确定不再接收 Go RSS 摘要邮件吗？可随时在设置中重新开启。</p>
+  <form method="post"><button type="submit">确认退订</button></form>
+</body>
+</html>
+`
+
+// ConfirmUnsubscribeDigest 显示退订确认页面，GET 请求不修改任何数据
+// GET /digest/unsubscribe/{token}
+func (apiCfg *ApiConfig) ConfirmUnsubscribeDigest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(200)
+	w.Write([]byte(unsubscribePage))
+}
+
+// UnsubscribeDigest 通过邮件中的退订链接关闭摘要，无需登录
+// POST /digest/unsubscribe/{token}，由确认页面或邮件客户端的一键退订（RFC 8058）发起
+func (apiCfg *ApiConfig) UnsubscribeDigest(w http.ResponseWriter, r *http.Request) {
+	n, err := apiCfg.DB.UnsubscribeDigest(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error unsubscribing: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Invalid unsubscribe link")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write([]byte("已退订 Go RSS 摘要邮件，可随时在设置中重新开启。\n"))
+}
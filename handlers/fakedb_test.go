@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+)
+
+// fakeQuery 模拟一条 sqlc 查询，返回结果行；:exec/:execrows 查询以行数作为受影响的行数
+type fakeQuery func(args []driver.Value) ([][]driver.Value, error)
+
+// fakeCall 记录一次查询调用
+type fakeCall struct {
+	name string
+	args []driver.Value
+}
+
+// fakeDB 是 handler 测试用的内存数据库，按查询注释中的 sqlc 查询名分发给测试注册的 fakeQuery
+// 所有查询串行执行，事务只是空操作，未注册的查询返回错误
+type fakeDB struct {
+	mu      sync.Mutex
+	queries map[string]fakeQuery
+	calls   []fakeCall
+}
+
+var fakeQueryName = regexp.MustCompile(`-- name: (\w+)`)
+
+// newFakeDB 返回使用 fake 的 ApiConfig，测试结束时关闭连接
+func newFakeDB(t *testing.T, queries map[string]fakeQuery) (*fakeDB, *ApiConfig) {
+	t.Helper()
+	fake := &fakeDB{queries: queries}
+	conn := sql.OpenDB(fake)
+	t.Cleanup(func() { conn.Close() })
+	return fake, &ApiConfig{DB: db.New(conn), Conn: conn, SessionSecret: []byte("test secret")}
+}
+
+// called 返回按顺序调用过的查询名
+func (f *fakeDB) called() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.calls))
+	for _, c := range f.calls {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// callsTo 返回某个查询每次调用的参数
+func (f *fakeDB) callsTo(name string) [][]driver.Value {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var args [][]driver.Value
+	for _, c := range f.calls {
+		if c.name == name {
+			args = append(args, c.args)
+		}
+	}
+	return args
+}
+
+func (f *fakeDB) run(query string, args []driver.Value) ([][]driver.Value, error) {
+	m := fakeQueryName.FindStringSubmatch(query)
+	if m == nil {
+		return nil, fmt.Errorf("query without a name: %s", query)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fakeCall{name: m[1], args: args})
+	fn, ok := f.queries[m[1]]
+	if !ok {
+		return nil, fmt.Errorf("unexpected query %s", m[1])
+	}
+	return fn(args)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ f *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.f, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	f     *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	rows, err := s.f.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, err := s.f.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+// fakeNoRows 模拟没有结果或不关心结果的查询
+func fakeNoRows([]driver.Value) ([][]driver.Value, error) { return nil, nil }
+
+// fakeFail 模拟执行失败的查询
+func fakeFail([]driver.Value) ([][]driver.Value, error) {
+	return nil, errors.New("connection reset")
+}
+
+// fakeInt64Array 解析 pq.Array 编码的整数数组参数
+func fakeInt64Array(v driver.Value) []int64 {
+	s, _ := v.(string)
+	if s == "" {
+		if b, ok := v.([]byte); ok {
+			s = string(b)
+		}
+	}
+	s = strings.Trim(s, "{}")
+	if s == "" {
+		return nil
+	}
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// fakeUserRow 按 users 表的列顺序返回一行
+func fakeUserRow(u db.User) []driver.Value {
+	return []driver.Value{
+		u.ID.String(), u.Username, u.Password, u.CreatedAt, u.UpdatedAt,
+		fakeNull(u.FeedTokenHash), fakeNull(u.FeverApiKey), fakeNull(u.DisplayName), fakeNull(u.Email),
+		u.Role, fakeNull(u.DisabledAt), fakeNull(u.InviteID),
+		fakeNull(u.MaxFeeds), fakeNull(u.MaxFollows), fakeNull(u.MaxWebhooks),
+	}
+}
+
+// fakeNull 把 sql.Null* 等可为空的值转换为驱动值
+func fakeNull(v driver.Valuer) driver.Value {
+	value, err := v.Value()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
@@ -1,19 +1,29 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/djchanahcjd/go-rss/api"
 	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/rss"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 func (apiCfg *ApiConfig) CreateFeed(w http.ResponseWriter, r *http.Request, user db.User) {
 	type parameters struct {
-		Name string `json:"name"`
-		Url  string `json:"url"`
+		Name     string `json:"name"`
+		Url      string `json:"url"`
+		Category string `json:"category"`
 	}
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
@@ -22,22 +32,30 @@ func (apiCfg *ApiConfig) CreateFeed(w http.ResponseWriter, r *http.Request, user
 		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
 		return
 	}
-
-	feed, err := apiCfg.DB.CreateFeed(r.Context(), db.CreateFeedParams{
-		ID:        uuid.New(),
-		Name:      params.Name,
-		Url:       params.Url,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		UserID:    user.ID,
-	})
-	if err != nil {
-		respondWithError(w, 400, fmt.Sprintf("Error creating feed: %v", err))
-		return
-	}
-	// 用户会自动follow自己创建的feed
-	go func() {
-		_, err := apiCfg.DB.CreateFeedFollow(r.Context(), db.CreateFeedFollowParams{
+	var feed db.Feed
+	err = apiCfg.withQuota(r.Context(), user.ID, func(q *db.Queries) error {
+		// 创建者会自动关注新的订阅源，两项配额都要满足
+		if err := checkQuota(r.Context(), q, user.ID, quotaFeeds); err != nil {
+			return err
+		}
+		if err := checkQuota(r.Context(), q, user.ID, quotaFollows); err != nil {
+			return err
+		}
+		var err error
+		feed, err = q.CreateFeed(r.Context(), db.CreateFeedParams{
+			ID:        uuid.New(),
+			Name:      params.Name,
+			Url:       params.Url,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			Category:  nullString(strings.TrimSpace(params.Category)),
+		})
+		if err != nil {
+			return fmt.Errorf("Error creating feed: %v", err)
+		}
+		// 用户会自动follow自己创建的feed
+		_, err = q.CreateFeedFollow(r.Context(), db.CreateFeedFollowParams{
 			ID:        uuid.New(),
 			CreatedAt: time.Now().UTC(),
 			UpdatedAt: time.Now().UTC(),
@@ -45,19 +63,87 @@ func (apiCfg *ApiConfig) CreateFeed(w http.ResponseWriter, r *http.Request, user
 			FeedID:    feed.ID,
 		})
 		if err != nil {
-			fmt.Printf("Error following feed: %v", err)
+			return fmt.Errorf("Error following feed: %v", err)
 		}
-	}()
-	respondWithJSON(w, 201, feed)
+		return nil
+	})
+	if isQuotaExceeded(err) {
+		respondWithError(w, 403, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	apiCfg.auditFeed(r, user, auditFeedCreate, feed, nil)
+	apiCfg.auditFeed(r, user, auditFollowCreate, feed, nil)
+	respondWithJSON(w, 201, api.NewFeed(feed))
 }
 
+// GetAllFeeds 订阅源广场，登录用户会额外得到 is_following 标记
+// GET /v1/feeds?q=&language=&category=&sort=popular|newest|active&limit=&offset=
+// 总数通过 X-Total-Count 响应头返回
 func (apiCfg *ApiConfig) GetAllFeeds(w http.ResponseWriter, r *http.Request) {
-	feeds, err := apiCfg.DB.GetAllFeeds(r.Context())
+	query := r.URL.Query()
+	sort := query.Get("sort")
+	switch sort {
+	case "":
+		sort = "popular"
+	case "popular", "newest", "active":
+	default:
+		respondWithError(w, 400, fmt.Sprintf("Invalid sort: %q", sort))
+		return
+	}
+	limit, offset, err := parsePagination(r, 20, 100)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	userID := uuid.NullUUID{}
+	if user, ok := apiCfg.optionalUser(r); ok {
+		userID = uuid.NullUUID{UUID: user.ID, Valid: true}
+	}
+
+	feeds, err := apiCfg.DB.ListFeeds(r.Context(), db.ListFeedsParams{
+		UserID:     userID,
+		Query:      nullString(strings.TrimSpace(query.Get("q"))),
+		Language:   nullString(query.Get("language")),
+		Category:   nullString(query.Get("category")),
+		Sort:       sort,
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
 	if err != nil {
 		respondWithError(w, 400, fmt.Sprintf("Error getting feeds: %v", err))
 		return
 	}
-	respondWithJSON(w, 200, feeds)
+	total := int64(0)
+	if len(feeds) > 0 {
+		total = feeds[0].TotalCount
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	respondWithJSON(w, 200, api.List(feeds, api.NewFeedListItem))
+}
+
+// GetRecommendedFeeds 根据共同关注与分类/语言相似度推荐用户尚未关注的订阅源，失效的订阅源不会被推荐
+// GET /v1/feeds/recommended?limit=
+func (apiCfg *ApiConfig) GetRecommendedFeeds(w http.ResponseWriter, r *http.Request, user db.User) {
+	limit, _, err := parsePagination(r, 20, 50)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	feeds, err := apiCfg.DB.GetRecommendedFeeds(r.Context(), db.GetRecommendedFeedsParams{
+		UserID:         user.ID,
+		DeadErrorCount: rss.DeadFeedErrorCount,
+		PageLimit:      limit,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting recommendations: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(feeds, api.NewRecommendedFeed))
 }
 
 func (apiCfg *ApiConfig) GetFeedsByUser(w http.ResponseWriter, r *http.Request, user db.User) {
@@ -66,5 +152,374 @@ func (apiCfg *ApiConfig) GetFeedsByUser(w http.ResponseWriter, r *http.Request,
 		respondWithError(w, 400, fmt.Sprintf("Error getting feeds: %v", err))
 		return
 	}
-	respondWithJSON(w, 200, feeds)
+	respondWithJSON(w, 200, api.List(feeds, api.NewFeed))
+}
+
+// GetFeed 订阅源详情：频道信息、关注数、发文频率、抓取状态和最近的文章
+// GET /v1/feeds/{feedID}?posts=10
+func (apiCfg *ApiConfig) GetFeed(w http.ResponseWriter, r *http.Request) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feedID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing feed_id: %v", err))
+		return
+	}
+	postsLimit := int64(10)
+	if s := r.URL.Query().Get("posts"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			respondWithError(w, 400, fmt.Sprintf("Invalid posts: %q", s))
+			return
+		}
+		postsLimit = min(n, 50)
+	}
+
+	userID := uuid.NullUUID{}
+	if user, ok := apiCfg.optionalUser(r); ok {
+		userID = uuid.NullUUID{UUID: user.ID, Valid: true}
+	}
+	feed, err := apiCfg.DB.GetFeedDetail(r.Context(), db.GetFeedDetailParams{
+		UserID: userID,
+		ID:     feedID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Feed not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting feed: %v", err))
+		return
+	}
+	posts, err := apiCfg.DB.GetRecentPostsByFeed(r.Context(), db.GetRecentPostsByFeedParams{
+		FeedID: feedID,
+		Limit:  postsLimit,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting posts: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewFeedDetail(feed, posts))
+}
+
+// ownedFeed 读取路径中的订阅源并校验当前用户是否为所有者，失败时已写入响应
+func (apiCfg *ApiConfig) ownedFeed(w http.ResponseWriter, r *http.Request, user db.User) (db.Feed, bool) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return db.Feed{}, false
+	}
+	if feed.UserID != user.ID {
+		respondWithError(w, 403, "Only the feed owner can do this")
+		return db.Feed{}, false
+	}
+	return feed, true
+}
+
+// feedFromURL 读取路径中的订阅源，失败时已写入响应
+func (apiCfg *ApiConfig) feedFromURL(w http.ResponseWriter, r *http.Request) (db.Feed, bool) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feedID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing feed_id: %v", err))
+		return db.Feed{}, false
+	}
+	feed, err := apiCfg.DB.GetFeedByID(r.Context(), feedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Feed not found")
+		return db.Feed{}, false
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting feed: %v", err))
+		return db.Feed{}, false
+	}
+	return feed, true
+}
+
+// UpdateFeed 修改订阅源名称、URL 或分类，仅所有者可操作，未传的字段保持不变
+// 修改 URL 会改变所有关注者收到的内容，因此还有其他人关注（含团队关注）时返回 409
+func (apiCfg *ApiConfig) UpdateFeed(w http.ResponseWriter, r *http.Request, user db.User) {
+	feed, ok := apiCfg.ownedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	apiCfg.updateFeed(w, r, user, feed, auditFeedUpdate)
+}
+
+// updateFeed 按请求修改订阅源，调用方负责权限校验，修改前后的值以 action 记入审计日志
+func (apiCfg *ApiConfig) updateFeed(w http.ResponseWriter, r *http.Request, actor db.User, feed db.Feed, action string) {
+	type parameters struct {
+		Name     *string `json:"name"`
+		Url      *string `json:"url"`
+		Category *string `json:"category"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+
+	arg := db.UpdateFeedParams{
+		ID:       feed.ID,
+		Name:     feed.Name,
+		Url:      feed.Url,
+		Category: feed.Category,
+	}
+	if params.Name != nil {
+		arg.Name = strings.TrimSpace(*params.Name)
+	}
+	if params.Url != nil {
+		arg.Url = strings.TrimSpace(*params.Url)
+	}
+	if params.Category != nil {
+		arg.Category = nullString(strings.TrimSpace(*params.Category))
+	}
+	if arg.Name == "" || arg.Url == "" {
+		respondWithError(w, 400, "Feed name and url are required")
+		return
+	}
+
+	updated, err := apiCfg.DB.UpdateFeed(r.Context(), arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 409, "Feed is followed by other users, its url cannot be changed; create a new feed for the new url instead")
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "The owner already has a feed with this name or url")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error updating feed: %v", err))
+		return
+	}
+	apiCfg.auditFeed(r, actor, action, updated, map[string]any{
+		"before": map[string]any{"name": feed.Name, "url": feed.Url, "category": feed.Category.String},
+	})
+	respondWithJSON(w, 200, api.NewFeed(updated))
+}
+
+// DeleteFeed 删除订阅源，仅所有者可操作
+// 仍有其他用户关注时，所有权转给最早关注的用户，只有团队关注时转给团队的 owner，所有者只取消自己的关注；?force=true 时直接删除
+// 关注者都无法接手（配额已满或已有同名、同 URL 的订阅源）时返回 409
+func (apiCfg *ApiConfig) DeleteFeed(w http.ResponseWriter, r *http.Request, user db.User) {
+	feed, ok := apiCfg.ownedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	if r.URL.Query().Get("force") != "true" {
+		var next uuid.NullUUID
+		var followed bool
+		err := apiCfg.withTx(r.Context(), func(q *db.Queries) error {
+			var err error
+			next, followed, err = transferFeedToFollower(r.Context(), q, feed, user.ID)
+			if err != nil || !next.Valid {
+				return err
+			}
+			err = q.DeleteFeedFollow(r.Context(), db.DeleteFeedFollowParams{
+				UserID: user.ID,
+				FeedID: feed.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("error deleting feed follow: %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Error transferring feed: %v", err))
+			return
+		}
+		if followed && !next.Valid {
+			respondWithError(w, 409, "Feed is still followed but no follower can take it over, use force=true to delete it")
+			return
+		}
+		if next.Valid {
+			nextOwner := next.UUID
+			apiCfg.auditFeed(r, user, auditFeedTransfer, feed, map[string]any{"to_user_id": nextOwner, "reason": "owner_left"})
+			apiCfg.auditFeed(r, user, auditFollowDelete, feed, nil)
+			log.Printf("[Feed] %s left feed %s, ownership transferred to %s", user.Username, feed.ID, nextOwner)
+			respondWithJSON(w, 200, api.DeleteFeedResult{TransferredTo: &nextOwner})
+			return
+		}
+	}
+
+	err := apiCfg.DB.DeleteFeed(r.Context(), feed.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting feed: %v", err))
+		return
+	}
+	apiCfg.auditFeed(r, user, auditFeedDelete, feed, nil)
+	respondWithJSON(w, 200, api.DeleteFeedResult{Deleted: true})
+}
+
+// transferFeedToFollower 在事务中把订阅源转给第一个能接手的候选人，候选顺序见 GetNextFeedOwners
+// 候选人需要还有订阅源配额，且没有同名或同 URL 的订阅源；followed 表示除 owner 外是否还有人关注
+func transferFeedToFollower(ctx context.Context, q *db.Queries, feed db.Feed, owner uuid.UUID) (next uuid.NullUUID, followed bool, err error) {
+	candidates, err := q.GetNextFeedOwners(ctx, db.GetNextFeedOwnersParams{
+		FeedID: feed.ID,
+		UserID: owner,
+	})
+	if err != nil {
+		return uuid.NullUUID{}, false, fmt.Errorf("error getting followers: %v", err)
+	}
+	for _, candidate := range candidates {
+		if err := q.LockUserQuota(ctx, candidate); err != nil {
+			return uuid.NullUUID{}, true, fmt.Errorf("error locking quota: %v", err)
+		}
+		err := checkQuota(ctx, q, candidate, quotaFeeds)
+		if isQuotaExceeded(err) {
+			continue
+		}
+		if err != nil {
+			return uuid.NullUUID{}, true, err
+		}
+		n, err := q.TransferFeedUnlessConflict(ctx, db.TransferFeedUnlessConflictParams{
+			ID:     feed.ID,
+			UserID: candidate,
+		})
+		if err != nil {
+			return uuid.NullUUID{}, true, err
+		}
+		if n > 0 {
+			return uuid.NullUUID{UUID: candidate, Valid: true}, true, nil
+		}
+	}
+	return uuid.NullUUID{}, len(candidates) > 0, nil
+}
+
+// TransferFeed 请求把订阅源转让给其他用户，接收人通过 AcceptFeedTransfer 接受后所有权才会变更
+// POST /v1/feeds/{feedID}/transfer
+func (apiCfg *ApiConfig) TransferFeed(w http.ResponseWriter, r *http.Request, user db.User) {
+	feed, ok := apiCfg.ownedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	type parameters struct {
+		Username string `json:"username"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	newOwner, err := apiCfg.DB.GetUserByUsername(r.Context(), params.Username)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && newOwner.DisabledAt.Valid) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting user: %v", err))
+		return
+	}
+	if newOwner.ID == user.ID {
+		respondWithError(w, 400, "Feed is already owned by you")
+		return
+	}
+	transfer, err := apiCfg.DB.CreateFeedTransfer(r.Context(), db.CreateFeedTransferParams{
+		FeedID:     feed.ID,
+		FromUserID: user.ID,
+		ToUserID:   newOwner.ID,
+		CreatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error requesting transfer: %v", err))
+		return
+	}
+	apiCfg.auditFeed(r, user, auditFeedTransferRequest, feed, map[string]any{"to_user_id": newOwner.ID})
+	respondWithJSON(w, 202, api.NewFeedTransfer(transfer))
+}
+
+// GetFeedTransfers 列出发给当前用户、等待接受的订阅源转让
+// GET /v1/feed_transfers
+func (apiCfg *ApiConfig) GetFeedTransfers(w http.ResponseWriter, r *http.Request, user db.User) {
+	transfers, err := apiCfg.DB.GetIncomingFeedTransfers(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting feed transfers: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(transfers, api.NewIncomingFeedTransfer))
+}
+
+// AcceptFeedTransfer 接受订阅源转让，成为所有者并自动关注该订阅源，受自己的配额限制
+// POST /v1/feed_transfers/{feedID}/accept
+func (apiCfg *ApiConfig) AcceptFeedTransfer(w http.ResponseWriter, r *http.Request, user db.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feedID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing feed_id: %v", err))
+		return
+	}
+	var transfer db.FeedTransfer
+	var feed db.Feed
+	err = apiCfg.withQuota(r.Context(), user.ID, func(q *db.Queries) error {
+		var err error
+		transfer, err = q.TakeFeedTransfer(r.Context(), db.TakeFeedTransferParams{
+			FeedID:   feedID,
+			ToUserID: user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		// 需要还有订阅源配额，未关注时还需要关注配额
+		if err := checkQuota(r.Context(), q, user.ID, quotaFeeds); err != nil {
+			return err
+		}
+		if err := checkFollowQuota(r.Context(), q, user.ID, feedID); err != nil {
+			return err
+		}
+		feed, err = q.TransferFeed(r.Context(), db.TransferFeedParams{
+			ID:     feedID,
+			UserID: user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.UpsertFeedFollow(r.Context(), db.UpsertFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			FeedID:    feedID,
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Feed transfer not found")
+		return
+	}
+	if isQuotaExceeded(err) {
+		respondWithError(w, 403, fmt.Sprintf("Cannot take this feed: %v", err))
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "You already have a feed with this name or url")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error accepting feed transfer: %v", err))
+		return
+	}
+	apiCfg.auditFeed(r, user, auditFeedTransfer, feed, map[string]any{"from_user_id": transfer.FromUserID, "to_user_id": user.ID})
+	respondWithJSON(w, 200, api.NewFeed(feed))
+}
+
+// DeleteFeedTransfer 发起人撤回或接收人拒绝订阅源转让
+// DELETE /v1/feed_transfers/{feedID}
+func (apiCfg *ApiConfig) DeleteFeedTransfer(w http.ResponseWriter, r *http.Request, user db.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feedID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing feed_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.DeleteFeedTransfer(r.Context(), db.DeleteFeedTransferParams{
+		FeedID: feedID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting feed transfer: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Feed transfer not found")
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
 }
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/djchanahcjd/go-rss/api"
 	"github.com/djchanahcjd/go-rss/internal/db"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -22,19 +23,35 @@ func (apiCfg *ApiConfig) CreateFeedFollows(w http.ResponseWriter, r *http.Reques
 		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
 		return
 	}
-
-	feed_follow, err := apiCfg.DB.CreateFeedFollow(r.Context(), db.CreateFeedFollowParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		UserID:    user.ID,
-		FeedID:    params.FeedID,
+	var feed_follow db.FeedFollow
+	err = apiCfg.withQuota(r.Context(), user.ID, func(q *db.Queries) error {
+		if err := checkQuota(r.Context(), q, user.ID, quotaFollows); err != nil {
+			return err
+		}
+		var err error
+		feed_follow, err = q.CreateFeedFollow(r.Context(), db.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			FeedID:    params.FeedID,
+		})
+		return err
 	})
+	if isQuotaExceeded(err) {
+		respondWithError(w, 403, err.Error())
+		return
+	}
 	if err != nil {
 		respondWithError(w, 400, fmt.Sprintf("Error creating feed follow: %v", err))
 		return
 	}
-	respondWithJSON(w, 201, feed_follow)
+	apiCfg.audit(r, user, auditEvent{
+		Action:     auditFollowCreate,
+		TargetType: auditTargetFeed,
+		TargetID:   params.FeedID,
+	})
+	respondWithJSON(w, 201, api.NewFeedFollow(feed_follow))
 }
 
 func (apiCfg *ApiConfig) GetFeedFollowsByUser(w http.ResponseWriter, r *http.Request, user db.User) {
@@ -43,7 +60,7 @@ func (apiCfg *ApiConfig) GetFeedFollowsByUser(w http.ResponseWriter, r *http.Req
 		respondWithError(w, 400, fmt.Sprintf("Error getting feeds followed by %s: %v", user.ID, err))
 		return
 	}
-	respondWithJSON(w, 200, feeds)
+	respondWithJSON(w, 200, api.List(feeds, api.NewFollowedFeed))
 }
 
 func (apiCfg *ApiConfig) DeleteFeedFollow(w http.ResponseWriter, r *http.Request, user db.User) {
@@ -61,5 +78,10 @@ func (apiCfg *ApiConfig) DeleteFeedFollow(w http.ResponseWriter, r *http.Request
 		respondWithError(w, 400, fmt.Sprintf("Error deleting feed follow: %v", err))
 		return
 	}
+	apiCfg.audit(r, user, auditEvent{
+		Action:     auditFollowDelete,
+		TargetType: auditTargetFeed,
+		TargetID:   feedID,
+	})
 	respondWithJSON(w, 200, struct{}{})
 }
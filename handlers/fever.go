@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/ratelimit"
+	"github.com/google/uuid"
+)
+
+// Fever API 兼容层，供 Reeder、Unread、Fiery Feeds 等客户端使用
+// 分组对应文件夹，分组、订阅源和文章 ID 分别使用各自表的 short_id
+const (
+	feverAPIVersion = 3
+	feverItemsLimit = 50
+	// feverKindling 为全部订阅源，feverSparks 为热门源（不支持，始终为空）
+	feverKindling = 0
+	feverSparks   = -1
+)
+
+type feverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// feverAPIKey 按 Fever 约定计算 md5(username:password)
+func feverAPIKey(username, password string) string {
+	sum := md5.Sum([]byte(username + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetFeverCredentials 设置 Fever 客户端使用的密码，与登录密码相互独立
+// PUT /v1/users/fever {"password": "..."}
+func (apiCfg *ApiConfig) SetFeverCredentials(w http.ResponseWriter, r *http.Request, user db.User) {
+	type parameters struct {
+		Password string `json:"password"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	if params.Password == "" {
+		respondWithError(w, 400, "password is required")
+		return
+	}
+	user, err := apiCfg.DB.SetUserFeverAPIKey(r.Context(), db.SetUserFeverAPIKeyParams{
+		ID:          user.ID,
+		FeverApiKey: sql.NullString{String: feverAPIKey(user.Username, params.Password), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error saving Fever credentials: %v", err))
+		return
+	}
+
+	type response struct {
+		Username string `json:"username"`
+		Endpoint string `json:"endpoint"`
+	}
+	respondWithJSON(w, 200, response{
+		Username: user.Username,
+		Endpoint: apiCfg.BaseURL + "/fever/",
+	})
+}
+
+// DeleteFeverCredentials 停用 Fever 访问
+func (apiCfg *ApiConfig) DeleteFeverCredentials(w http.ResponseWriter, r *http.Request, user db.User) {
+	_, err := apiCfg.DB.SetUserFeverAPIKey(r.Context(), db.SetUserFeverAPIKeyParams{
+		ID:          user.ID,
+		FeverApiKey: sql.NullString{},
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error removing Fever credentials: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// FeverAPI 处理全部 Fever 请求，认证失败时按协议返回 auth=0 而不是错误状态码
+// POST /fever/?api&groups&feeds&items&unread_item_ids&saved_item_ids
+// 表单字段: api_key, mark, as, id, before, since_id, max_id, with_ids
+func (apiCfg *ApiConfig) FeverAPI(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing form: %v", err))
+		return
+	}
+	resp := map[string]interface{}{
+		"api_version": feverAPIVersion,
+		"auth":        0,
+	}
+	apiKey := strings.ToLower(r.Form.Get("api_key"))
+	if apiKey == "" {
+		writeJSON(w, 200, resp)
+		return
+	}
+	// api_key 由密码计算得出，连续猜错同样会被锁定
+	lockKey := ratelimit.FeverKey(apiCfg.clientIP(r))
+	if apiCfg.loginLocked(w, r, lockKey) {
+		writeJSON(w, 200, resp)
+		return
+	}
+	user, err := apiCfg.DB.GetUserByFeverAPIKey(r.Context(), nullString(apiKey))
+	if err != nil {
+		apiCfg.loginFailed(r, lockKey)
+		writeJSON(w, 200, resp)
+		return
+	}
+	resp["auth"] = 1
+	resp["last_refreshed_on_time"] = time.Now().Unix()
+
+	has := func(key string) bool {
+		_, ok := r.Form[key]
+		return ok
+	}
+
+	// 标记操作完成后返回对应的 ID 列表，方便客户端同步
+	if mark := r.Form.Get("mark"); mark != "" {
+		as := r.Form.Get("as")
+		if err := apiCfg.feverMark(r, user, mark, as); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, 404, fmt.Sprintf("%s not found", mark))
+				return
+			}
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		if as == "saved" || as == "unsaved" {
+			r.Form.Set("saved_item_ids", "")
+		} else {
+			r.Form.Set("unread_item_ids", "")
+		}
+	}
+
+	if has("groups") {
+		groups, err := apiCfg.DB.GetFeverGroups(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error getting groups: %v", err))
+			return
+		}
+		resp["groups"] = feverGroups(groups)
+	}
+	if has("feeds") {
+		feeds, err := apiCfg.DB.GetFeverFeeds(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error getting feeds: %v", err))
+			return
+		}
+		resp["feeds"] = feverFeeds(feeds)
+	}
+	if has("groups") || has("feeds") {
+		rows, err := apiCfg.DB.GetFeverFeedsGroups(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error getting feed groups: %v", err))
+			return
+		}
+		resp["feeds_groups"] = feverFeedsGroups(rows)
+	}
+	if has("favicons") {
+		resp["favicons"] = []struct{}{}
+	}
+	if has("links") {
+		resp["links"] = []struct{}{}
+	}
+	if has("items") {
+		items, total, err := apiCfg.feverItems(r, user)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		resp["items"] = items
+		resp["total_items"] = total
+	}
+	if has("unread_item_ids") {
+		ids, err := apiCfg.DB.GetFeverUnreadItemIDs(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error getting unread items: %v", err))
+			return
+		}
+		resp["unread_item_ids"] = joinFeverIDs(ids)
+	}
+	if has("saved_item_ids") {
+		ids, err := apiCfg.DB.GetFeverSavedItemIDs(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error getting saved items: %v", err))
+			return
+		}
+		resp["saved_item_ids"] = joinFeverIDs(ids)
+	}
+	writeJSON(w, 200, resp)
+}
+
+// feverItems 按 since_id / max_id / with_ids 返回最多 50 篇文章及文章总数
+func (apiCfg *ApiConfig) feverItems(r *http.Request, user db.User) ([]feverItem, int64, error) {
+	params := db.GetFeverItemsParams{
+		UserID:    user.ID,
+		WithIds:   []int64{},
+		PageLimit: feverItemsLimit,
+	}
+	if s := r.Form.Get("since_id"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid since_id: %q", s)
+		}
+		params.SinceID = sql.NullInt64{Int64: n, Valid: true}
+	}
+	if s := r.Form.Get("max_id"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid max_id: %q", s)
+		}
+		params.MaxID = sql.NullInt64{Int64: n, Valid: true}
+	}
+	if s := r.Form.Get("with_ids"); s != "" {
+		for _, part := range strings.Split(s, ",") {
+			n, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("invalid with_ids: %q", s)
+			}
+			params.WithIds = append(params.WithIds, n)
+		}
+		if len(params.WithIds) > feverItemsLimit {
+			params.WithIds = params.WithIds[:feverItemsLimit]
+		}
+	}
+
+	rows, err := apiCfg.DB.GetFeverItems(r.Context(), params)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting items: %v", err)
+	}
+	total, err := apiCfg.DB.CountFeverItems(r.Context(), user.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting items: %v", err)
+	}
+
+	items := make([]feverItem, 0, len(rows))
+	for _, row := range rows {
+		item := feverItem{
+			ID:            row.ShortID,
+			FeedID:        row.FeedShortID,
+			Title:         row.Title,
+			Author:        row.Author.String,
+			HTML:          row.Description.String,
+			URL:           row.Url,
+			CreatedOnTime: row.PublishedAt.Unix(),
+		}
+		if row.ReadAt.Valid {
+			item.IsRead = 1
+		}
+		if row.StarredAt.Valid {
+			item.IsSaved = 1
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+// feverMark 执行 mark=item|feed|group 操作
+// 订阅源和分组只支持 as=read，before 为 Unix 秒，只标记此前发布的文章
+func (apiCfg *ApiConfig) feverMark(r *http.Request, user db.User, mark, as string) error {
+	id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid id: %q", r.Form.Get("id"))
+	}
+
+	if mark == "item" {
+		postIDs, err := apiCfg.DB.GetGReaderPostIDs(r.Context(), db.GetGReaderPostIDsParams{
+			UserID:   user.ID,
+			ShortIds: []int64{id},
+		})
+		if err != nil {
+			return err
+		}
+		if len(postIDs) == 0 {
+			return sql.ErrNoRows
+		}
+		postID := postIDs[0]
+		switch as {
+		case "read":
+			return apiCfg.DB.MarkPostRead(r.Context(), db.MarkPostReadParams{UserID: user.ID, PostID: postID})
+		case "unread":
+			return apiCfg.DB.MarkPostUnread(r.Context(), db.MarkPostUnreadParams{UserID: user.ID, PostID: postID})
+		case "saved":
+			return apiCfg.DB.StarPost(r.Context(), db.StarPostParams{UserID: user.ID, PostID: postID})
+		case "unsaved":
+			return apiCfg.DB.UnstarPost(r.Context(), db.UnstarPostParams{UserID: user.ID, PostID: postID})
+		}
+		return fmt.Errorf("invalid as: %q", as)
+	}
+
+	if as != "read" {
+		return fmt.Errorf("invalid as: %q", as)
+	}
+	before := time.Now().UTC()
+	if s := r.Form.Get("before"); s != "" {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid before: %q", s)
+		}
+		before = time.Unix(sec, 0)
+	}
+	params := db.MarkGReaderStreamReadParams{
+		UserID:    user.ID,
+		OlderThan: before,
+	}
+	switch mark {
+	case "feed":
+		feedID, err := apiCfg.DB.GetFeverFeedID(r.Context(), db.GetFeverFeedIDParams{UserID: user.ID, ShortID: id})
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feedID, Valid: true}
+	case "group":
+		switch id {
+		case feverKindling:
+		case feverSparks:
+			return nil
+		default:
+			folderID, err := apiCfg.DB.GetFeverGroupID(r.Context(), db.GetFeverGroupIDParams{UserID: user.ID, ShortID: id})
+			if err != nil {
+				return err
+			}
+			params.FolderID = uuid.NullUUID{UUID: folderID, Valid: true}
+		}
+	default:
+		return fmt.Errorf("invalid mark: %q", mark)
+	}
+	_, err = apiCfg.DB.MarkGReaderStreamRead(r.Context(), params)
+	return err
+}
+
+func feverGroups(rows []db.GetFeverGroupsRow) []feverGroup {
+	groups := make([]feverGroup, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, feverGroup{ID: row.ShortID, Title: row.Name})
+	}
+	return groups
+}
+
+func feverFeeds(rows []db.GetFeverFeedsRow) []feverFeed {
+	feeds := make([]feverFeed, 0, len(rows))
+	for _, row := range rows {
+		feed := feverFeed{
+			ID:      row.ShortID,
+			Title:   row.Name,
+			URL:     row.Url,
+			SiteURL: row.Link.String,
+		}
+		if row.LastFetchedAt.Valid {
+			feed.LastUpdatedOnTime = row.LastFetchedAt.Time.Unix()
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds
+}
+
+// feverFeedsGroups 把 (分组, 订阅源) 关系聚合为 Fever 的逗号分隔格式
+func feverFeedsGroups(rows []db.GetFeverFeedsGroupsRow) []feverFeedsGroup {
+	var order []int64
+	feedIDs := map[int64][]int64{}
+	for _, row := range rows {
+		if _, ok := feedIDs[row.GroupID]; !ok {
+			order = append(order, row.GroupID)
+		}
+		feedIDs[row.GroupID] = append(feedIDs[row.GroupID], row.FeedID)
+	}
+	groups := make([]feverFeedsGroup, 0, len(order))
+	for _, groupID := range order {
+		groups = append(groups, feverFeedsGroup{GroupID: groupID, FeedIDs: joinFeverIDs(feedIDs[groupID])})
+	}
+	return groups
+}
+
+// joinFeverIDs 把 ID 列表拼接为逗号分隔字符串
+func joinFeverIDs(ids []int64) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(parts, ",")
+}
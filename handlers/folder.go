@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// CreateFolder 创建文件夹，新文件夹排在末尾
+func (apiCfg *ApiConfig) CreateFolder(w http.ResponseWriter, r *http.Request, user db.User) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		respondWithError(w, 400, "Folder name is required")
+		return
+	}
+
+	folder, err := apiCfg.DB.CreateFolder(r.Context(), db.CreateFolderParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error creating folder: %v", err))
+		return
+	}
+	respondWithJSON(w, 201, api.NewFolder(folder))
+}
+
+// GetFolders 获取用户的文件夹及其中的订阅源，附带未读数
+func (apiCfg *ApiConfig) GetFolders(w http.ResponseWriter, r *http.Request, user db.User) {
+	resp, err := apiCfg.userFolders(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+// userFolders 返回用户的文件夹及其中的订阅源
+func (apiCfg *ApiConfig) userFolders(ctx context.Context, userID uuid.UUID) ([]api.FolderWithFeeds, error) {
+	folders, err := apiCfg.DB.GetFoldersByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting folders: %v", err)
+	}
+	feeds, err := apiCfg.DB.GetFolderFeedsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting folder feeds: %v", err)
+	}
+
+	feedsByFolder := make(map[uuid.UUID][]db.GetFolderFeedsByUserIDRow)
+	for _, feed := range feeds {
+		feedsByFolder[feed.FolderID] = append(feedsByFolder[feed.FolderID], feed)
+	}
+	resp := make([]api.FolderWithFeeds, 0, len(folders))
+	for _, folder := range folders {
+		resp = append(resp, api.NewFolderWithFeeds(folder, feedsByFolder[folder.ID]))
+	}
+	return resp, nil
+}
+
+// UpdateFolder 重命名文件夹
+func (apiCfg *ApiConfig) UpdateFolder(w http.ResponseWriter, r *http.Request, user db.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+		return
+	}
+	type parameters struct {
+		Name string `json:"name"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		respondWithError(w, 400, "Folder name is required")
+		return
+	}
+
+	folder, err := apiCfg.DB.UpdateFolder(r.Context(), db.UpdateFolderParams{
+		ID:     folderID,
+		UserID: user.ID,
+		Name:   name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Folder not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error updating folder: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewFolder(folder))
+}
+
+// DeleteFolder 删除文件夹，其中的订阅不受影响
+func (apiCfg *ApiConfig) DeleteFolder(w http.ResponseWriter, r *http.Request, user db.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.DeleteFolder(r.Context(), db.DeleteFolderParams{
+		ID:     folderID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting folder: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Folder not found")
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// ReorderFolders 按给定顺序排列文件夹
+func (apiCfg *ApiConfig) ReorderFolders(w http.ResponseWriter, r *http.Request, user db.User) {
+	type parameters struct {
+		FolderIDs []uuid.UUID `json:"folder_ids"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	err = apiCfg.DB.ReorderFolders(r.Context(), db.ReorderFoldersParams{
+		FolderIds: params.FolderIDs,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error reordering folders: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// AddFeedToFolder 把已关注的订阅源加入文件夹
+func (apiCfg *ApiConfig) AddFeedToFolder(w http.ResponseWriter, r *http.Request, user db.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+		return
+	}
+	type parameters struct {
+		FeedID uuid.UUID `json:"feed_id"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+
+	folderFeed, err := apiCfg.DB.AddFeedToFolder(r.Context(), db.AddFeedToFolderParams{
+		FolderID: folderID,
+		UserID:   user.ID,
+		FeedID:   params.FeedID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Folder not found or feed not followed")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error adding feed to folder: %v", err))
+		return
+	}
+	respondWithJSON(w, 201, api.NewFolderFeed(folderFeed))
+}
+
+// RemoveFeedFromFolder 把订阅源移出文件夹，不会取消订阅
+func (apiCfg *ApiConfig) RemoveFeedFromFolder(w http.ResponseWriter, r *http.Request, user db.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+		return
+	}
+	feedID, err := uuid.Parse(chi.URLParam(r, "feedID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing feed_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.RemoveFeedFromFolder(r.Context(), db.RemoveFeedFromFolderParams{
+		FolderID: folderID,
+		UserID:   user.ID,
+		FeedID:   feedID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error removing feed from folder: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Feed not found in folder")
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// ReorderFolderFeeds 按给定顺序排列文件夹内的订阅源
+func (apiCfg *ApiConfig) ReorderFolderFeeds(w http.ResponseWriter, r *http.Request, user db.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+		return
+	}
+	type parameters struct {
+		FeedIDs []uuid.UUID `json:"feed_ids"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	err = apiCfg.DB.ReorderFolderFeeds(r.Context(), db.ReorderFolderFeedsParams{
+		FeedIds:  params.FeedIDs,
+		FolderID: folderID,
+		UserID:   user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error reordering folder feeds: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
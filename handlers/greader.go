@@ -0,0 +1,953 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/apikeys"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/opml"
+	"github.com/djchanahcjd/go-rss/ratelimit"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Google Reader API 兼容层，供 Reeder、NetNewsWire、FeedMe 等客户端使用
+// 文件夹和团队对应 label，文章 ID 使用 posts.short_id
+const (
+	greaderReadingList = "user/-/state/com.google/reading-list"
+	greaderRead        = "user/-/state/com.google/read"
+	greaderStarred     = "user/-/state/com.google/starred"
+	greaderKeptUnread  = "user/-/state/com.google/kept-unread"
+	greaderLabelPrefix = "user/-/label/"
+	greaderFeedPrefix  = "feed/"
+	greaderItemPrefix  = "tag:google.com,2005:reader/item/"
+)
+
+const (
+	// greaderKeyName 是 ClientLogin 签发的 API Key 的名称
+	greaderKeyName = "Google Reader"
+	// greaderMaxKeys 每个用户最多保留的 Google Reader Key 数量，对应同时登录的客户端数
+	greaderMaxKeys = 5
+)
+
+// greaderUserPrefix 客户端可能用真实用户 ID 代替 "-"
+var greaderUserPrefix = regexp.MustCompile(`^user/[^/]+/`)
+
+type greaderCategory struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type greaderSubscription struct {
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Categories []greaderCategory `json:"categories"`
+	URL        string            `json:"url"`
+	HTMLURL    string            `json:"htmlUrl"`
+	IconURL    string            `json:"iconUrl"`
+	FirstItem  string            `json:"firstitemmsec"`
+}
+
+type greaderLink struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type greaderContent struct {
+	Direction string `json:"direction"`
+	Content   string `json:"content"`
+}
+
+type greaderOrigin struct {
+	StreamID string `json:"streamId"`
+	Title    string `json:"title"`
+	HTMLURL  string `json:"htmlUrl"`
+}
+
+type greaderItem struct {
+	ID            string         `json:"id"`
+	CrawlTimeMsec string         `json:"crawlTimeMsec"`
+	TimestampUsec string         `json:"timestampUsec"`
+	Published     int64          `json:"published"`
+	Updated       int64          `json:"updated"`
+	Title         string         `json:"title"`
+	Author        string         `json:"author,omitempty"`
+	Canonical     []greaderLink  `json:"canonical"`
+	Alternate     []greaderLink  `json:"alternate"`
+	Summary       greaderContent `json:"summary"`
+	Categories    []string       `json:"categories"`
+	Origin        greaderOrigin  `json:"origin"`
+}
+
+type greaderStream struct {
+	ID           string        `json:"id"`
+	Updated      int64         `json:"updated"`
+	Items        []greaderItem `json:"items"`
+	Continuation string        `json:"continuation,omitempty"`
+}
+
+// greaderFilter 是 stream ID 解析后的筛选条件
+type greaderFilter struct {
+	FeedID      uuid.NullUUID
+	FolderID    uuid.NullUUID
+	TeamID      uuid.NullUUID
+	StarredOnly bool
+	ReadOnly    bool
+}
+
+func greaderError(w http.ResponseWriter, code int, msg string) {
+	if code > 499 {
+		log.Println("Server error:", msg)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write([]byte(msg + "\n"))
+}
+
+func greaderOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write([]byte("OK"))
+}
+
+func greaderFeedStreamID(feedID uuid.UUID) string {
+	return greaderFeedPrefix + feedID.String()
+}
+
+func greaderItemID(shortID int64) string {
+	return fmt.Sprintf("%s%016x", greaderItemPrefix, uint64(shortID))
+}
+
+// parseGReaderItemID 支持长格式（tag:google.com,2005:reader/item/<16 位十六进制>）和十进制短格式
+func parseGReaderItemID(s string) (int64, error) {
+	if hex, ok := strings.CutPrefix(s, greaderItemPrefix); ok {
+		id, err := strconv.ParseUint(hex, 16, 64)
+		return int64(id), err
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func normalizeGReaderStreamID(s string) string {
+	if strings.HasPrefix(s, "user/") {
+		return greaderUserPrefix.ReplaceAllString(s, "user/-/")
+	}
+	return s
+}
+
+// greaderWriteToken 生成 /reader/api/0/token 返回的 T 参数，由用户 ID 签名得到，无需额外存储
+func (apiCfg *ApiConfig) greaderWriteToken(user db.User) string {
+	mac := hmac.New(sha256.New, apiCfg.SessionSecret)
+	mac.Write([]byte("greader." + user.ID.String()))
+	return hex.EncodeToString(mac.Sum(nil))[:57]
+}
+
+// GReaderAuthMiddleware 校验 Authorization: GoogleLogin auth=<token>，POST 请求还需要携带有效的 T 参数
+// T 参数无效时按 Google Reader 协议返回 X-Reader-Google-Bad-Token 响应头，客户端会重新获取 T 后重试
+func (apiCfg *ApiConfig) GReaderAuthMiddleware(handler authedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth=")
+		if !ok || token == "" {
+			greaderError(w, 401, "Unauthorized")
+			return
+		}
+		user, key, err := apiCfg.authenticate(r.Context(), token)
+		if err != nil {
+			greaderError(w, 401, "Unauthorized")
+			return
+		}
+		if !apikeys.Allows(key.Scope, apikeys.ScopeForMethod(r.Method)) {
+			greaderError(w, 403, "Forbidden")
+			return
+		}
+		if !apiCfg.limitUser(w, r, user) {
+			return
+		}
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				greaderError(w, 400, fmt.Sprintf("Error parsing form: %v", err))
+				return
+			}
+			if !hmac.Equal([]byte(r.Form.Get("T")), []byte(apiCfg.greaderWriteToken(user))) {
+				w.Header().Set("X-Reader-Google-Bad-Token", "true")
+				greaderError(w, 401, "Unauthorized")
+				return
+			}
+		}
+		handler(w, r, user)
+	}
+}
+
+// GReaderClientLogin 使用用户名和密码登录，每次登录签发一个名为 Google Reader 的 read-write API Key 作为 Auth 令牌
+// POST /accounts/ClientLogin (Email, Passwd)，只接受 POST，避免密码出现在 URL 和访问日志中
+func (apiCfg *ApiConfig) GReaderClientLogin(w http.ResponseWriter, r *http.Request) {
+	if apiCfg.PasswordLoginDisabled {
+		greaderError(w, 403, "Error=BadAuthentication")
+		return
+	}
+	// 只读取请求体，忽略 URL 中的参数
+	if err := r.ParseForm(); err != nil {
+		greaderError(w, 400, "Error=BadRequest")
+		return
+	}
+	lockKey := ratelimit.LoginKey(r.PostForm.Get("Email"), apiCfg.clientIP(r))
+	username := r.PostForm.Get("Email")
+	if apiCfg.loginLocked(w, r, lockKey) {
+		apiCfg.auditLoginResult(r, "greader", username, db.User{}, "locked")
+		greaderError(w, 429, "Error=TooManyRequests")
+		return
+	}
+	user, err := apiCfg.DB.GetUserByUsername(r.Context(), username)
+	if err != nil {
+		apiCfg.loginFailed(r, lockKey)
+		apiCfg.auditLoginResult(r, "greader", username, db.User{}, "unknown_user")
+		greaderError(w, 401, "Error=BadAuthentication")
+		return
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(r.PostForm.Get("Passwd")))
+	if err != nil {
+		apiCfg.loginFailed(r, lockKey)
+		apiCfg.auditLoginResult(r, "greader", username, user, "invalid_password")
+		greaderError(w, 401, "Error=BadAuthentication")
+		return
+	}
+	apiCfg.loginSucceeded(r, lockKey)
+	if user.DisabledAt.Valid {
+		apiCfg.auditLoginResult(r, "greader", username, user, "disabled")
+		greaderError(w, 403, "Error=AccountDisabled")
+		return
+	}
+	key, apiKey, err := apiCfg.issueAPIKey(r.Context(), user.ID, greaderKeyName, apikeys.ScopeReadWrite, sql.NullTime{})
+	if err != nil {
+		greaderError(w, 500, "Error=Unknown")
+		return
+	}
+	apiCfg.auditLoginResult(r, "greader", username, user, "")
+	apiCfg.auditAPIKeyCreate(r, user, apiKey)
+	// 每次登录都会签发新 Key，只保留最近使用的几个，长期不用的客户端需要重新登录
+	pruned, err := apiCfg.DB.PruneAPIKeysByName(r.Context(), db.PruneAPIKeysByNameParams{
+		UserID: user.ID,
+		Name:   greaderKeyName,
+		Keep:   greaderMaxKeys,
+	})
+	if err != nil {
+		log.Printf("Error pruning Google Reader keys for %s: %v\n", user.ID, err)
+	}
+	for _, old := range pruned {
+		apiCfg.audit(r, user, auditEvent{
+			Action:     auditAPIKeyRevoke,
+			TargetType: auditTargetAPIKey,
+			TargetID:   old.ID,
+			Details:    map[string]any{"name": old.Name, "prefix": old.Prefix, "reason": "rotated"},
+		})
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(200)
+	fmt.Fprintf(w, "SID=%s\nLSID=%s\nAuth=%s\n", key, key, key)
+}
+
+// GReaderToken 返回 POST 请求需要携带的 T 参数
+func (apiCfg *ApiConfig) GReaderToken(w http.ResponseWriter, r *http.Request, user db.User) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write([]byte(apiCfg.greaderWriteToken(user)))
+}
+
+func (apiCfg *ApiConfig) GReaderUserInfo(w http.ResponseWriter, r *http.Request, user db.User) {
+	type response struct {
+		UserID        string `json:"userId"`
+		UserName      string `json:"userName"`
+		UserProfileID string `json:"userProfileId"`
+		UserEmail     string `json:"userEmail"`
+	}
+	writeJSON(w, 200, response{
+		UserID:        user.ID.String(),
+		UserName:      user.Username,
+		UserProfileID: user.ID.String(),
+	})
+}
+
+// GReaderSubscriptionList 订阅列表，文件夹作为 category 返回
+func (apiCfg *ApiConfig) GReaderSubscriptionList(w http.ResponseWriter, r *http.Request, user db.User) {
+	subs, err := apiCfg.DB.GetGReaderSubscriptions(r.Context(), user.ID)
+	if err != nil {
+		greaderError(w, 500, fmt.Sprintf("Error getting subscriptions: %v", err))
+		return
+	}
+	type response struct {
+		Subscriptions []greaderSubscription `json:"subscriptions"`
+	}
+	resp := response{Subscriptions: make([]greaderSubscription, 0, len(subs))}
+	for _, sub := range subs {
+		categories := make([]greaderCategory, 0, len(sub.FolderNames))
+		for _, name := range sub.FolderNames {
+			categories = append(categories, greaderCategory{ID: greaderLabelPrefix + name, Label: name})
+		}
+		resp.Subscriptions = append(resp.Subscriptions, greaderSubscription{
+			ID:         greaderFeedStreamID(sub.ID),
+			Title:      sub.Name,
+			Categories: categories,
+			URL:        sub.Url,
+			HTMLURL:    sub.Link.String,
+			FirstItem:  strconv.FormatInt(sub.CreatedAt.UnixMilli(), 10),
+		})
+	}
+	writeJSON(w, 200, resp)
+}
+
+// GReaderSubscriptionEdit 订阅、取消订阅以及调整订阅所在的文件夹
+// POST /reader/api/0/subscription/edit (ac=subscribe|unsubscribe|edit, s, t, a, r)
+func (apiCfg *ApiConfig) GReaderSubscriptionEdit(w http.ResponseWriter, r *http.Request, user db.User) {
+	if err := r.ParseForm(); err != nil {
+		greaderError(w, 400, fmt.Sprintf("Error parsing form: %v", err))
+		return
+	}
+	action := r.Form.Get("ac")
+	for _, streamID := range r.Form["s"] {
+		var err error
+		switch action {
+		case "subscribe":
+			err = apiCfg.greaderSubscribe(r, user, streamID, r.Form.Get("t"), r.Form.Get("a"))
+		case "unsubscribe":
+			err = apiCfg.greaderUnsubscribe(r, user, streamID)
+		case "edit":
+			err = apiCfg.greaderEditLabels(r, user, streamID, r.Form["a"], r.Form["r"])
+		default:
+			greaderError(w, 400, fmt.Sprintf("Unknown action: %q", action))
+			return
+		}
+		if isQuotaExceeded(err) {
+			greaderError(w, 403, err.Error())
+			return
+		}
+		if err != nil {
+			greaderError(w, 400, err.Error())
+			return
+		}
+	}
+	greaderOK(w)
+}
+
+// GReaderQuickAdd 通过订阅源 URL 快速订阅
+// POST /reader/api/0/subscription/quickadd (quickadd)
+func (apiCfg *ApiConfig) GReaderQuickAdd(w http.ResponseWriter, r *http.Request, user db.User) {
+	if err := r.ParseForm(); err != nil {
+		greaderError(w, 400, fmt.Sprintf("Error parsing form: %v", err))
+		return
+	}
+	feedURL := strings.TrimPrefix(strings.TrimSpace(r.Form.Get("quickadd")), greaderFeedPrefix)
+	if feedURL == "" {
+		greaderError(w, 400, "quickadd is required")
+		return
+	}
+	feedID, _, err := apiCfg.importSubscription(r, user, opml.Subscription{XMLURL: feedURL}, map[string]uuid.UUID{})
+	if isQuotaExceeded(err) {
+		greaderError(w, 403, err.Error())
+		return
+	}
+	if err != nil {
+		greaderError(w, 400, err.Error())
+		return
+	}
+	feed, err := apiCfg.DB.GetFeedByID(r.Context(), feedID)
+	if err != nil {
+		greaderError(w, 500, fmt.Sprintf("Error getting feed: %v", err))
+		return
+	}
+	type response struct {
+		NumResults int    `json:"numResults"`
+		Query      string `json:"query"`
+		StreamID   string `json:"streamId"`
+		StreamName string `json:"streamName"`
+	}
+	writeJSON(w, 200, response{
+		NumResults: 1,
+		Query:      feedURL,
+		StreamID:   greaderFeedStreamID(feed.ID),
+		StreamName: feed.Name,
+	})
+}
+
+func (apiCfg *ApiConfig) greaderSubscribe(r *http.Request, user db.User, streamID, title, label string) error {
+	feedURL, ok := strings.CutPrefix(streamID, greaderFeedPrefix)
+	if !ok || feedURL == "" {
+		return fmt.Errorf("invalid feed stream: %q", streamID)
+	}
+	if id, err := uuid.Parse(feedURL); err == nil {
+		feed, err := apiCfg.DB.GetFeedByID(r.Context(), id)
+		if err != nil {
+			return fmt.Errorf("feed not found: %q", streamID)
+		}
+		feedURL = feed.Url
+	}
+	folder, _ := strings.CutPrefix(normalizeGReaderStreamID(label), greaderLabelPrefix)
+	_, _, err := apiCfg.importSubscription(r, user, opml.Subscription{
+		Title:  title,
+		XMLURL: feedURL,
+		Folder: folder,
+	}, map[string]uuid.UUID{})
+	return err
+}
+
+func (apiCfg *ApiConfig) greaderUnsubscribe(r *http.Request, user db.User, streamID string) error {
+	filter, err := apiCfg.greaderFilter(r, user, streamID)
+	if err != nil {
+		return err
+	}
+	if !filter.FeedID.Valid {
+		return fmt.Errorf("invalid feed stream: %q", streamID)
+	}
+	err = apiCfg.DB.DeleteFeedFollow(r.Context(), db.DeleteFeedFollowParams{
+		UserID: user.ID,
+		FeedID: filter.FeedID.UUID,
+	})
+	if err != nil {
+		return err
+	}
+	apiCfg.audit(r, user, auditEvent{
+		Action:     auditFollowDelete,
+		TargetType: auditTargetFeed,
+		TargetID:   filter.FeedID.UUID,
+	})
+	return nil
+}
+
+func (apiCfg *ApiConfig) greaderEditLabels(r *http.Request, user db.User, streamID string, add, remove []string) error {
+	filter, err := apiCfg.greaderFilter(r, user, streamID)
+	if err != nil {
+		return err
+	}
+	if !filter.FeedID.Valid {
+		return fmt.Errorf("invalid feed stream: %q", streamID)
+	}
+	for _, label := range add {
+		name, ok := strings.CutPrefix(normalizeGReaderStreamID(label), greaderLabelPrefix)
+		if !ok || name == "" {
+			continue
+		}
+		folder, err := apiCfg.DB.GetOrCreateFolder(r.Context(), db.GetOrCreateFolderParams{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			Name:      name,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("error creating folder: %v", err)
+		}
+		_, err = apiCfg.DB.AddFeedToFolder(r.Context(), db.AddFeedToFolderParams{
+			FolderID: folder.ID,
+			UserID:   user.ID,
+			FeedID:   filter.FeedID.UUID,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding feed to folder: %v", err)
+		}
+	}
+	for _, label := range remove {
+		folderFilter, err := apiCfg.greaderFilter(r, user, label)
+		if err != nil || !folderFilter.FolderID.Valid {
+			continue
+		}
+		_, err = apiCfg.DB.RemoveFeedFromFolder(r.Context(), db.RemoveFeedFromFolderParams{
+			FolderID: folderFilter.FolderID.UUID,
+			UserID:   user.ID,
+			FeedID:   filter.FeedID.UUID,
+		})
+		if err != nil {
+			return fmt.Errorf("error removing feed from folder: %v", err)
+		}
+	}
+	return nil
+}
+
+// greaderFilter 把 stream ID 解析为筛选条件
+// 支持 reading-list、starred、read、label 和 feed/<id 或 URL>
+func (apiCfg *ApiConfig) greaderFilter(r *http.Request, user db.User, streamID string) (greaderFilter, error) {
+	streamID = normalizeGReaderStreamID(streamID)
+	switch streamID {
+	case "", greaderReadingList:
+		return greaderFilter{}, nil
+	case greaderStarred:
+		return greaderFilter{StarredOnly: true}, nil
+	case greaderRead:
+		return greaderFilter{ReadOnly: true}, nil
+	}
+
+	if name, ok := strings.CutPrefix(streamID, greaderLabelPrefix); ok {
+		folders, err := apiCfg.DB.GetFoldersByUserID(r.Context(), user.ID)
+		if err != nil {
+			return greaderFilter{}, fmt.Errorf("error getting folders: %v", err)
+		}
+		for _, folder := range folders {
+			if folder.Name == name {
+				return greaderFilter{FolderID: uuid.NullUUID{UUID: folder.ID, Valid: true}}, nil
+			}
+		}
+		// 没有同名的个人文件夹时，label 对应用户所在的团队
+		teams, err := apiCfg.DB.GetTeamsByUserID(r.Context(), user.ID)
+		if err != nil {
+			return greaderFilter{}, fmt.Errorf("error getting teams: %v", err)
+		}
+		for _, team := range teams {
+			if team.Name == name {
+				return greaderFilter{TeamID: uuid.NullUUID{UUID: team.ID, Valid: true}}, nil
+			}
+		}
+		return greaderFilter{}, fmt.Errorf("label not found: %q", name)
+	}
+
+	if feed, ok := strings.CutPrefix(streamID, greaderFeedPrefix); ok {
+		if id, err := uuid.Parse(feed); err == nil {
+			return greaderFilter{FeedID: uuid.NullUUID{UUID: id, Valid: true}}, nil
+		}
+		subs, err := apiCfg.DB.GetGReaderSubscriptions(r.Context(), user.ID)
+		if err != nil {
+			return greaderFilter{}, fmt.Errorf("error getting subscriptions: %v", err)
+		}
+		for _, sub := range subs {
+			if sub.Url == feed {
+				return greaderFilter{FeedID: uuid.NullUUID{UUID: sub.ID, Valid: true}}, nil
+			}
+		}
+		return greaderFilter{}, fmt.Errorf("feed not found: %q", feed)
+	}
+	return greaderFilter{}, fmt.Errorf("unsupported stream: %q", streamID)
+}
+
+// greaderItemRefs 按请求参数查询 stream 中的文章
+// n 数量，r=o 从旧到新，ot/nt 时间范围（秒），xt 排除已读，it 仅已读/收藏，c 为续页标记
+func (apiCfg *ApiConfig) greaderItemRefs(r *http.Request, user db.User, streamID string, defaultCount, maxCount int64) ([]db.GetGReaderItemRefsRow, string, error) {
+	filter, err := apiCfg.greaderFilter(r, user, streamID)
+	if err != nil {
+		return nil, "", err
+	}
+	query := r.URL.Query()
+	count := defaultCount
+	if s := query.Get("n"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, "", fmt.Errorf("invalid n: %q", s)
+		}
+		count = min(n, maxCount)
+	}
+	offset := int64(0)
+	if s := query.Get("c"); s != "" {
+		offset, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || offset < 0 {
+			return nil, "", fmt.Errorf("invalid continuation: %q", s)
+		}
+	}
+	params := db.GetGReaderItemRefsParams{
+		UserID:      user.ID,
+		FeedID:      filter.FeedID,
+		FolderID:    filter.FolderID,
+		TeamID:      filter.TeamID,
+		ReadOnly:    filter.ReadOnly,
+		StarredOnly: filter.StarredOnly,
+		OldestFirst: query.Get("r") == "o",
+		PageLimit:   count,
+		PageOffset:  offset,
+	}
+	for _, target := range query["xt"] {
+		if normalizeGReaderStreamID(target) == greaderRead {
+			params.UnreadOnly = true
+		}
+	}
+	for _, target := range query["it"] {
+		switch normalizeGReaderStreamID(target) {
+		case greaderRead:
+			params.ReadOnly = true
+		case greaderStarred:
+			params.StarredOnly = true
+		}
+	}
+	if s := query.Get("ot"); s != "" {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid ot: %q", s)
+		}
+		params.NewerThan = sql.NullTime{Time: time.Unix(sec, 0), Valid: true}
+	}
+	if s := query.Get("nt"); s != "" {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid nt: %q", s)
+		}
+		params.OlderThan = sql.NullTime{Time: time.Unix(sec, 0), Valid: true}
+	}
+
+	refs, err := apiCfg.DB.GetGReaderItemRefs(r.Context(), params)
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting items: %v", err)
+	}
+	continuation := ""
+	if int64(len(refs)) == count {
+		continuation = strconv.FormatInt(offset+count, 10)
+	}
+	return refs, continuation, nil
+}
+
+// GReaderStreamItemIDs 返回 stream 中文章的 ID
+// GET /reader/api/0/stream/items/ids?s=&n=&xt=&it=&ot=&nt=&r=&c=
+func (apiCfg *ApiConfig) GReaderStreamItemIDs(w http.ResponseWriter, r *http.Request, user db.User) {
+	refs, continuation, err := apiCfg.greaderItemRefs(r, user, r.URL.Query().Get("s"), 1000, 10000)
+	if err != nil {
+		greaderError(w, 400, err.Error())
+		return
+	}
+	type itemRef struct {
+		ID              string   `json:"id"`
+		DirectStreamIDs []string `json:"directStreamIds"`
+		TimestampUsec   string   `json:"timestampUsec"`
+	}
+	type response struct {
+		ItemRefs     []itemRef `json:"itemRefs"`
+		Continuation string    `json:"continuation,omitempty"`
+	}
+	resp := response{ItemRefs: make([]itemRef, 0, len(refs)), Continuation: continuation}
+	for _, ref := range refs {
+		resp.ItemRefs = append(resp.ItemRefs, itemRef{
+			ID:              strconv.FormatInt(ref.ShortID, 10),
+			DirectStreamIDs: []string{greaderFeedStreamID(ref.FeedID)},
+			TimestampUsec:   strconv.FormatInt(ref.PublishedAt.UnixMicro(), 10),
+		})
+	}
+	writeJSON(w, 200, resp)
+}
+
+// GReaderStreamContents 返回 stream 中文章的完整内容，stream ID 位于路径或 s 参数中
+// GET /reader/api/0/stream/contents/{streamId}
+func (apiCfg *ApiConfig) GReaderStreamContents(w http.ResponseWriter, r *http.Request, user db.User) {
+	streamID := r.URL.Query().Get("s")
+	if path := chi.URLParam(r, "*"); path != "" {
+		unescaped, err := url.PathUnescape(path)
+		if err != nil {
+			greaderError(w, 400, fmt.Sprintf("Invalid stream: %v", err))
+			return
+		}
+		streamID = unescaped
+	}
+	refs, continuation, err := apiCfg.greaderItemRefs(r, user, streamID, 20, 1000)
+	if err != nil {
+		greaderError(w, 400, err.Error())
+		return
+	}
+	ids := make([]int64, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, ref.ShortID)
+	}
+	items, err := apiCfg.greaderItems(r, user, ids)
+	if err != nil {
+		greaderError(w, 500, err.Error())
+		return
+	}
+	if streamID == "" {
+		streamID = greaderReadingList
+	}
+	writeJSON(w, 200, greaderStream{
+		ID:           streamID,
+		Updated:      time.Now().Unix(),
+		Items:        items,
+		Continuation: continuation,
+	})
+}
+
+// GReaderStreamItemContents 按 ID 批量获取文章内容
+// POST /reader/api/0/stream/items/contents (i 可重复)
+func (apiCfg *ApiConfig) GReaderStreamItemContents(w http.ResponseWriter, r *http.Request, user db.User) {
+	if err := r.ParseForm(); err != nil {
+		greaderError(w, 400, fmt.Sprintf("Error parsing form: %v", err))
+		return
+	}
+	ids, err := parseGReaderItemIDs(r.Form["i"])
+	if err != nil {
+		greaderError(w, 400, err.Error())
+		return
+	}
+	items, err := apiCfg.greaderItems(r, user, ids)
+	if err != nil {
+		greaderError(w, 500, err.Error())
+		return
+	}
+	writeJSON(w, 200, greaderStream{
+		ID:      greaderReadingList,
+		Updated: time.Now().Unix(),
+		Items:   items,
+	})
+}
+
+func parseGReaderItemIDs(values []string) ([]int64, error) {
+	ids := make([]int64, 0, len(values))
+	for _, s := range values {
+		id, err := parseGReaderItemID(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid item id: %q", s)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// greaderItems 读取文章并按 ids 的顺序返回
+func (apiCfg *ApiConfig) greaderItems(r *http.Request, user db.User, ids []int64) ([]greaderItem, error) {
+	items := make([]greaderItem, 0, len(ids))
+	if len(ids) == 0 {
+		return items, nil
+	}
+	rows, err := apiCfg.DB.GetGReaderItems(r.Context(), db.GetGReaderItemsParams{
+		UserID:   user.ID,
+		ShortIds: ids,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting items: %v", err)
+	}
+	byID := make(map[int64]db.GetGReaderItemsRow, len(rows))
+	for _, row := range rows {
+		byID[row.ShortID] = row
+	}
+	for _, id := range ids {
+		row, ok := byID[id]
+		if !ok {
+			continue
+		}
+		categories := []string{greaderReadingList}
+		if row.ReadAt.Valid {
+			categories = append(categories, greaderRead)
+		}
+		if row.StarredAt.Valid {
+			categories = append(categories, greaderStarred)
+		}
+		items = append(items, greaderItem{
+			ID:            greaderItemID(row.ShortID),
+			CrawlTimeMsec: strconv.FormatInt(row.CreatedAt.UnixMilli(), 10),
+			TimestampUsec: strconv.FormatInt(row.PublishedAt.UnixMicro(), 10),
+			Published:     row.PublishedAt.Unix(),
+			Updated:       row.PublishedAt.Unix(),
+			Title:         row.Title,
+			Author:        row.Author.String,
+			Canonical:     []greaderLink{{Href: row.Url}},
+			Alternate:     []greaderLink{{Href: row.Url, Type: "text/html"}},
+			Summary:       greaderContent{Direction: "ltr", Content: row.Description.String},
+			Categories:    categories,
+			Origin: greaderOrigin{
+				StreamID: greaderFeedStreamID(row.FeedID),
+				Title:    row.FeedName,
+				HTMLURL:  row.FeedLink.String,
+			},
+		})
+	}
+	return items, nil
+}
+
+// GReaderEditTag 为文章添加或移除已读、收藏状态
+// POST /reader/api/0/edit-tag (i 可重复, a, r)
+func (apiCfg *ApiConfig) GReaderEditTag(w http.ResponseWriter, r *http.Request, user db.User) {
+	if err := r.ParseForm(); err != nil {
+		greaderError(w, 400, fmt.Sprintf("Error parsing form: %v", err))
+		return
+	}
+	shortIDs, err := parseGReaderItemIDs(r.Form["i"])
+	if err != nil {
+		greaderError(w, 400, err.Error())
+		return
+	}
+	postIDs, err := apiCfg.DB.GetGReaderPostIDs(r.Context(), db.GetGReaderPostIDsParams{
+		UserID:   user.ID,
+		ShortIds: shortIDs,
+	})
+	if err != nil {
+		greaderError(w, 500, fmt.Sprintf("Error getting items: %v", err))
+		return
+	}
+
+	var ops []func(uuid.UUID) error
+	for _, tag := range r.Form["a"] {
+		switch normalizeGReaderStreamID(tag) {
+		case greaderRead:
+			ops = append(ops, func(id uuid.UUID) error {
+				return apiCfg.DB.MarkPostRead(r.Context(), db.MarkPostReadParams{UserID: user.ID, PostID: id})
+			})
+		case greaderKeptUnread:
+			ops = append(ops, func(id uuid.UUID) error {
+				return apiCfg.DB.MarkPostUnread(r.Context(), db.MarkPostUnreadParams{UserID: user.ID, PostID: id})
+			})
+		case greaderStarred:
+			ops = append(ops, func(id uuid.UUID) error {
+				return apiCfg.DB.StarPost(r.Context(), db.StarPostParams{UserID: user.ID, PostID: id})
+			})
+		}
+	}
+	for _, tag := range r.Form["r"] {
+		switch normalizeGReaderStreamID(tag) {
+		case greaderRead:
+			ops = append(ops, func(id uuid.UUID) error {
+				return apiCfg.DB.MarkPostUnread(r.Context(), db.MarkPostUnreadParams{UserID: user.ID, PostID: id})
+			})
+		case greaderStarred:
+			ops = append(ops, func(id uuid.UUID) error {
+				return apiCfg.DB.UnstarPost(r.Context(), db.UnstarPostParams{UserID: user.ID, PostID: id})
+			})
+		}
+	}
+	for _, id := range postIDs {
+		for _, op := range ops {
+			if err := op(id); err != nil {
+				greaderError(w, 500, fmt.Sprintf("Error updating item: %v", err))
+				return
+			}
+		}
+	}
+	greaderOK(w)
+}
+
+// GReaderMarkAllAsRead 把 stream 中 ts（微秒）之前的文章全部标记为已读
+// POST /reader/api/0/mark-all-as-read (s, ts)
+func (apiCfg *ApiConfig) GReaderMarkAllAsRead(w http.ResponseWriter, r *http.Request, user db.User) {
+	if err := r.ParseForm(); err != nil {
+		greaderError(w, 400, fmt.Sprintf("Error parsing form: %v", err))
+		return
+	}
+	filter, err := apiCfg.greaderFilter(r, user, r.Form.Get("s"))
+	if err != nil {
+		greaderError(w, 400, err.Error())
+		return
+	}
+	olderThan := time.Now().UTC()
+	if s := r.Form.Get("ts"); s != "" {
+		usec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			greaderError(w, 400, fmt.Sprintf("Invalid ts: %q", s))
+			return
+		}
+		olderThan = time.UnixMicro(usec)
+	}
+	_, err = apiCfg.DB.MarkGReaderStreamRead(r.Context(), db.MarkGReaderStreamReadParams{
+		UserID:      user.ID,
+		FeedID:      filter.FeedID,
+		FolderID:    filter.FolderID,
+		TeamID:      filter.TeamID,
+		StarredOnly: filter.StarredOnly,
+		OlderThan:   olderThan,
+	})
+	if err != nil {
+		greaderError(w, 500, fmt.Sprintf("Error marking items as read: %v", err))
+		return
+	}
+	greaderOK(w)
+}
+
+// GReaderTagList 返回收藏状态、所有文件夹和用户所在的团队
+func (apiCfg *ApiConfig) GReaderTagList(w http.ResponseWriter, r *http.Request, user db.User) {
+	folders, err := apiCfg.DB.GetFoldersByUserID(r.Context(), user.ID)
+	if err != nil {
+		greaderError(w, 500, fmt.Sprintf("Error getting folders: %v", err))
+		return
+	}
+	teams, err := apiCfg.DB.GetTeamsByUserID(r.Context(), user.ID)
+	if err != nil {
+		greaderError(w, 500, fmt.Sprintf("Error getting teams: %v", err))
+		return
+	}
+	type tag struct {
+		ID   string `json:"id"`
+		Type string `json:"type,omitempty"`
+	}
+	type response struct {
+		Tags []tag `json:"tags"`
+	}
+	resp := response{Tags: []tag{{ID: greaderStarred}}}
+	seen := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		resp.Tags = append(resp.Tags, tag{ID: greaderLabelPrefix + folder.Name, Type: "folder"})
+		seen[folder.Name] = true
+	}
+	for _, team := range teams {
+		if !seen[team.Name] {
+			resp.Tags = append(resp.Tags, tag{ID: greaderLabelPrefix + team.Name, Type: "folder"})
+			seen[team.Name] = true
+		}
+	}
+	writeJSON(w, 200, resp)
+}
+
+// GReaderUnreadCount 每个订阅源、文件夹以及全部文章的未读数
+func (apiCfg *ApiConfig) GReaderUnreadCount(w http.ResponseWriter, r *http.Request, user db.User) {
+	counts, err := apiCfg.DB.GetGReaderUnreadCounts(r.Context(), user.ID)
+	if err != nil {
+		greaderError(w, 500, fmt.Sprintf("Error getting unread counts: %v", err))
+		return
+	}
+	subs, err := apiCfg.DB.GetGReaderSubscriptions(r.Context(), user.ID)
+	if err != nil {
+		greaderError(w, 500, fmt.Sprintf("Error getting subscriptions: %v", err))
+		return
+	}
+	foldersByFeed := make(map[uuid.UUID][]string, len(subs))
+	for _, sub := range subs {
+		foldersByFeed[sub.ID] = sub.FolderNames
+	}
+
+	type unreadCount struct {
+		ID                      string `json:"id"`
+		Count                   int64  `json:"count"`
+		NewestItemTimestampUsec string `json:"newestItemTimestampUsec"`
+	}
+	type aggregate struct {
+		count  int64
+		newest time.Time
+	}
+	add := func(agg *aggregate, count int64, newest time.Time) {
+		agg.count += count
+		if newest.After(agg.newest) {
+			agg.newest = newest
+		}
+	}
+
+	resp := struct {
+		Max          int           `json:"max"`
+		UnreadCounts []unreadCount `json:"unreadcounts"`
+	}{Max: 1000, UnreadCounts: []unreadCount{}}
+	var total aggregate
+	labels := make(map[string]*aggregate)
+	var labelOrder []string
+	for _, c := range counts {
+		resp.UnreadCounts = append(resp.UnreadCounts, unreadCount{
+			ID:                      greaderFeedStreamID(c.FeedID),
+			Count:                   c.Count,
+			NewestItemTimestampUsec: strconv.FormatInt(c.NewestPublishedAt.UnixMicro(), 10),
+		})
+		add(&total, c.Count, c.NewestPublishedAt)
+		for _, name := range foldersByFeed[c.FeedID] {
+			if labels[name] == nil {
+				labels[name] = &aggregate{}
+				labelOrder = append(labelOrder, name)
+			}
+			add(labels[name], c.Count, c.NewestPublishedAt)
+		}
+	}
+	for _, name := range labelOrder {
+		resp.UnreadCounts = append(resp.UnreadCounts, unreadCount{
+			ID:                      greaderLabelPrefix + name,
+			Count:                   labels[name].count,
+			NewestItemTimestampUsec: strconv.FormatInt(labels[name].newest.UnixMicro(), 10),
+		})
+	}
+	resp.UnreadCounts = append(resp.UnreadCounts, unreadCount{
+		ID:                      greaderReadingList,
+		Count:                   total.count,
+		NewestItemTimestampUsec: strconv.FormatInt(total.newest.UnixMicro(), 10),
+	})
+	writeJSON(w, 200, resp)
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/apikeys"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// greaderFixture 是 Google Reader 测试共用的用户、订阅源和文章，文章按发布时间从新到旧排列
+type greaderFixture struct {
+	user   db.User
+	key    string
+	feedID uuid.UUID
+	posts  []greaderPost
+}
+
+type greaderPost struct {
+	id      uuid.UUID
+	shortID int64
+	read    bool
+	at      time.Time
+}
+
+func newGReaderFixture(t *testing.T) *greaderFixture {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &greaderFixture{
+		user:   db.User{ID: uuid.New(), Username: "alice", Password: string(hash), Role: "user"},
+		key:    "rss_greader-test-key",
+		feedID: uuid.New(),
+	}
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for shortID := int64(5); shortID >= 1; shortID-- {
+		f.posts = append(f.posts, greaderPost{
+			id:      uuid.New(),
+			shortID: shortID,
+			read:    shortID%2 == 0,
+			at:      base.Add(time.Duration(shortID) * time.Hour),
+		})
+	}
+	return f
+}
+
+// queries 模拟认证和文章相关的查询，extra 中的查询覆盖默认实现
+func (f *greaderFixture) queries(extra map[string]fakeQuery) map[string]fakeQuery {
+	queries := map[string]fakeQuery{
+		"UseAPIKey": func(args []driver.Value) ([][]driver.Value, error) {
+			if args[0] != apikeys.Hash(f.key) {
+				return nil, nil
+			}
+			return [][]driver.Value{{uuid.NewString(), f.user.ID.String(), greaderKeyName, "rss_gr", args[0], apikeys.ScopeReadWrite, time.Now(), nil, nil}}, nil
+		},
+		"GetUserByID": func(args []driver.Value) ([][]driver.Value, error) {
+			return [][]driver.Value{fakeUserRow(f.user)}, nil
+		},
+		"GetFoldersByUserID": fakeNoRows,
+		"GetTeamsByUserID":   fakeNoRows,
+		"GetGReaderItemRefs": f.itemRefs,
+		"GetGReaderItems":    f.items,
+		"GetGReaderPostIDs": func(args []driver.Value) ([][]driver.Value, error) {
+			var rows [][]driver.Value
+			for _, id := range fakeInt64Array(args[1]) {
+				for _, p := range f.posts {
+					if p.shortID == id {
+						rows = append(rows, []driver.Value{p.id.String()})
+					}
+				}
+			}
+			return rows, nil
+		},
+	}
+	for name, fn := range extra {
+		queries[name] = fn
+	}
+	return queries
+}
+
+// itemRefs 按 GetGReaderItemRefs 的参数筛选、排序和分页
+func (f *greaderFixture) itemRefs(args []driver.Value) ([][]driver.Value, error) {
+	feedID, unreadOnly, readOnly, oldestFirst := args[1], args[4].(bool), args[5].(bool), args[9].(bool)
+	limit, offset := args[10].(int64), args[11].(int64)
+	var rows [][]driver.Value
+	for _, p := range f.posts {
+		if (feedID != nil && feedID != f.feedID.String()) || (unreadOnly && p.read) || (readOnly && !p.read) {
+			continue
+		}
+		rows = append(rows, []driver.Value{p.shortID, p.at, f.feedID.String()})
+	}
+	if oldestFirst {
+		slices.Reverse(rows)
+	}
+	rows = rows[min(offset, int64(len(rows))):]
+	return rows[:min(limit, int64(len(rows)))], nil
+}
+
+func (f *greaderFixture) items(args []driver.Value) ([][]driver.Value, error) {
+	var rows [][]driver.Value
+	for _, id := range fakeInt64Array(args[1]) {
+		for _, p := range f.posts {
+			if p.shortID != id {
+				continue
+			}
+			var readAt driver.Value
+			if p.read {
+				readAt = p.at
+			}
+			rows = append(rows, []driver.Value{
+				p.id.String(), p.shortID, "Post", "https://example.com/post", nil, p.at, p.at, nil,
+				f.feedID.String(), "Example", "https://example.com/feed.xml", nil, readAt, nil,
+			})
+		}
+	}
+	return rows, nil
+}
+
+// newGReaderRouter 按 main.go 的路径挂载被测试的接口
+func newGReaderRouter(apiCfg *ApiConfig) http.Handler {
+	r := chi.NewRouter()
+	r.Post("/accounts/ClientLogin", apiCfg.GReaderClientLogin)
+	r.Get("/reader/api/0/stream/contents", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderStreamContents))
+	r.Get("/reader/api/0/stream/contents/*", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderStreamContents))
+	r.Post("/reader/api/0/edit-tag", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderEditTag))
+	return r
+}
+
+func TestGReaderClientLogin(t *testing.T) {
+	f := newGReaderFixture(t)
+	disabled := f.user
+	disabled.ID, disabled.Username = uuid.New(), "carol"
+	disabled.DisabledAt.Time, disabled.DisabledAt.Valid = time.Now(), true
+	users := map[string]db.User{"alice": f.user, "carol": disabled}
+
+	tests := []struct {
+		name             string
+		target           string
+		form             url.Values
+		passwordDisabled bool
+		wantCode         int
+		wantBody         string
+	}{
+		{"valid credentials", "/accounts/ClientLogin", url.Values{"Email": {"alice"}, "Passwd": {"hunter2"}}, false, 200, "Auth=rss_"},
+		{"wrong password", "/accounts/ClientLogin", url.Values{"Email": {"alice"}, "Passwd": {"hunter3"}}, false, 401, "Error=BadAuthentication"},
+		{"unknown user", "/accounts/ClientLogin", url.Values{"Email": {"bob"}, "Passwd": {"hunter2"}}, false, 401, "Error=BadAuthentication"},
+		{"credentials in the url are ignored", "/accounts/ClientLogin?Email=alice&Passwd=hunter2", url.Values{}, false, 401, "Error=BadAuthentication"},
+		{"disabled account", "/accounts/ClientLogin", url.Values{"Email": {"carol"}, "Passwd": {"hunter2"}}, false, 403, "Error=AccountDisabled"},
+		{"password login disabled", "/accounts/ClientLogin", url.Values{"Email": {"alice"}, "Passwd": {"hunter2"}}, true, 403, "Error=BadAuthentication"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, apiCfg := newFakeDB(t, map[string]fakeQuery{
+				"GetLoginLockedUntil": fakeNoRows,
+				"RecordLoginFailure": func([]driver.Value) ([][]driver.Value, error) {
+					return [][]driver.Value{{int64(1)}}, nil
+				},
+				"ClearLoginFailures": fakeNoRows,
+				"CreateAuditEvent":   fakeNoRows,
+				"GetUserByUsername": func(args []driver.Value) ([][]driver.Value, error) {
+					if u, ok := users[args[0].(string)]; ok {
+						return [][]driver.Value{fakeUserRow(u)}, nil
+					}
+					return nil, nil
+				},
+				"CreateAPIKey": func(args []driver.Value) ([][]driver.Value, error) {
+					return [][]driver.Value{{args[0], args[1], args[2], args[3], args[4], args[5], args[6], nil, args[7]}}, nil
+				},
+				"PruneAPIKeysByName": fakeNoRows,
+			})
+			apiCfg.PasswordLoginDisabled = tt.passwordDisabled
+			r := httptest.NewRequest("POST", tt.target, strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			newGReaderRouter(apiCfg).ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode || !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Fatalf("ClientLogin = %d %q, want %d containing %q", w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+			}
+			created := fake.callsTo("CreateAPIKey")
+			if tt.wantCode != 200 {
+				if len(created) != 0 {
+					t.Errorf("CreateAPIKey called %d times on a failed login", len(created))
+				}
+				return
+			}
+			if len(created) != 1 {
+				t.Fatalf("CreateAPIKey called %d times, want 1", len(created))
+			}
+			auth := strings.TrimPrefix(strings.Fields(w.Body.String())[2], "Auth=")
+			args := created[0]
+			if args[2] != greaderKeyName || args[4] != apikeys.Hash(auth) || args[5] != apikeys.ScopeReadWrite {
+				t.Errorf("CreateAPIKey(name %v, hash %v, scope %v), want %q, hash of %q, %q", args[2], args[4], args[5], greaderKeyName, auth, apikeys.ScopeReadWrite)
+			}
+			if n := len(fake.callsTo("PruneAPIKeysByName")); n != 1 {
+				t.Errorf("PruneAPIKeysByName called %d times, want 1", n)
+			}
+		})
+	}
+}
+
+func TestGReaderStreamContents(t *testing.T) {
+	f := newGReaderFixture(t)
+	_, apiCfg := newFakeDB(t, f.queries(nil))
+	router := newGReaderRouter(apiCfg)
+	readingList := "/reader/api/0/stream/contents/" + greaderReadingList
+
+	tests := []struct {
+		name             string
+		target           string
+		wantIDs          []int64
+		wantContinuation string
+		wantStream       string
+	}{
+		{"first page", readingList + "?n=2", []int64{5, 4}, "2", greaderReadingList},
+		{"second page", readingList + "?n=2&c=2", []int64{3, 2}, "4", greaderReadingList},
+		{"last page", readingList + "?n=2&c=4", []int64{1}, "", greaderReadingList},
+		{"oldest first", readingList + "?n=3&r=o", []int64{1, 2, 3}, "3", greaderReadingList},
+		{"exclude read", readingList + "?xt=user/-/state/com.google/read", []int64{5, 3, 1}, "", greaderReadingList},
+		{"stream in query", "/reader/api/0/stream/contents?s=user/-/state/com.google/read", []int64{4, 2}, "", greaderRead},
+		{"feed stream in path", "/reader/api/0/stream/contents/feed/" + f.feedID.String() + "?n=5", []int64{5, 4, 3, 2, 1}, "5", "feed/" + f.feedID.String()},
+		{"other feed", "/reader/api/0/stream/contents/feed/" + uuid.NewString(), []int64{}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.target, nil)
+			r.Header.Set("Authorization", "GoogleLogin auth="+f.key)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != 200 {
+				t.Fatalf("stream/contents = %d %s", w.Code, w.Body.String())
+			}
+			var got greaderStream
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]int64, 0, len(got.Items))
+			for _, item := range got.Items {
+				id, err := parseGReaderItemID(item.ID)
+				if err != nil {
+					t.Fatalf("item id %q: %v", item.ID, err)
+				}
+				ids = append(ids, id)
+			}
+			if !slices.Equal(ids, tt.wantIDs) || got.Continuation != tt.wantContinuation {
+				t.Errorf("items = %v, continuation %q, want %v, %q", ids, got.Continuation, tt.wantIDs, tt.wantContinuation)
+			}
+			if tt.wantStream != "" && got.ID != tt.wantStream {
+				t.Errorf("stream id = %q, want %q", got.ID, tt.wantStream)
+			}
+		})
+	}
+
+	for _, target := range []string{readingList + "?c=-1", readingList + "?n=0", "/reader/api/0/stream/contents/user/-/label/missing"} {
+		r := httptest.NewRequest("GET", target, nil)
+		r.Header.Set("Authorization", "GoogleLogin auth="+f.key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != 400 {
+			t.Errorf("GET %s = %d, want 400", target, w.Code)
+		}
+	}
+}
+
+func TestGReaderEditTag(t *testing.T) {
+	f := newGReaderFixture(t)
+	token := (&ApiConfig{SessionSecret: []byte("test secret")}).greaderWriteToken(f.user)
+	post := func(shortID int64) string {
+		for _, p := range f.posts {
+			if p.shortID == shortID {
+				return p.id.String()
+			}
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name     string
+		form     url.Values
+		wantCode int
+		// want 为按调用顺序记录的“查询名 文章 ID”
+		want []string
+	}{
+		{"missing write token", url.Values{"i": {"1"}, "a": {greaderRead}}, 401, nil},
+		{"write token of another user", url.Values{"T": {(&ApiConfig{SessionSecret: []byte("test secret")}).greaderWriteToken(db.User{ID: uuid.New()})}, "i": {"1"}, "a": {greaderRead}}, 401, nil},
+		{"mark read by long id", url.Values{"T": {token}, "i": {greaderItemID(1)}, "a": {greaderRead}}, 200, []string{"MarkPostRead " + post(1)}},
+		{"star and read several", url.Values{"T": {token}, "i": {"1", "2"}, "a": {"user/12345/state/com.google/starred", greaderRead}}, 200, []string{
+			"StarPost " + post(1), "MarkPostRead " + post(1), "StarPost " + post(2), "MarkPostRead " + post(2),
+		}},
+		{"unread and unstar", url.Values{"T": {token}, "i": {"3"}, "r": {greaderRead, greaderStarred}}, 200, []string{"MarkPostUnread " + post(3), "UnstarPost " + post(3)}},
+		{"kept unread", url.Values{"T": {token}, "i": {"3"}, "a": {greaderKeptUnread}}, 200, []string{"MarkPostUnread " + post(3)}},
+		{"item outside the user's feeds", url.Values{"T": {token}, "i": {"99"}, "a": {greaderRead}}, 200, nil},
+		{"invalid item id", url.Values{"T": {token}, "i": {"xyz"}, "a": {greaderRead}}, 400, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, apiCfg := newFakeDB(t, f.queries(map[string]fakeQuery{
+				"MarkPostRead":   fakeNoRows,
+				"MarkPostUnread": fakeNoRows,
+				"StarPost":       fakeNoRows,
+				"UnstarPost":     fakeNoRows,
+			}))
+			r := httptest.NewRequest("POST", "/reader/api/0/edit-tag", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.Header.Set("Authorization", "GoogleLogin auth="+f.key)
+			w := httptest.NewRecorder()
+			newGReaderRouter(apiCfg).ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("edit-tag = %d %s, want %d", w.Code, w.Body.String(), tt.wantCode)
+			}
+			if tt.wantCode == 401 && w.Header().Get("X-Reader-Google-Bad-Token") != "true" {
+				t.Errorf("missing X-Reader-Google-Bad-Token header")
+			}
+			var got []string
+			fake.mu.Lock()
+			for _, c := range fake.calls {
+				switch c.name {
+				case "MarkPostRead", "MarkPostUnread", "StarPost", "UnstarPost":
+					got = append(got, c.name+" "+c.args[1].(string))
+				}
+			}
+			fake.mu.Unlock()
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("updates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// 注册方式
+const (
+	registrationOpen   = "open"
+	registrationInvite = "invite"
+	registrationClosed = "closed"
+)
+
+// inviteCodePrefix 便于用户识别邀请码
+const inviteCodePrefix = "inv_"
+
+var (
+	errRegistrationClosed = errors.New("registration is closed")
+	errInvalidInvite      = errors.New("invite code is invalid, expired or used up")
+)
+
+// hashInviteCode 计算邀请码的 SHA-256 哈希，首尾空白不影响匹配
+func hashInviteCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// redeemInvite 在 invite 模式下占用邀请码的一次使用次数，其他模式下不需要邀请码
+// 返回的邀请码在注册失败时需要通过 ReleaseInvite 归还
+func (apiCfg *ApiConfig) redeemInvite(ctx context.Context, code string) (uuid.NullUUID, error) {
+	if apiCfg.RegistrationMode != registrationInvite {
+		return uuid.NullUUID{}, nil
+	}
+	if strings.TrimSpace(code) == "" {
+		return uuid.NullUUID{}, errInvalidInvite
+	}
+	invite, err := apiCfg.DB.RedeemInvite(ctx, hashInviteCode(code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.NullUUID{}, errInvalidInvite
+	}
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: invite.ID, Valid: true}, nil
+}
+
+// AdminCreateInvite 生成邀请码，max_uses 默认为 1，明文只在响应中返回一次
+// POST /v1/admin/invites {"note": "...", "max_uses": 1, "expires_at": "RFC3339"}
+func (apiCfg *ApiConfig) AdminCreateInvite(w http.ResponseWriter, r *http.Request, admin db.User) {
+	type parameters struct {
+		Note      string     `json:"note"`
+		MaxUses   *int32     `json:"max_uses"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	maxUses := int32(1)
+	if params.MaxUses != nil {
+		if *params.MaxUses < 1 {
+			respondWithError(w, 400, "max_uses must be at least 1")
+			return
+		}
+		maxUses = *params.MaxUses
+	}
+	expiresAt := sql.NullTime{}
+	if params.ExpiresAt != nil {
+		if !params.ExpiresAt.After(time.Now()) {
+			respondWithError(w, 400, "expires_at must be in the future")
+			return
+		}
+		expiresAt = sql.NullTime{Time: params.ExpiresAt.UTC(), Valid: true}
+	}
+
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error generating invite code: %v", err))
+		return
+	}
+	code := inviteCodePrefix + hex.EncodeToString(buf)
+	invite, err := apiCfg.DB.CreateInvite(r.Context(), db.CreateInviteParams{
+		ID:        uuid.New(),
+		CodeHash:  hashInviteCode(code),
+		Prefix:    code[:len(inviteCodePrefix)+6],
+		Note:      strings.TrimSpace(params.Note),
+		MaxUses:   maxUses,
+		CreatedBy: uuid.NullUUID{UUID: admin.ID, Valid: true},
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error creating invite: %v", err))
+		return
+	}
+	apiCfg.audit(r, admin, auditEvent{
+		Action:     auditAdminInviteCreate,
+		TargetType: auditTargetInvite,
+		TargetID:   invite.ID,
+		Details:    map[string]any{"prefix": invite.Prefix, "max_uses": invite.MaxUses},
+	})
+	resp := api.NewInvite(invite)
+	resp.Code = code
+	respondWithJSON(w, 201, resp)
+}
+
+// AdminGetInvites 列出全部邀请码，包括已用完、过期和吊销的
+// GET /v1/admin/invites
+func (apiCfg *ApiConfig) AdminGetInvites(w http.ResponseWriter, r *http.Request, admin db.User) {
+	invites, err := apiCfg.DB.ListInvites(r.Context())
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting invites: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(invites, api.NewInviteListItem))
+}
+
+// AdminRevokeInvite 吊销邀请码，已经注册的用户不受影响
+// DELETE /v1/admin/invites/{inviteID}
+func (apiCfg *ApiConfig) AdminRevokeInvite(w http.ResponseWriter, r *http.Request, admin db.User) {
+	inviteID, err := uuid.Parse(chi.URLParam(r, "inviteID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing invite_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.RevokeInvite(r.Context(), inviteID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error revoking invite: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Invite not found or already revoked")
+		return
+	}
+	apiCfg.audit(r, admin, auditEvent{
+		Action:     auditAdminInviteRevoke,
+		TargetType: auditTargetInvite,
+		TargetID:   inviteID,
+	})
+	respondWithJSON(w, 200, struct{}{})
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeInvite 是内存中的邀请码，fakeRedeemInvite 按 RedeemInvite 的条件占用使用次数
+type fakeInvite struct {
+	id        uuid.UUID
+	maxUses   int64
+	uses      int64
+	expiresAt time.Time
+	revoked   bool
+}
+
+func fakeRedeemInvite(invites map[string]*fakeInvite) fakeQuery {
+	return func(args []driver.Value) ([][]driver.Value, error) {
+		hash, _ := args[0].(string)
+		inv, ok := invites[hash]
+		if !ok || inv.revoked || inv.uses >= inv.maxUses || (!inv.expiresAt.IsZero() && !inv.expiresAt.After(time.Now())) {
+			return nil, nil
+		}
+		inv.uses++
+		return [][]driver.Value{{
+			inv.id.String(), hash, "inv_012345", "", inv.maxUses, inv.uses, nil, time.Now(), nil, nil,
+		}}, nil
+	}
+}
+
+func TestHashInviteCode(t *testing.T) {
+	const code = "inv_0123456789abcdef01234567"
+	want := "7a002dff6f46d7f5a36e4cdbd208ff699fca688529e337e71580e75d081e5db1"
+	if got := hashInviteCode(code); got != want {
+		t.Errorf("hashInviteCode(%q) = %q, want %q", code, got, want)
+	}
+	if got := hashInviteCode("  " + code + "\n"); got != want {
+		t.Errorf("hashInviteCode with surrounding whitespace = %q, want %q", got, want)
+	}
+	if hashInviteCode(code+"x") == want {
+		t.Error("different codes must not share a hash")
+	}
+}
+
+func TestRedeemInvite(t *testing.T) {
+	valid := uuid.New()
+	single := uuid.New()
+	invites := map[string]*fakeInvite{
+		hashInviteCode("inv_valid"):   {id: valid, maxUses: 3},
+		hashInviteCode("inv_single"):  {id: single, maxUses: 1},
+		hashInviteCode("inv_used"):    {id: uuid.New(), maxUses: 2, uses: 2},
+		hashInviteCode("inv_expired"): {id: uuid.New(), maxUses: 1, expiresAt: time.Now().Add(-time.Hour)},
+		hashInviteCode("inv_revoked"): {id: uuid.New(), maxUses: 1, revoked: true},
+	}
+	_, apiCfg := newFakeDB(t, map[string]fakeQuery{"RedeemInvite": fakeRedeemInvite(invites)})
+
+	tests := []struct {
+		name    string
+		mode    string
+		code    string
+		want    uuid.NullUUID
+		wantErr error
+	}{
+		{"open mode ignores code", registrationOpen, "", uuid.NullUUID{}, nil},
+		{"open mode does not redeem", registrationOpen, "inv_valid", uuid.NullUUID{}, nil},
+		{"missing code", registrationInvite, "", uuid.NullUUID{}, errInvalidInvite},
+		{"blank code", registrationInvite, "   ", uuid.NullUUID{}, errInvalidInvite},
+		{"unknown code", registrationInvite, "inv_nope", uuid.NullUUID{}, errInvalidInvite},
+		{"valid code", registrationInvite, "inv_valid", uuid.NullUUID{UUID: valid, Valid: true}, nil},
+		{"valid code with whitespace", registrationInvite, " inv_valid\n", uuid.NullUUID{UUID: valid, Valid: true}, nil},
+		{"single use first", registrationInvite, "inv_single", uuid.NullUUID{UUID: single, Valid: true}, nil},
+		{"single use second", registrationInvite, "inv_single", uuid.NullUUID{}, errInvalidInvite},
+		{"used up", registrationInvite, "inv_used", uuid.NullUUID{}, errInvalidInvite},
+		{"expired", registrationInvite, "inv_expired", uuid.NullUUID{}, errInvalidInvite},
+		{"revoked", registrationInvite, "inv_revoked", uuid.NullUUID{}, errInvalidInvite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiCfg.RegistrationMode = tt.mode
+			got, err := apiCfg.redeemInvite(context.Background(), tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("redeemInvite() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("redeemInvite() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if uses := invites[hashInviteCode("inv_valid")].uses; uses != 2 {
+		t.Errorf("inv_valid uses = %d, want 2", uses)
+	}
+}
@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/djchanahcjd/go-rss/apikeys"
 	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/sessions"
 )
 
 type authedHandler func(http.ResponseWriter, *http.Request, db.User)
 
 // GetAPIKey extracts the API key from the request headers.
-// Authorization: <api_key>
+// Authorization: <api_key> or Authorization: Bearer <access_token|api_key>
 func GetAPIKey(headers http.Header) (string, error) {
 	apiKey := headers.Get("Authorization")
 	if apiKey == "" {
@@ -20,19 +27,98 @@ func GetAPIKey(headers http.Header) (string, error) {
 	return apiKey, nil
 }
 
+// optionalUser 在携带了认证信息时返回对应用户，用于登录与未登录均可访问的接口
+func (apiCfg *ApiConfig) optionalUser(r *http.Request) (db.User, bool) {
+	user, _, err := apiCfg.authenticateRequest(r)
+	if err != nil {
+		return db.User{}, false
+	}
+	return user, true
+}
+
+// authenticateRequest 识别会话访问令牌或 API Key，返回用户和本次请求拥有的权限范围
+// 会话访问令牌代表用户本人登录，拥有 admin 权限
+func (apiCfg *ApiConfig) authenticateRequest(r *http.Request) (db.User, string, error) {
+	token, err := GetAPIKey(r.Header)
+	if err != nil {
+		return db.User{}, "", err
+	}
+	token = strings.TrimPrefix(token, "Bearer ")
+	if sessions.IsAccessToken(token) {
+		claims, err := sessions.Verify(apiCfg.SessionSecret, token, time.Now())
+		if err != nil {
+			return db.User{}, "", err
+		}
+		user, err := apiCfg.DB.GetSessionUser(r.Context(), claims.SessionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.User{}, "", sessions.ErrInvalidToken
+		}
+		if err != nil {
+			return db.User{}, "", err
+		}
+		return user, apikeys.ScopeAdmin, nil
+	}
+	user, key, err := apiCfg.authenticate(r.Context(), token)
+	if err != nil {
+		return db.User{}, "", err
+	}
+	return user, key.Scope, nil
+}
+
+// authenticate 按哈希查找未过期的 API Key，返回其所属用户
+func (apiCfg *ApiConfig) authenticate(ctx context.Context, key string) (db.User, db.ApiKey, error) {
+	apiKey, err := apiCfg.DB.UseAPIKey(ctx, apikeys.Hash(key))
+	if err != nil {
+		return db.User{}, db.ApiKey{}, err
+	}
+	user, err := apiCfg.DB.GetUserByID(ctx, apiKey.UserID)
+	if err != nil {
+		return db.User{}, db.ApiKey{}, err
+	}
+	if user.DisabledAt.Valid {
+		return db.User{}, db.ApiKey{}, errUserDisabled
+	}
+	return user, apiKey, nil
+}
+
+// AuthMiddleware 只读请求需要 read-only 权限，其余请求需要 read-write 权限
 func (apiCfg *ApiConfig) AuthMiddleware(handler authedHandler) http.HandlerFunc {
+	return apiCfg.ScopedAuthMiddleware("", handler)
+}
+
+// ScopedAuthMiddleware 要求 API Key 至少具有 scope 权限，scope 为空时按请求方法决定
+func (apiCfg *ApiConfig) ScopedAuthMiddleware(scope string, handler authedHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		apiKey, err := GetAPIKey(r.Header)
-		if err != nil {
+		if _, err := GetAPIKey(r.Header); err != nil {
 			respondWithError(w, 403, fmt.Sprintf("Auth error: %v", err))
 			return
 		}
-		user, err := apiCfg.DB.GetUserByAPIKey(r.Context(), apiKey)
+		user, granted, err := apiCfg.authenticateRequest(r)
+		if errors.Is(err, sessions.ErrInvalidToken) {
+			// 访问令牌过期时返回 401，提示客户端刷新会话
+			respondWithError(w, 401, err.Error())
+			return
+		}
+		if errors.Is(err, errUserDisabled) {
+			respondWithError(w, 403, err.Error())
+			return
+		}
 		if err!= nil {
 			log.Printf("[AUTH] Invalid API key provided: %v", err)
 			respondWithError(w, 400, fmt.Sprintf("Couldn't get user: %v", err))
 			return
 		}
+		required := scope
+		if required == "" {
+			required = apikeys.ScopeForMethod(r.Method)
+		}
+		if !apikeys.Allows(granted, required) {
+			respondWithError(w, 403, fmt.Sprintf("API key scope %q is not allowed, %q required", granted, required))
+			return
+		}
+		if !apiCfg.limitUser(w, r, user) {
+			return
+		}
 		handler(w, r, user)
 	}
 }
@@ -44,6 +130,9 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		method := r.Method
 		path := r.URL.Path
 		params := r.URL.Query()
+		if params.Has(streamAccessTokenParam) {
+			params.Set(streamAccessTokenParam, "REDACTED")
+		}
 		
 		// 处理请求
 		next.ServeHTTP(w, r)
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/oidc"
+	"github.com/google/uuid"
+)
+
+// 登录状态 Cookie 只发送给回调地址；从身份提供方跳转回来属于跨站导航，需要使用 Lax
+const (
+	oidcStateCookieName = "oidc_state"
+	oidcStateCookiePath = "/v1/auth/oidc"
+)
+
+// GetAuthMethods 返回可用的登录方式，前端据此显示密码登录表单或单点登录按钮
+// GET /v1/auth/methods
+func (apiCfg *ApiConfig) GetAuthMethods(w http.ResponseWriter, r *http.Request) {
+	methods := api.AuthMethods{
+		Password:     !apiCfg.PasswordLoginDisabled,
+		Registration: apiCfg.RegistrationMode,
+	}
+	if apiCfg.OIDC != nil {
+		methods.OIDC = true
+		methods.OIDCLoginURL = oidcStateCookiePath + "/login"
+	}
+	respondWithJSON(w, 200, methods)
+}
+
+// OIDCLogin 生成 state、nonce 和 PKCE code_verifier，跳转到身份提供方登录
+// GET /v1/auth/oidc/login?reauth=1
+// reauth 用于没有密码的账号在删除账号等敏感操作前重新确认身份，身份提供方会要求重新输入凭据
+func (apiCfg *ApiConfig) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if apiCfg.OIDC == nil {
+		respondWithError(w, 404, "Single sign-on is not configured")
+		return
+	}
+	state, err := oidc.NewLoginState(time.Now())
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error creating login state: %v", err))
+		return
+	}
+	state.Reauth = r.URL.Query().Get("reauth") != ""
+	sealed, err := state.Seal(apiCfg.SessionSecret)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error creating login state: %v", err))
+		return
+	}
+	authURL, err := apiCfg.OIDC.AuthCodeURL(r.Context(), state.State, state.Nonce, state.Verifier, state.Reauth)
+	if err != nil {
+		respondWithError(w, 502, fmt.Sprintf("Error contacting identity provider: %v", err))
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    sealed,
+		Path:     oidcStateCookiePath,
+		MaxAge:   int(oidc.LoginStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   apiCfg.secureCookies(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback 校验 state，用授权码换取 ID Token，登录或创建对应的本地用户后跳转回首页
+// GET /v1/auth/oidc/callback?code=&state=
+// 会话与密码登录相同，前端加载后通过刷新令牌 Cookie 获取访问令牌
+func (apiCfg *ApiConfig) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if apiCfg.OIDC == nil {
+		respondWithError(w, 404, "Single sign-on is not configured")
+		return
+	}
+	query := r.URL.Query()
+	if e := query.Get("error"); e != "" {
+		respondWithError(w, 401, fmt.Sprintf("Identity provider error: %s %s", e, query.Get("error_description")))
+		return
+	}
+	cookie, err := r.Cookie(oidcStateCookieName)
+	if err != nil {
+		respondWithError(w, 400, oidc.ErrInvalidLoginState.Error())
+		return
+	}
+	// state 只能使用一次
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookieName, Path: oidcStateCookiePath, MaxAge: -1, HttpOnly: true})
+	state, err := oidc.OpenLoginState(apiCfg.SessionSecret, cookie.Value, time.Now())
+	if err != nil || !hmac.Equal([]byte(query.Get("state")), []byte(state.State)) {
+		respondWithError(w, 400, oidc.ErrInvalidLoginState.Error())
+		return
+	}
+	if query.Get("code") == "" {
+		respondWithError(w, 400, "Missing authorization code")
+		return
+	}
+
+	identity, err := apiCfg.OIDC.Exchange(r.Context(), query.Get("code"), state.Verifier, state.Nonce)
+	if err != nil {
+		log.Printf("[AUTH] OIDC login failed: %v", err)
+		apiCfg.auditLoginResult(r, "oidc", "", db.User{}, "sso_error")
+		respondWithError(w, 401, "Single sign-on failed")
+		return
+	}
+	// 重新确认身份时身份提供方必须返回足够新的 auth_time，否则可能只是沿用了旧的登录状态
+	if state.Reauth && (identity.AuthTime.IsZero() || time.Since(identity.AuthTime) > reauthWindow) {
+		apiCfg.auditLoginResult(r, "oidc", identity.PreferredUsername, db.User{}, "stale_auth_time")
+		respondWithError(w, 401, "Identity provider did not re-authenticate the user")
+		return
+	}
+	user, err := apiCfg.externalUser(r.Context(), identity)
+	if errors.Is(err, errRegistrationClosed) {
+		apiCfg.auditLoginResult(r, "oidc", identity.PreferredUsername, db.User{}, "registration_closed")
+		respondWithError(w, 403, "Registration is closed")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error signing in: %v", err))
+		return
+	}
+	if user.DisabledAt.Valid {
+		apiCfg.auditLoginResult(r, "oidc", user.Username, user, "disabled")
+		respondWithError(w, 403, "Account is disabled")
+		return
+	}
+	apiCfg.auditLoginResult(r, "oidc", user.Username, user, "")
+	session, refreshToken, err := apiCfg.createSession(r, user)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error creating session: %v", err))
+		return
+	}
+	apiCfg.setSessionCookies(w, session, refreshToken)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// externalUser 查找外部身份对应的本地用户，首次登录时自动创建，并按用户组同步管理员角色
+// 不会按邮箱关联已有的本地账号，以免身份提供方中同邮箱的用户接管他人账号
+func (apiCfg *ApiConfig) externalUser(ctx context.Context, identity oidc.Identity) (db.User, error) {
+	email := sql.NullString{}
+	if identity.EmailVerified {
+		email = nullString(identity.Email)
+	}
+	user, err := apiCfg.DB.GetUserByExternalIdentity(ctx, db.GetUserByExternalIdentityParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	})
+	switch {
+	case err == nil:
+		err = apiCfg.DB.TouchExternalIdentity(ctx, db.TouchExternalIdentityParams{
+			Email:       email,
+			LastLoginAt: time.Now().UTC(),
+			Issuer:      identity.Issuer,
+			Subject:     identity.Subject,
+		})
+		if err != nil {
+			return db.User{}, err
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		// 身份提供方负责准入，invite 模式下单点登录不需要邀请码，closed 模式下不再创建新用户
+		if apiCfg.RegistrationMode == registrationClosed {
+			return db.User{}, errRegistrationClosed
+		}
+		user, err = apiCfg.provisionExternalUser(ctx, identity, email)
+		if err != nil {
+			return db.User{}, err
+		}
+	default:
+		return db.User{}, err
+	}
+
+	admin, ok := apiCfg.OIDC.IsAdmin(identity)
+	if !ok {
+		return user, nil
+	}
+	role := roleUser
+	if admin {
+		role = roleAdmin
+	}
+	if user.Role == role {
+		return user, nil
+	}
+	log.Printf("[AUTH] Role of %s changed from %s to %s by identity provider groups", user.Username, user.Role, role)
+	return apiCfg.DB.SetUserRole(ctx, db.SetUserRoleParams{Role: role, ID: user.ID})
+}
+
+// provisionExternalUser 为首次登录的外部身份创建本地用户，用户名冲突时追加随机后缀
+func (apiCfg *ApiConfig) provisionExternalUser(ctx context.Context, identity oidc.Identity, email sql.NullString) (db.User, error) {
+	base := externalUsername(identity)
+	now := time.Now().UTC()
+	var user db.User
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		username := base
+		if attempt > 0 {
+			suffix := make([]byte, 3)
+			if _, err := rand.Read(suffix); err != nil {
+				return db.User{}, err
+			}
+			username = base + "-" + hex.EncodeToString(suffix)
+		}
+		user, err = apiCfg.DB.CreateExternalUser(ctx, db.CreateExternalUserParams{
+			ID:          uuid.New(),
+			Username:    username,
+			DisplayName: nullString(identity.Name),
+			Email:       email,
+			CreatedAt:   now,
+		})
+		if !isUniqueViolation(err) {
+			break
+		}
+	}
+	if err != nil {
+		return db.User{}, fmt.Errorf("creating user: %w", err)
+	}
+
+	_, err = apiCfg.DB.CreateExternalIdentity(ctx, db.CreateExternalIdentityParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		Email:     email,
+		CreatedAt: now,
+	})
+	// 同一身份并发首次登录时只保留先创建的用户
+	if isUniqueViolation(err) {
+		if _, err := apiCfg.DB.DeleteUser(ctx, user.ID); err != nil {
+			return db.User{}, err
+		}
+		return apiCfg.DB.GetUserByExternalIdentity(ctx, db.GetUserByExternalIdentityParams{
+			Issuer:  identity.Issuer,
+			Subject: identity.Subject,
+		})
+	}
+	if err != nil {
+		return db.User{}, fmt.Errorf("linking identity: %w", err)
+	}
+	log.Printf("[AUTH] Provisioned user %s for %s subject %s", user.Username, identity.Issuer, identity.Subject)
+	return user, nil
+}
+
+// externalUsername 依次使用 preferred_username、邮箱前缀和 sub 作为用户名
+func externalUsername(identity oidc.Identity) string {
+	name := strings.TrimSpace(identity.PreferredUsername)
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+		name = strings.TrimSpace(name)
+	}
+	if name == "" {
+		name = "user-" + identity.Subject
+	}
+	// 留出随机后缀的长度
+	if runes := []rune(name); len(runes) > 240 {
+		name = string(runes[:240])
+	}
+	return name
+}
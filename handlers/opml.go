@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/opml"
+	"github.com/google/uuid"
+)
+
+const maxOPMLSize = 5 << 20
+
+// ImportOPML 导入 OPML 订阅列表
+// 支持 multipart/form-data（字段名 file）或直接以请求体上传 OPML 文件
+func (apiCfg *ApiConfig) ImportOPML(w http.ResponseWriter, r *http.Request, user db.User) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxOPMLSize)
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Error reading upload: %v", err))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	doc, err := opml.Parse(body)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing OPML: %v", err))
+		return
+	}
+
+	type outlineResult struct {
+		Title  string     `json:"title"`
+		XMLURL string     `json:"xml_url"`
+		Folder *string    `json:"folder"`
+		FeedID *uuid.UUID `json:"feed_id"`
+		// Status: created 新建订阅源 | existing 复用已有订阅源 | failed 失败
+		Status string  `json:"status"`
+		Error  *string `json:"error"`
+	}
+	type importResponse struct {
+		Imported int             `json:"imported"`
+		Failed   int             `json:"failed"`
+		Results  []outlineResult `json:"results"`
+	}
+
+	resp := importResponse{Results: []outlineResult{}}
+	folders := make(map[string]uuid.UUID)
+	for _, sub := range doc.Subscriptions() {
+		result := outlineResult{Title: sub.Title, XMLURL: sub.XMLURL}
+		if sub.Folder != "" {
+			result.Folder = &sub.Folder
+		}
+		feedID, created, err := apiCfg.importSubscription(r, user, sub, folders)
+		if err != nil {
+			result.Status = "failed"
+			msg := err.Error()
+			result.Error = &msg
+			resp.Failed++
+		} else {
+			result.FeedID = &feedID
+			result.Status = "existing"
+			if created {
+				result.Status = "created"
+			}
+			resp.Imported++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	log.Printf("[OPML] %s imported %d outlines, %d failed", user.Username, resp.Imported, resp.Failed)
+	respondWithJSON(w, 200, resp)
+}
+
+// importSubscription 复用或创建订阅源，关注它并放入对应文件夹，超出配额时返回 quotaExceededError
+// 配额检查、创建和关注在同一个持有配额锁的事务中完成，与其他客户端并发添加时也不会超出配额
+func (apiCfg *ApiConfig) importSubscription(r *http.Request, user db.User, sub opml.Subscription, folders map[string]uuid.UUID) (uuid.UUID, bool, error) {
+	created := false
+	var feed db.Feed
+	err := apiCfg.withQuota(r.Context(), user.ID, func(q *db.Queries) error {
+		var err error
+		feed, err = q.GetFeedByURL(r.Context(), sub.XMLURL)
+		if errors.Is(err, sql.ErrNoRows) {
+			// 创建者会关注新的订阅源，两项配额都要满足
+			if err := checkQuota(r.Context(), q, user.ID, quotaFeeds); err != nil {
+				return err
+			}
+			if err := checkQuota(r.Context(), q, user.ID, quotaFollows); err != nil {
+				return err
+			}
+			name := sub.Title
+			if name == "" {
+				name = sub.XMLURL
+			}
+			feed, err = q.CreateFeed(r.Context(), db.CreateFeedParams{
+				ID:        uuid.New(),
+				Name:      name,
+				Url:       sub.XMLURL,
+				CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(),
+				UserID:    user.ID,
+				Category:  nullString(sub.Folder),
+			})
+			if err != nil {
+				return fmt.Errorf("error creating feed: %v", err)
+			}
+			created = true
+		} else if err != nil {
+			return fmt.Errorf("error getting feed: %v", err)
+		} else if err := checkFollowQuota(r.Context(), q, user.ID, feed.ID); err != nil {
+			return err
+		}
+
+		_, err = q.UpsertFeedFollow(r.Context(), db.UpsertFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error following feed: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	if created {
+		apiCfg.auditFeed(r, user, auditFeedCreate, feed, nil)
+	}
+	apiCfg.auditFeed(r, user, auditFollowCreate, feed, nil)
+
+	if sub.Folder == "" {
+		return feed.ID, created, nil
+	}
+	folderID, ok := folders[sub.Folder]
+	if !ok {
+		folder, err := apiCfg.DB.GetOrCreateFolder(r.Context(), db.GetOrCreateFolderParams{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			Name:      sub.Folder,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return feed.ID, created, fmt.Errorf("error creating folder: %v", err)
+		}
+		folderID = folder.ID
+		folders[sub.Folder] = folderID
+	}
+	_, err = apiCfg.DB.AddFeedToFolder(r.Context(), db.AddFeedToFolderParams{
+		FolderID: folderID,
+		UserID:   user.ID,
+		FeedID:   feed.ID,
+	})
+	if err != nil {
+		return feed.ID, created, fmt.Errorf("error adding feed to folder: %v", err)
+	}
+	return feed.ID, created, nil
+}
+
+// ExportOPML 以 OPML 2.0 导出用户的订阅，文件夹对应分类 outline
+func (apiCfg *ApiConfig) ExportOPML(w http.ResponseWriter, r *http.Request, user db.User) {
+	folders, ungrouped, err := apiCfg.userSubscriptions(r, user)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.opml"`)
+	if err := opml.Render(w, fmt.Sprintf("%s 的订阅", user.Username), folders, ungrouped); err != nil {
+		log.Printf("Error rendering OPML: %v", err)
+	}
+}
+
+// userSubscriptions 按文件夹整理用户的订阅，未放入任何文件夹的订阅单独返回
+func (apiCfg *ApiConfig) userSubscriptions(r *http.Request, user db.User) ([]opml.Folder, []opml.Subscription, error) {
+	follows, err := apiCfg.DB.GetFeedFollowsByUserID(r.Context(), user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error getting feed follows: %v", err)
+	}
+	userFolders, err := apiCfg.DB.GetFoldersByUserID(r.Context(), user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error getting folders: %v", err)
+	}
+	folderFeeds, err := apiCfg.DB.GetFolderFeedsByUserID(r.Context(), user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error getting folder feeds: %v", err)
+	}
+
+	grouped := make(map[uuid.UUID]bool)
+	byFolder := make(map[uuid.UUID][]opml.Subscription)
+	for _, ff := range folderFeeds {
+		grouped[ff.FeedID] = true
+		byFolder[ff.FolderID] = append(byFolder[ff.FolderID], opml.Subscription{
+			Title:  ff.FeedName,
+			XMLURL: ff.FeedUrl,
+		})
+	}
+	folders := make([]opml.Folder, 0, len(userFolders))
+	for _, folder := range userFolders {
+		folders = append(folders, opml.Folder{
+			Name:          folder.Name,
+			Subscriptions: byFolder[folder.ID],
+		})
+	}
+	var ungrouped []opml.Subscription
+	for _, follow := range follows {
+		if !grouped[follow.FeedID] {
+			ungrouped = append(ungrouped, opml.Subscription{
+				Title:  follow.FeedName,
+				XMLURL: follow.FeedUrl,
+			})
+		}
+	}
+	return folders, ungrouped, nil
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/rss"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// outputFormats 输出订阅支持的格式及对应的 Content-Type
+var outputFormats = map[string]struct {
+	contentType string
+	render      func(io.Writer, rss.OutputFeed) error
+}{
+	"rss":  {"application/rss+xml; charset=utf-8", rss.RenderRSS},
+	"atom": {"application/atom+xml; charset=utf-8", rss.RenderAtom},
+	"json": {"application/feed+json; charset=utf-8", rss.RenderJSONFeed},
+}
+
+// hashFeedToken 计算输出订阅令牌的 SHA-256 哈希，数据库中只保存哈希
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RotateFeedToken 生成新的输出订阅令牌，旧链接立即失效；明文只在这里返回一次
+func (apiCfg *ApiConfig) RotateFeedToken(w http.ResponseWriter, r *http.Request, user db.User) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error generating feed token: %v", err))
+		return
+	}
+	token := hex.EncodeToString(buf)
+	_, err := apiCfg.DB.SetUserFeedToken(r.Context(), db.SetUserFeedTokenParams{
+		ID:            user.ID,
+		FeedTokenHash: sql.NullString{String: hashFeedToken(token), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error saving feed token: %v", err))
+		return
+	}
+
+	type feedURLs struct {
+		RSS  string `json:"rss"`
+		Atom string `json:"atom"`
+		JSON string `json:"json"`
+	}
+	type response struct {
+		FeedToken string   `json:"feed_token"`
+		URLs      feedURLs `json:"urls"`
+	}
+	base := fmt.Sprintf("%s/feeds/u/%s", apiCfg.BaseURL, token)
+	respondWithJSON(w, 201, response{
+		FeedToken: token,
+		URLs: feedURLs{
+			RSS:  base + ".rss",
+			Atom: base + ".atom",
+			JSON: base + ".json",
+		},
+	})
+}
+
+// RevokeFeedToken 吊销输出订阅令牌
+func (apiCfg *ApiConfig) RevokeFeedToken(w http.ResponseWriter, r *http.Request, user db.User) {
+	_, err := apiCfg.DB.SetUserFeedToken(r.Context(), db.SetUserFeedTokenParams{
+		ID:            user.ID,
+		FeedTokenHash: sql.NullString{},
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error revoking feed token: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// GetUserOutputFeed 以 RSS/Atom/JSON Feed 输出用户的聚合时间线
+// GET /feeds/u/{token}.{format}?folder_id=&starred=true
+func (apiCfg *ApiConfig) GetUserOutputFeed(w http.ResponseWriter, r *http.Request) {
+	format, ok := outputFormats[chi.URLParam(r, "format")]
+	if !ok {
+		respondWithError(w, 404, "Unknown feed format")
+		return
+	}
+	hash := hashFeedToken(chi.URLParam(r, "token"))
+	user, err := apiCfg.DB.GetUserByFeedToken(r.Context(), sql.NullString{String: hash, Valid: true})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, fmt.Sprintf("Error getting user: %v", err))
+		return
+	}
+	if err != nil || subtle.ConstantTimeCompare([]byte(user.FeedTokenHash.String), []byte(hash)) != 1 {
+		respondWithError(w, 404, "Feed not found")
+		return
+	}
+
+	folderID := uuid.NullUUID{}
+	if s := r.URL.Query().Get("folder_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+			return
+		}
+		folderID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	starred := r.URL.Query().Get("starred") == "true"
+
+	posts, err := apiCfg.DB.GetPostsForUser(r.Context(), db.GetPostsForUserParams{
+		UserID:      user.ID,
+		FolderID:    folderID,
+		StarredOnly: starred,
+		PageLimit:   50,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error getting posts: %v", err))
+		return
+	}
+
+	title := fmt.Sprintf("%s 的订阅", user.Username)
+	if starred {
+		title = fmt.Sprintf("%s 的收藏", user.Username)
+	}
+	feed := rss.OutputFeed{
+		Title:       title,
+		Description: "由 Go RSS 订阅器聚合生成",
+		Link:        apiCfg.BaseURL + "/",
+		FeedURL:     apiCfg.BaseURL + r.URL.RequestURI(),
+		Updated:     user.CreatedAt,
+	}
+	for _, post := range posts {
+		// 以入库时间作为最后修改时间，新文章的发布时间可能早于上次抓取；
+		// 收藏输出中较早的文章被收藏时才出现，以收藏时间为准
+		updated := post.CreatedAt
+		if starred {
+			updated = post.StarredAt.Time
+		}
+		if updated.After(feed.Updated) {
+			feed.Updated = updated
+		}
+		feed.Items = append(feed.Items, rss.OutputItem{
+			ID:          "urn:uuid:" + post.ID.String(),
+			Title:       post.Title,
+			Link:        post.Url,
+			Description: post.Description.String,
+			Author:      post.FeedName,
+			Published:   post.PublishedAt,
+		})
+	}
+
+	var body bytes.Buffer
+	if err := format.render(&body, feed); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error rendering feed: %v", err))
+		return
+	}
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	lastModified := feed.Updated.UTC().Truncate(time.Second)
+
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", format.contentType)
+	w.WriteHeader(200)
+	w.Write(body.Bytes())
+}
+
+// notModified 处理条件请求，If-None-Match 优先于 If-Modified-Since
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return inm == etag || inm == "*"
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.After(t)
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// MarkPostRead 标记文章为已读
+func (apiCfg *ApiConfig) MarkPostRead(w http.ResponseWriter, r *http.Request, user db.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing post_id: %v", err))
+		return
+	}
+	err = apiCfg.DB.MarkPostRead(r.Context(), db.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error marking post as read: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// MarkPostUnread 标记文章为未读
+func (apiCfg *ApiConfig) MarkPostUnread(w http.ResponseWriter, r *http.Request, user db.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing post_id: %v", err))
+		return
+	}
+	err = apiCfg.DB.MarkPostUnread(r.Context(), db.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error marking post as unread: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// StarPost 收藏文章
+func (apiCfg *ApiConfig) StarPost(w http.ResponseWriter, r *http.Request, user db.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing post_id: %v", err))
+		return
+	}
+	err = apiCfg.DB.StarPost(r.Context(), db.StarPostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error starring post: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// UnstarPost 取消收藏文章
+func (apiCfg *ApiConfig) UnstarPost(w http.ResponseWriter, r *http.Request, user db.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing post_id: %v", err))
+		return
+	}
+	err = apiCfg.DB.UnstarPost(r.Context(), db.UnstarPostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error unstarring post: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// HidePost 隐藏文章，隐藏后不再出现在时间线中
+func (apiCfg *ApiConfig) HidePost(w http.ResponseWriter, r *http.Request, user db.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing post_id: %v", err))
+		return
+	}
+	err = apiCfg.DB.HidePost(r.Context(), db.HidePostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error hiding post: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// UnhidePost 取消隐藏文章
+func (apiCfg *ApiConfig) UnhidePost(w http.ResponseWriter, r *http.Request, user db.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing post_id: %v", err))
+		return
+	}
+	err = apiCfg.DB.UnhidePost(r.Context(), db.UnhidePostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error unhiding post: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// 受配额限制的资源
+const (
+	quotaFeeds    = "feeds"
+	quotaFollows  = "follows"
+	quotaWebhooks = "webhooks"
+)
+
+// quotaExceededError 表示用户已达到某项资源的配额
+type quotaExceededError struct {
+	Resource string
+	Limit    int32
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: you can have at most %d %s", e.Limit, e.Resource)
+}
+
+func isQuotaExceeded(err error) bool {
+	var quotaErr *quotaExceededError
+	return errors.As(err, &quotaErr)
+}
+
+// withQuota 在事务中持有用户的配额锁执行 fn，fn 在同一事务中检查配额并插入资源
+// 同一用户的并发请求依次执行，不会因为先查后插而超出配额
+func (apiCfg *ApiConfig) withQuota(ctx context.Context, userID uuid.UUID, fn func(q *db.Queries) error) error {
+	return apiCfg.withTx(ctx, func(q *db.Queries) error {
+		if err := q.LockUserQuota(ctx, userID); err != nil {
+			return fmt.Errorf("error locking quota: %v", err)
+		}
+		return fn(q)
+	})
+}
+
+// checkQuota 检查用户是否还能再添加一个 resource，超出配额时返回 quotaExceededError
+// 需要在持有该用户配额锁的事务中调用，见 withQuota
+func checkQuota(ctx context.Context, q *db.Queries, userID uuid.UUID, resource string) error {
+	quota, err := q.GetUserQuota(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error getting quota: %v", err)
+	}
+	return quotaError(quota, resource)
+}
+
+// quotaError 按有效配额和当前用量判断能否再添加一个 resource
+// 配额按用户设置、角色默认值的顺序生效，-1 或都未设置时不限制，GetUserQuota 已把 -1 转为 NULL
+func quotaError(quota db.GetUserQuotaRow, resource string) error {
+	var limit sql.NullInt32
+	var used int64
+	switch resource {
+	case quotaFeeds:
+		limit, used = quota.MaxFeeds, quota.Feeds
+	case quotaFollows:
+		limit, used = quota.MaxFollows, quota.Follows
+	case quotaWebhooks:
+		limit, used = quota.MaxWebhooks, quota.Webhooks
+	}
+	if limit.Valid && used >= int64(limit.Int32) {
+		return &quotaExceededError{Resource: resource, Limit: limit.Int32}
+	}
+	return nil
+}
+
+// checkFollowQuota 检查用户能否关注订阅源，已经关注时不占用新的配额
+func checkFollowQuota(ctx context.Context, q *db.Queries, userID, feedID uuid.UUID) error {
+	following, err := q.IsFollowingFeed(ctx, db.IsFollowingFeedParams{
+		UserID: userID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		return fmt.Errorf("error checking follow: %v", err)
+	}
+	if following {
+		return nil
+	}
+	return checkQuota(ctx, q, userID, quotaFollows)
+}
+
+// quotaParameters 是设置配额的请求参数，-1 表示不限制
+// null 或省略时角色配额为不限制，用户配额为使用角色默认值
+type quotaParameters struct {
+	MaxFeeds    *int32 `json:"max_feeds"`
+	MaxFollows  *int32 `json:"max_follows"`
+	MaxWebhooks *int32 `json:"max_webhooks"`
+}
+
+func parseQuotaParameters(r *http.Request) (quotaParameters, error) {
+	params := quotaParameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return params, fmt.Errorf("Error parsing JSON: %v", err)
+	}
+	for _, limit := range []*int32{params.MaxFeeds, params.MaxFollows, params.MaxWebhooks} {
+		if limit != nil && *limit < -1 {
+			return params, errors.New("quota limits must be -1 (unlimited) or a non-negative number")
+		}
+	}
+	return params, nil
+}
+
+// GetQuota 查看当前用户的有效配额和用量
+// GET /v1/users/quota
+func (apiCfg *ApiConfig) GetQuota(w http.ResponseWriter, r *http.Request, user db.User) {
+	quota, err := apiCfg.DB.GetUserQuota(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting quota: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewQuotaUsage(quota))
+}
+
+// AdminGetRoleQuotas 列出各角色的默认配额
+// GET /v1/admin/quotas
+func (apiCfg *ApiConfig) AdminGetRoleQuotas(w http.ResponseWriter, r *http.Request, admin db.User) {
+	quotas, err := apiCfg.DB.GetRoleQuotas(r.Context())
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting quotas: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(quotas, api.NewRoleQuota))
+}
+
+// AdminUpdateRoleQuota 设置角色的默认配额，已有资源超出新配额时不会被删除，只是不能再添加
+// PUT /v1/admin/quotas/{role} {"max_feeds": 100, "max_follows": 500, "max_webhooks": null}
+func (apiCfg *ApiConfig) AdminUpdateRoleQuota(w http.ResponseWriter, r *http.Request, admin db.User) {
+	role := chi.URLParam(r, "role")
+	if role != roleUser && role != roleAdmin {
+		respondWithError(w, 400, fmt.Sprintf("Invalid role: %q", role))
+		return
+	}
+	params, err := parseQuotaParameters(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	quota, err := apiCfg.DB.UpsertRoleQuota(r.Context(), db.UpsertRoleQuotaParams{
+		Role:        role,
+		MaxFeeds:    nullInt32(params.MaxFeeds),
+		MaxFollows:  nullInt32(params.MaxFollows),
+		MaxWebhooks: nullInt32(params.MaxWebhooks),
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error updating quota: %v", err))
+		return
+	}
+	apiCfg.audit(r, admin, auditEvent{
+		Action:  auditAdminQuotaUpdate,
+		Details: map[string]any{"role": role, "quota": params},
+	})
+	respondWithJSON(w, 200, api.NewRoleQuota(quota))
+}
+
+// AdminUpdateUserQuota 单独设置用户的配额，null 的项使用角色默认配额，-1 表示不受角色配额限制
+// PUT /v1/admin/users/{userID}/quota {"max_feeds": 1000, "max_follows": -1, "max_webhooks": null}
+func (apiCfg *ApiConfig) AdminUpdateUserQuota(w http.ResponseWriter, r *http.Request, admin db.User) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing user_id: %v", err))
+		return
+	}
+	params, err := parseQuotaParameters(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	user, err := apiCfg.DB.SetUserQuota(r.Context(), db.SetUserQuotaParams{
+		MaxFeeds:    nullInt32(params.MaxFeeds),
+		MaxFollows:  nullInt32(params.MaxFollows),
+		MaxWebhooks: nullInt32(params.MaxWebhooks),
+		ID:          userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error updating quota: %v", err))
+		return
+	}
+	apiCfg.audit(r, admin, auditEvent{
+		Action:     auditAdminQuotaUpdate,
+		TargetType: auditTargetUser,
+		TargetID:   user.ID,
+		Details:    map[string]any{"username": user.Username, "quota": params},
+	})
+	respondWithJSON(w, 200, api.NewAdminUser(user))
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+)
+
+func TestQuotaError(t *testing.T) {
+	limit := func(n int32) sql.NullInt32 { return sql.NullInt32{Int32: n, Valid: true} }
+	quota := db.GetUserQuotaRow{
+		MaxFeeds:    limit(10),
+		MaxFollows:  limit(0),
+		MaxWebhooks: sql.NullInt32{},
+		Feeds:       9,
+		Follows:     0,
+		Webhooks:    1000,
+	}
+	tests := []struct {
+		name      string
+		quota     db.GetUserQuotaRow
+		resource  string
+		wantLimit int32
+		exceeded  bool
+	}{
+		{"below limit", quota, quotaFeeds, 0, false},
+		{"at limit", db.GetUserQuotaRow{MaxFeeds: limit(10), Feeds: 10}, quotaFeeds, 10, true},
+		{"over limit after lowering", db.GetUserQuotaRow{MaxFeeds: limit(10), Feeds: 25}, quotaFeeds, 10, true},
+		{"zero limit", quota, quotaFollows, 0, true},
+		{"unlimited", quota, quotaWebhooks, 0, false},
+		{"other resources unaffected", db.GetUserQuotaRow{MaxFeeds: limit(1), Feeds: 1, Follows: 50}, quotaFollows, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := quotaError(tt.quota, tt.resource)
+			if isQuotaExceeded(err) != tt.exceeded {
+				t.Fatalf("quotaError() = %v, exceeded want %v", err, tt.exceeded)
+			}
+			if !tt.exceeded {
+				return
+			}
+			quotaErr := err.(*quotaExceededError)
+			if quotaErr.Resource != tt.resource || quotaErr.Limit != tt.wantLimit {
+				t.Errorf("quotaError() = %+v, want resource %q limit %d", quotaErr, tt.resource, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestParseQuotaParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    [3]*int32
+		wantErr bool
+	}{
+		{"all null", `{}`, [3]*int32{}, false},
+		{"limits", `{"max_feeds": 100, "max_follows": 0, "max_webhooks": null}`, [3]*int32{ptr(int32(100)), ptr(int32(0)), nil}, false},
+		{"unlimited override", `{"max_feeds": -1}`, [3]*int32{ptr(int32(-1)), nil, nil}, false},
+		{"below -1", `{"max_follows": -2}`, [3]*int32{}, true},
+		{"invalid json", `{"max_feeds": "many"}`, [3]*int32{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/", strings.NewReader(tt.body))
+			params, err := parseQuotaParameters(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQuotaParameters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := [3]*int32{params.MaxFeeds, params.MaxFollows, params.MaxWebhooks}
+			for i := range got {
+				if (got[i] == nil) != (tt.want[i] == nil) || (got[i] != nil && *got[i] != *tt.want[i]) {
+					t.Errorf("parseQuotaParameters() field %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/ratelimit"
+)
+
+type rateLimitPolicyKey struct{}
+
+// RateLimit 按客户端 IP 限流，并记下策略供认证中间件按用户限流
+func (apiCfg *ApiConfig) RateLimit(policy ratelimit.Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !apiCfg.takeRateLimit(w, r, "ip:"+policy.Name+":"+apiCfg.clientIP(r), policy.IP) {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rateLimitPolicyKey{}, policy)))
+		})
+	}
+}
+
+// RateLimitByMethod 读取类请求使用 Read 策略，其余请求使用 Write 策略
+func (apiCfg *ApiConfig) RateLimitByMethod(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiCfg.RateLimit(ratelimit.PolicyForMethod(r.Method))(next).ServeHTTP(w, r)
+	})
+}
+
+// limitUser 认证通过后按用户限流，路由没有配置策略时直接放行
+func (apiCfg *ApiConfig) limitUser(w http.ResponseWriter, r *http.Request, user db.User) bool {
+	policy, ok := r.Context().Value(rateLimitPolicyKey{}).(ratelimit.Policy)
+	if !ok {
+		return true
+	}
+	return apiCfg.takeRateLimit(w, r, "user:"+policy.Name+":"+user.ID.String(), policy.User)
+}
+
+// takeRateLimit 取一个令牌并写入 RateLimit-* 响应头，超限时返回 429，已写入响应
+// 存储出错时放行，避免数据库抖动导致整个服务不可用
+func (apiCfg *ApiConfig) takeRateLimit(w http.ResponseWriter, r *http.Request, key string, rate ratelimit.Rate) bool {
+	result, err := ratelimit.Take(r.Context(), apiCfg.RateLimits, key, rate)
+	if err != nil {
+		log.Printf("Error checking rate limit %s: %v\n", key, err)
+		return true
+	}
+	if result.Limit == 0 {
+		return true
+	}
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", ceilSeconds(result.Reset))
+	if !result.Allowed {
+		w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+		respondWithError(w, 429, "Too many requests")
+		return false
+	}
+	return true
+}
+
+// loginLocked 检查用户名在当前 IP 上是否因连续登录失败被锁定，锁定时已设置 Retry-After
+func (apiCfg *ApiConfig) loginLocked(w http.ResponseWriter, r *http.Request, key string) bool {
+	lockedUntil, locked, err := ratelimit.LockedUntil(r.Context(), apiCfg.DB, key)
+	if err != nil {
+		log.Printf("Error checking login lockout: %v\n", err)
+		return false
+	}
+	if locked {
+		w.Header().Set("Retry-After", ceilSeconds(time.Until(lockedUntil)))
+	}
+	return locked
+}
+
+// loginFailed 记录一次登录失败
+func (apiCfg *ApiConfig) loginFailed(r *http.Request, key string) {
+	lockedUntil, locked, err := ratelimit.RecordFailure(r.Context(), apiCfg.DB, key)
+	if err != nil {
+		log.Printf("Error recording login failure: %v\n", err)
+		return
+	}
+	if locked {
+		log.Printf("[AUTH] Login locked for %s until %s", key, lockedUntil.Format(time.RFC3339))
+	}
+}
+
+// loginSucceeded 登录成功后清除失败计数
+func (apiCfg *ApiConfig) loginSucceeded(r *http.Request, key string) {
+	if err := ratelimit.ResetFailures(r.Context(), apiCfg.DB, key); err != nil {
+		log.Printf("Error resetting login failures: %v\n", err)
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(max(d, 0).Seconds())), 10)
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/rules"
+	"github.com/djchanahcjd/go-rss/search"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultRuleApplyDays = 7
+	maxRuleApplyDays     = 90
+)
+
+// ruleParameters 是创建和修改规则的请求体
+// apply_days 为回溯应用的天数，默认 7 天，0 表示只对之后抓取的文章生效
+type ruleParameters struct {
+	Name      string `json:"name"`
+	Field     string `json:"field"`
+	MatchType string `json:"match_type"`
+	Pattern   string `json:"pattern"`
+	Action    string `json:"action"`
+	Tag       string `json:"tag"`
+	Enabled   *bool  `json:"enabled"`
+	ApplyDays *int   `json:"apply_days"`
+}
+
+// parse 解析并校验规则，失败时已写入响应
+func (params *ruleParameters) parse(w http.ResponseWriter, r *http.Request) (rules.Rule, bool) {
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return rules.Rule{}, false
+	}
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" {
+		respondWithError(w, 400, "Rule name is required")
+		return rules.Rule{}, false
+	}
+	if params.MatchType == "" {
+		params.MatchType = rules.MatchContains
+	}
+	rule, err := rules.New(params.Field, params.MatchType, params.Pattern, params.Action, params.Tag)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Invalid rule: %v", err))
+		return rules.Rule{}, false
+	}
+	if params.ApplyDays != nil && (*params.ApplyDays < 0 || *params.ApplyDays > maxRuleApplyDays) {
+		respondWithError(w, 400, fmt.Sprintf("apply_days must be between 0 and %d", maxRuleApplyDays))
+		return rules.Rule{}, false
+	}
+	return rule, true
+}
+
+func (params ruleParameters) enabled() bool {
+	return params.Enabled == nil || *params.Enabled
+}
+
+func (params ruleParameters) applyDays() int {
+	if params.ApplyDays == nil {
+		return defaultRuleApplyDays
+	}
+	return *params.ApplyDays
+}
+
+// CreateRule 创建过滤规则，并回溯应用到最近的文章
+func (apiCfg *ApiConfig) CreateRule(w http.ResponseWriter, r *http.Request, user db.User) {
+	params := ruleParameters{}
+	rule, ok := params.parse(w, r)
+	if !ok {
+		return
+	}
+
+	saved, err := apiCfg.DB.CreateRule(r.Context(), db.CreateRuleParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Name:      params.Name,
+		Field:     rule.Field,
+		MatchType: rule.MatchType,
+		Pattern:   rule.Pattern,
+		Action:    rule.Action,
+		Tag:       nullString(rule.Tag),
+		Enabled:   params.enabled(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error creating rule: %v", err))
+		return
+	}
+
+	applied := 0
+	if saved.Enabled {
+		applied, err = apiCfg.applyRule(r, user, rule, params.applyDays())
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error applying rule: %v", err))
+			return
+		}
+	}
+	respondWithJSON(w, 201, api.AppliedRule{Rule: api.NewRule(saved), Applied: applied})
+}
+
+// GetRules 获取用户的过滤规则
+func (apiCfg *ApiConfig) GetRules(w http.ResponseWriter, r *http.Request, user db.User) {
+	list, err := apiCfg.DB.GetRulesByUserID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting rules: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(list, api.NewRule))
+}
+
+// UpdateRule 修改过滤规则，启用状态下同样回溯应用到最近的文章
+func (apiCfg *ApiConfig) UpdateRule(w http.ResponseWriter, r *http.Request, user db.User) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "ruleID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing rule_id: %v", err))
+		return
+	}
+	params := ruleParameters{}
+	rule, ok := params.parse(w, r)
+	if !ok {
+		return
+	}
+
+	saved, err := apiCfg.DB.UpdateRule(r.Context(), db.UpdateRuleParams{
+		ID:        ruleID,
+		UserID:    user.ID,
+		Name:      params.Name,
+		Field:     rule.Field,
+		MatchType: rule.MatchType,
+		Pattern:   rule.Pattern,
+		Action:    rule.Action,
+		Tag:       nullString(rule.Tag),
+		Enabled:   params.enabled(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Rule not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error updating rule: %v", err))
+		return
+	}
+
+	applied := 0
+	if saved.Enabled {
+		applied, err = apiCfg.applyRule(r, user, rule, params.applyDays())
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error applying rule: %v", err))
+			return
+		}
+	}
+	respondWithJSON(w, 200, api.AppliedRule{Rule: api.NewRule(saved), Applied: applied})
+}
+
+// DeleteRule 删除过滤规则，已经执行过的动作不会撤销
+func (apiCfg *ApiConfig) DeleteRule(w http.ResponseWriter, r *http.Request, user db.User) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "ruleID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing rule_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.DeleteRule(r.Context(), db.DeleteRuleParams{
+		ID:     ruleID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting rule: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Rule not found")
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// applyRule 把规则回溯应用到用户最近 days 天的文章，返回命中的文章数
+func (apiCfg *ApiConfig) applyRule(r *http.Request, user db.User, rule rules.Rule, days int) (int, error) {
+	if days == 0 {
+		return 0, nil
+	}
+	posts, err := apiCfg.DB.GetPostsForRules(r.Context(), db.GetPostsForRulesParams{
+		UserID:      user.ID,
+		PublishedAt: time.Now().UTC().AddDate(0, 0, -days),
+	})
+	if err != nil {
+		return 0, err
+	}
+	applied := 0
+	for _, post := range posts {
+		result := rules.Evaluate([]rules.Rule{rule}, rules.Post{
+			FeedName:   post.FeedName,
+			FeedURL:    post.FeedUrl,
+			Title:      post.Title,
+			Content:    search.PlainText(post.Description.String),
+			Author:     post.Author.String,
+			Categories: post.Categories,
+		})
+		if result.Empty() {
+			continue
+		}
+		if err := rules.Save(r.Context(), apiCfg.DB, user.ID, post.ID, result); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
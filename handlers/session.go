@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/sessions"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// 刷新令牌放在 HttpOnly Cookie 中，只发送给 /v1/sessions 下的接口；
+// CSRF 令牌放在前端可读的 Cookie 中，调用这些接口时需要通过 X-CSRF-Token 请求头回传
+const (
+	refreshCookieName = "refresh_token"
+	refreshCookiePath = "/v1/sessions"
+	csrfCookieName    = "csrf_token"
+	csrfHeaderName    = "X-CSRF-Token"
+)
+
+var errInvalidRefreshToken = errors.New("invalid refresh token")
+
+// startSession 创建新会话，写入刷新令牌和 CSRF Cookie 并返回访问令牌
+func (apiCfg *ApiConfig) startSession(w http.ResponseWriter, r *http.Request, code int, user db.User) {
+	session, refreshToken, err := apiCfg.createSession(r, user)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error creating session: %v", err))
+		return
+	}
+	apiCfg.respondWithSession(w, code, user, session, refreshToken)
+}
+
+// createSession 保存新会话，返回刷新令牌明文
+func (apiCfg *ApiConfig) createSession(r *http.Request, user db.User) (db.Session, string, error) {
+	refreshToken, hash, err := sessions.NewRefreshToken()
+	if err != nil {
+		return db.Session{}, "", err
+	}
+	now := time.Now().UTC()
+	session, err := apiCfg.DB.CreateSession(r.Context(), db.CreateSessionParams{
+		ID:               uuid.New(),
+		UserID:           user.ID,
+		RefreshTokenHash: hash,
+		UserAgent:        r.UserAgent(),
+		Ip:               apiCfg.clientIP(r),
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(sessions.RefreshTokenTTL),
+	})
+	if err != nil {
+		return db.Session{}, "", err
+	}
+	return session, refreshToken, nil
+}
+
+func (apiCfg *ApiConfig) respondWithSession(w http.ResponseWriter, code int, user db.User, session db.Session, refreshToken string) {
+	accessToken, expiresAt, err := sessions.Sign(apiCfg.SessionSecret, user.ID, session.ID, time.Now())
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error signing access token: %v", err))
+		return
+	}
+	csrfToken := apiCfg.setSessionCookies(w, session, refreshToken)
+	respondWithJSON(w, code, api.Session{
+		User:        api.NewUser(user),
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+		CsrfToken:   csrfToken,
+	})
+}
+
+// setSessionCookies 写入刷新令牌和 CSRF Cookie，返回 CSRF 令牌
+func (apiCfg *ApiConfig) setSessionCookies(w http.ResponseWriter, session db.Session, refreshToken string) string {
+	csrfToken := sessions.CSRFToken(apiCfg.SessionSecret, refreshToken)
+	secure := apiCfg.secureCookies()
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    refreshToken,
+		Path:     refreshCookiePath,
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    csrfToken,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return csrfToken
+}
+
+// secureCookies 通过 HTTPS 对外提供服务时 Cookie 只通过 HTTPS 发送
+func (apiCfg *ApiConfig) secureCookies() bool {
+	return strings.HasPrefix(apiCfg.BaseURL, "https://")
+}
+
+// clearSessionCookies 注销或刷新失败时清除 Cookie
+func (apiCfg *ApiConfig) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: refreshCookieName, Path: refreshCookiePath, MaxAge: -1, HttpOnly: true})
+	http.SetCookie(w, &http.Cookie{Name: csrfCookieName, Path: "/", MaxAge: -1})
+}
+
+// refreshTokenFromRequest 读取刷新令牌 Cookie，并校验请求头中的 CSRF 令牌
+func (apiCfg *ApiConfig) refreshTokenFromRequest(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(refreshCookieName)
+	if err != nil || cookie.Value == "" {
+		return "", errInvalidRefreshToken
+	}
+	expected := sessions.CSRFToken(apiCfg.SessionSecret, cookie.Value)
+	if !hmac.Equal([]byte(r.Header.Get(csrfHeaderName)), []byte(expected)) {
+		return "", errors.New("invalid CSRF token")
+	}
+	return cookie.Value, nil
+}
+
+// RefreshSession 用刷新令牌换取新的访问令牌，同时轮换刷新令牌
+// POST /v1/sessions/refresh (Cookie: refresh_token, X-CSRF-Token)
+// 多个标签页同时刷新时，刚被轮换掉的旧令牌在 RefreshGracePeriod 内会得到同一对新令牌；
+// 超过宽限期或已再次轮换后仍使用旧令牌视为泄露，吊销整个会话
+func (apiCfg *ApiConfig) RefreshSession(w http.ResponseWriter, r *http.Request) {
+	refreshToken, err := apiCfg.refreshTokenFromRequest(r)
+	if err != nil {
+		respondWithError(w, 401, err.Error())
+		return
+	}
+	newToken, newHash := sessions.NextRefreshToken(apiCfg.SessionSecret, refreshToken)
+	hash := sessions.HashToken(refreshToken)
+	now := time.Now().UTC()
+	session, err := apiCfg.DB.RotateSessionToken(r.Context(), db.RotateSessionTokenParams{
+		NewTokenHash: newHash,
+		Now:          now,
+		ExpiresAt:    now.Add(sessions.RefreshTokenTTL),
+		TokenHash:    hash,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		session, err = apiCfg.DB.GetRecentlyRotatedSession(r.Context(), db.GetRecentlyRotatedSessionParams{
+			PreviousTokenHash: nullString(hash),
+			RefreshTokenHash:  newHash,
+			RotatedAfter:      now.Add(-sessions.RefreshGracePeriod),
+			Now:               now,
+		})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := apiCfg.DB.RevokeSessionByPreviousToken(r.Context(), nullString(hash)); err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error revoking session: %v", err))
+			return
+		}
+		apiCfg.clearSessionCookies(w)
+		respondWithError(w, 401, errInvalidRefreshToken.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error refreshing session: %v", err))
+		return
+	}
+	user, err := apiCfg.DB.GetUserByID(r.Context(), session.UserID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error getting user: %v", err))
+		return
+	}
+	if user.DisabledAt.Valid {
+		apiCfg.clearSessionCookies(w)
+		respondWithError(w, 403, "Account is disabled")
+		return
+	}
+	apiCfg.respondWithSession(w, 200, user, session, newToken)
+}
+
+// Logout 吊销当前会话，其访问令牌随即失效
+// POST /v1/sessions/logout (Cookie: refresh_token, X-CSRF-Token)
+func (apiCfg *ApiConfig) Logout(w http.ResponseWriter, r *http.Request) {
+	refreshToken, err := apiCfg.refreshTokenFromRequest(r)
+	if err != nil {
+		respondWithError(w, 401, err.Error())
+		return
+	}
+	session, err := apiCfg.DB.RevokeSessionByToken(r.Context(), sessions.HashToken(refreshToken))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, fmt.Sprintf("Error revoking session: %v", err))
+		return
+	}
+	// 会话已失效时没有可记录的操作者，照常清除 Cookie
+	if err == nil {
+		user, err := apiCfg.DB.GetUserByID(r.Context(), session.UserID)
+		if err == nil {
+			apiCfg.auditSessionRevoke(r, user, session.ID, "logout")
+		}
+	}
+	apiCfg.clearSessionCookies(w)
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// GetSessions 列出用户当前有效的登录会话，current 标记发起请求的会话
+func (apiCfg *ApiConfig) GetSessions(w http.ResponseWriter, r *http.Request, user db.User) {
+	list, err := apiCfg.DB.GetActiveSessionsByUserID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting sessions: %v", err))
+		return
+	}
+	current := apiCfg.currentSessionID(r)
+	resp := make([]api.SessionInfo, 0, len(list))
+	for _, s := range list {
+		resp = append(resp, api.NewSessionInfo(s, current.Valid && s.ID == current.UUID))
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+// currentSessionID 返回发起请求的会话，使用 API Key 时为空
+func (apiCfg *ApiConfig) currentSessionID(r *http.Request) uuid.NullUUID {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return uuid.NullUUID{}
+	}
+	claims, err := sessions.Verify(apiCfg.SessionSecret, token, time.Now())
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: claims.SessionID, Valid: true}
+}
+
+// DeleteSession 吊销指定会话，例如在其他设备上退出登录
+func (apiCfg *ApiConfig) DeleteSession(w http.ResponseWriter, r *http.Request, user db.User) {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing session_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.RevokeSession(r.Context(), db.RevokeSessionParams{
+		ID:     sessionID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error revoking session: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Session not found")
+		return
+	}
+	apiCfg.auditSessionRevoke(r, user, sessionID, "revoked")
+	respondWithJSON(w, 200, struct{}{})
+}
+
+func (apiCfg *ApiConfig) auditSessionRevoke(r *http.Request, user db.User, sessionID uuid.UUID, reason string) {
+	apiCfg.audit(r, user, auditEvent{
+		Action:     auditSessionRevoke,
+		TargetType: auditTargetSession,
+		TargetID:   sessionID,
+		Details:    map[string]any{"reason": reason},
+	})
+}
+
+// clientIP 返回客户端地址，只有连接来自 TrustedProxies 时才采信 X-Forwarded-For
+func (apiCfg *ApiConfig) clientIP(r *http.Request) string {
+	return forwardedClientIP(r, apiCfg.TrustedProxies)
+}
+
+// forwardedClientIP 从连接的远端地址开始，沿 X-Forwarded-For 从右向左跳过可信代理，
+// 返回第一个不可信的地址；客户端自己填写的更靠左的部分无法伪造结果
+func forwardedClientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !isTrustedProxy(addr, trusted) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// 无法解析的值说明之前的部分不可信，停在最后一个可信代理处
+			break
+		}
+		addr = hop.Unmap()
+		if !isTrustedProxy(addr, trusted) {
+			break
+		}
+	}
+	return addr.String()
+}
+
+func isTrustedProxy(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
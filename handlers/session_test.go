@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/sessions"
+	"github.com/google/uuid"
+)
+
+func TestForwardedClientIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("127.0.0.1/32"),
+	}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		trusted    []netip.Prefix
+		want       string
+	}{
+		{"no proxies configured", "10.0.0.2:1234", []string{"203.0.113.7"}, nil, "10.0.0.2"},
+		{"untrusted peer", "198.51.100.9:1234", []string{"203.0.113.7"}, trusted, "198.51.100.9"},
+		{"trusted peer without header", "10.0.0.2:1234", nil, trusted, "10.0.0.2"},
+		{"single proxy", "10.0.0.2:1234", []string{"203.0.113.7"}, trusted, "203.0.113.7"},
+		{"spoofed left entries", "10.0.0.2:1234", []string{"1.2.3.4, 203.0.113.7"}, trusted, "203.0.113.7"},
+		{"chain of proxies", "127.0.0.1:1234", []string{"203.0.113.7, 10.1.1.1"}, trusted, "203.0.113.7"},
+		{"multiple headers", "10.0.0.2:1234", []string{"1.2.3.4", "203.0.113.7"}, trusted, "203.0.113.7"},
+		{"all hops trusted", "10.0.0.2:1234", []string{"10.9.9.9"}, trusted, "10.9.9.9"},
+		{"garbage stops the walk", "10.0.0.2:1234", []string{"203.0.113.7, unknown"}, trusted, "10.0.0.2"},
+		{"ipv4 mapped hop", "10.0.0.2:1234", []string{"::ffff:203.0.113.7"}, trusted, "203.0.113.7"},
+		{"ipv6 client", "[::1]:1234", []string{"2001:db8::1"}, []netip.Prefix{netip.MustParsePrefix("::1/128")}, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := forwardedClientIP(r, tt.trusted); got != tt.want {
+				t.Errorf("forwardedClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeSessionStore 保存一个会话，按 sessions.sql 中的条件模拟会话查询
+// 比较时间时使用 handler 传入的 now，与查询只使用一个时钟的约定一致
+type fakeSessionStore struct {
+	session db.Session
+	user    db.User
+}
+
+func newFakeSessionStore(refreshToken string) *fakeSessionStore {
+	now := time.Now().UTC()
+	user := db.User{ID: uuid.New(), Username: "alice", Role: "user"}
+	return &fakeSessionStore{
+		user: user,
+		session: db.Session{
+			ID:               uuid.New(),
+			UserID:           user.ID,
+			RefreshTokenHash: sessions.HashToken(refreshToken),
+			CreatedAt:        now.Add(-time.Hour),
+			LastUsedAt:       now.Add(-time.Hour),
+			ExpiresAt:        now.Add(sessions.RefreshTokenTTL),
+		},
+	}
+}
+
+func (s *fakeSessionStore) row() [][]driver.Value {
+	x := s.session
+	return [][]driver.Value{{
+		x.ID.String(), x.UserID.String(), x.RefreshTokenHash, fakeNull(x.PreviousTokenHash), x.UserAgent, x.Ip,
+		x.CreatedAt, x.LastUsedAt, x.ExpiresAt, fakeNull(x.RevokedAt),
+	}}
+}
+
+func (s *fakeSessionStore) active(now time.Time) bool {
+	return !s.session.RevokedAt.Valid && s.session.ExpiresAt.After(now)
+}
+
+func (s *fakeSessionStore) revoke() [][]driver.Value {
+	s.session.RevokedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	return s.row()
+}
+
+func (s *fakeSessionStore) queries() map[string]fakeQuery {
+	return map[string]fakeQuery{
+		"RotateSessionToken": func(args []driver.Value) ([][]driver.Value, error) {
+			now := args[1].(time.Time)
+			if args[3] != s.session.RefreshTokenHash || !s.active(now) {
+				return nil, nil
+			}
+			s.session.PreviousTokenHash = sql.NullString{String: s.session.RefreshTokenHash, Valid: true}
+			s.session.RefreshTokenHash = args[0].(string)
+			s.session.LastUsedAt = now
+			s.session.ExpiresAt = args[2].(time.Time)
+			return s.row(), nil
+		},
+		"GetRecentlyRotatedSession": func(args []driver.Value) ([][]driver.Value, error) {
+			if args[0] != fakeNull(s.session.PreviousTokenHash) || args[1] != s.session.RefreshTokenHash ||
+				!s.session.LastUsedAt.After(args[2].(time.Time)) || !s.active(args[3].(time.Time)) {
+				return nil, nil
+			}
+			return s.row(), nil
+		},
+		"RevokeSessionByPreviousToken": func(args []driver.Value) ([][]driver.Value, error) {
+			if args[0] != fakeNull(s.session.PreviousTokenHash) || s.session.RevokedAt.Valid {
+				return nil, nil
+			}
+			return s.revoke(), nil
+		},
+		"RevokeSessionByToken": func(args []driver.Value) ([][]driver.Value, error) {
+			if args[0] != s.session.RefreshTokenHash || s.session.RevokedAt.Valid {
+				return nil, nil
+			}
+			return s.revoke(), nil
+		},
+		"GetSessionUser": func(args []driver.Value) ([][]driver.Value, error) {
+			if args[0] != s.session.ID.String() || !s.active(time.Now()) || s.user.DisabledAt.Valid {
+				return nil, nil
+			}
+			return [][]driver.Value{fakeUserRow(s.user)}, nil
+		},
+		"GetUserByID": func(args []driver.Value) ([][]driver.Value, error) {
+			return [][]driver.Value{fakeUserRow(s.user)}, nil
+		},
+		"CreateAuditEvent": fakeNoRows,
+	}
+}
+
+// sessionRequest 构造携带刷新令牌 Cookie 和 CSRF 请求头的请求
+func sessionRequest(apiCfg *ApiConfig, path, refreshToken string) *http.Request {
+	r := httptest.NewRequest("POST", path, nil)
+	r.AddCookie(&http.Cookie{Name: refreshCookieName, Value: refreshToken})
+	r.Header.Set(csrfHeaderName, sessions.CSRFToken(apiCfg.SessionSecret, refreshToken))
+	return r
+}
+
+// refreshCookie 返回响应写入的刷新令牌，清除 Cookie 时为空
+func refreshCookie(w *httptest.ResponseRecorder) string {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == refreshCookieName {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func TestRefreshSession(t *testing.T) {
+	token, _, err := sessions.NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, nextHash := sessions.NextRefreshToken([]byte("test secret"), token)
+	_, rotatedAgainHash := sessions.NextRefreshToken([]byte("test secret"), next)
+	rotated := func(lastUsed time.Duration, refreshHash string) func(*db.Session) {
+		return func(s *db.Session) {
+			s.PreviousTokenHash = sql.NullString{String: sessions.HashToken(token), Valid: true}
+			s.RefreshTokenHash = refreshHash
+			s.LastUsedAt = time.Now().UTC().Add(-lastUsed)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		session     func(*db.Session)
+		disabled    bool
+		noCSRF      bool
+		wantCode    int
+		wantToken   string
+		wantRevoked bool
+	}{
+		{name: "rotates the refresh token", wantCode: 200, wantToken: next},
+		{name: "reuse inside the grace window", session: rotated(sessions.RefreshGracePeriod/2, nextHash), wantCode: 200, wantToken: next},
+		{name: "reuse after the grace window", session: rotated(sessions.RefreshGracePeriod+time.Second, nextHash), wantCode: 401, wantRevoked: true},
+		{name: "reuse after another rotation", session: rotated(time.Second, rotatedAgainHash), wantCode: 401, wantRevoked: true},
+		{name: "revoked session", session: func(s *db.Session) {
+			s.RevokedAt = sql.NullTime{Time: time.Now().UTC().Add(-time.Minute), Valid: true}
+		}, wantCode: 401, wantRevoked: true},
+		{name: "expired session", session: func(s *db.Session) {
+			s.ExpiresAt = time.Now().UTC().Add(-time.Second)
+		}, wantCode: 401},
+		{name: "disabled user", disabled: true, wantCode: 403},
+		{name: "missing CSRF header", noCSRF: true, wantCode: 401},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeSessionStore(token)
+			if tt.session != nil {
+				tt.session(&store.session)
+			}
+			if tt.disabled {
+				store.user.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+			}
+			_, apiCfg := newFakeDB(t, store.queries())
+			r := sessionRequest(apiCfg, "/v1/sessions/refresh", token)
+			if tt.noCSRF {
+				r.Header.Del(csrfHeaderName)
+			}
+			w := httptest.NewRecorder()
+			apiCfg.RefreshSession(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if got := refreshCookie(w); got != tt.wantToken {
+				t.Errorf("refresh cookie = %q, want %q", got, tt.wantToken)
+			}
+			if store.session.RevokedAt.Valid != tt.wantRevoked {
+				t.Errorf("revoked = %v, want %v", store.session.RevokedAt.Valid, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+func TestRefreshSessionUsesOneClock(t *testing.T) {
+	token, _, err := sessions.NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newFakeSessionStore(token)
+	fake, apiCfg := newFakeDB(t, store.queries())
+	w := httptest.NewRecorder()
+	apiCfg.RefreshSession(w, sessionRequest(apiCfg, "/v1/sessions/refresh", token))
+	if w.Code != 200 {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	args := fake.callsTo("RotateSessionToken")[0]
+	now := args[1].(time.Time)
+	if now.Location() != time.UTC {
+		t.Errorf("now = %v, want UTC", now)
+	}
+	if !store.session.LastUsedAt.Equal(now) || !store.session.ExpiresAt.Equal(now.Add(sessions.RefreshTokenTTL)) {
+		t.Errorf("last_used_at = %v, expires_at = %v, want both computed from %v", store.session.LastUsedAt, store.session.ExpiresAt, now)
+	}
+}
+
+func TestSessionAccessToken(t *testing.T) {
+	token, _, err := sessions.NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		setup    func(*testing.T, *ApiConfig, *fakeSessionStore)
+		wantCode int
+	}{
+		{"active session", nil, 200},
+		{"after logout", func(t *testing.T, apiCfg *ApiConfig, _ *fakeSessionStore) {
+			w := httptest.NewRecorder()
+			apiCfg.Logout(w, sessionRequest(apiCfg, "/v1/sessions/logout", token))
+			if w.Code != 200 {
+				t.Fatalf("logout status = %d: %s", w.Code, w.Body)
+			}
+		}, 401},
+		{"session expired", func(_ *testing.T, _ *ApiConfig, store *fakeSessionStore) {
+			store.session.ExpiresAt = time.Now().UTC().Add(-time.Second)
+		}, 401},
+		{"user disabled", func(_ *testing.T, _ *ApiConfig, store *fakeSessionStore) {
+			store.user.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}, 401},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeSessionStore(token)
+			_, apiCfg := newFakeDB(t, store.queries())
+			access, _, err := sessions.Sign(apiCfg.SessionSecret, store.user.ID, store.session.ID, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.setup != nil {
+				tt.setup(t, apiCfg, store)
+			}
+
+			handler := apiCfg.AuthMiddleware(func(w http.ResponseWriter, r *http.Request, user db.User) {
+				respondWithJSON(w, 200, struct{}{})
+			})
+			r := httptest.NewRequest("GET", "/v1/users", nil)
+			r.Header.Set("Authorization", "Bearer "+access)
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/sessions"
+)
+
+const (
+	streamBatchSize = 100
+	streamHeartbeat = 25 * time.Second
+	streamRetry     = 5 * time.Second
+	// streamWindow 每次推送时向前回看的 short_id 数量。short_id 在插入时分配、提交时才可见，
+	// 事务提交顺序与分配顺序不一致时，较小的 short_id 可能晚于较大的出现，回看窗口内的文章会被补发
+	streamWindow = 512
+)
+
+// streamAccessTokenParam 是 EventSource 无法设置请求头时传递会话访问令牌的查询参数
+const streamAccessTokenParam = "access_token"
+
+// StreamAuthMiddleware 在没有 Authorization 请求头时读取 ?access_token= 中的会话访问令牌，
+// 以便浏览器的 EventSource 使用；只接受短期有效的访问令牌，API Key 仍需通过请求头传递
+func (apiCfg *ApiConfig) StreamAuthMiddleware(handler authedHandler) http.HandlerFunc {
+	next := apiCfg.AuthMiddleware(handler)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get(streamAccessTokenParam); token != "" && r.Header.Get("Authorization") == "" {
+			if !sessions.IsAccessToken(token) {
+				respondWithError(w, 403, fmt.Sprintf("Auth error: %s only accepts session access tokens", streamAccessTokenParam))
+				return
+			}
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next(w, r)
+	}
+}
+
+// streamCursor 记录已推送的位置：after 之前窗口内已推送的 short_id 保存在 sent 中用于去重，
+// floor 是连接时的起点，之前的文章不会推送
+type streamCursor struct {
+	floor int64
+	after int64
+	sent  map[int64]struct{}
+}
+
+func newStreamCursor(after int64) *streamCursor {
+	return &streamCursor{floor: after, after: after, sent: make(map[int64]struct{})}
+}
+
+// scanFrom 返回本次查询的起点：after 向前回看 streamWindow，但不早于 floor
+func (c *streamCursor) scanFrom() int64 {
+	return max(c.after-streamWindow, c.floor)
+}
+
+// mark 记录 short_id 已推送，返回 false 表示此前已经推送过
+func (c *streamCursor) mark(shortID int64) bool {
+	if shortID <= c.floor {
+		return false
+	}
+	if _, ok := c.sent[shortID]; ok {
+		return false
+	}
+	c.sent[shortID] = struct{}{}
+	c.after = max(c.after, shortID)
+	return true
+}
+
+// prune 丢弃已经移出回看窗口的记录
+func (c *streamCursor) prune() {
+	from := c.scanFrom()
+	for id := range c.sent {
+		if id <= from {
+			delete(c.sent, id)
+		}
+	}
+}
+
+// StreamPosts 通过 Server-Sent Events 推送用户关注的订阅源中新入库的文章
+// GET /v1/posts/stream
+// 事件 ID 为文章 short_id，断线重连时根据 Last-Event-ID 补发错过的文章
+// 同一连接内会回看最近 streamWindow 个 short_id，补发晚提交的文章，已推送的不会重复
+func (apiCfg *ApiConfig) StreamPosts(w http.ResponseWriter, r *http.Request, user db.User) {
+	if apiCfg.Stream == nil {
+		respondWithError(w, 503, "Post stream is not available")
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var afterID int64
+	if lastID != "" {
+		n, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || n < 0 {
+			respondWithError(w, 400, fmt.Sprintf("Invalid Last-Event-ID: %q", lastID))
+			return
+		}
+		afterID = n
+	} else {
+		// 首次连接只推送之后入库的文章
+		n, err := apiCfg.DB.GetMaxPostShortID(r.Context())
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error getting latest post: %v", err))
+			return
+		}
+		afterID = n
+	}
+
+	// 先订阅再补发，避免两者之间入库的文章被漏掉
+	wake, unsubscribe := apiCfg.Stream.Subscribe()
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(200)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+
+	cursor := newStreamCursor(afterID)
+	// send 推送回看窗口起点之后尚未推送的文章
+	send := func() error {
+		pageAfter := cursor.scanFrom()
+		for {
+			posts, err := apiCfg.DB.GetStreamPosts(r.Context(), db.GetStreamPostsParams{
+				UserID:    user.ID,
+				AfterID:   pageAfter,
+				PageLimit: streamBatchSize,
+			})
+			if err != nil {
+				return err
+			}
+			for _, post := range posts {
+				pageAfter = post.ShortID
+				if !cursor.mark(post.ShortID) {
+					continue
+				}
+				data, err := json.Marshal(api.NewStreamPost(post))
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "id: %d\nevent: post\ndata: %s\n\n", post.ShortID, data)
+			}
+			if len(posts) < streamBatchSize {
+				cursor.prune()
+				return rc.Flush()
+			}
+		}
+	}
+
+	if err := send(); err != nil {
+		log.Printf("Error streaming posts: %v\n", err)
+		return
+	}
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-wake:
+			if err := send(); err != nil {
+				log.Printf("Error streaming posts: %v\n", err)
+				return
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
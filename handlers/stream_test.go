@@ -0,0 +1,72 @@
+package handlers
+
+import "testing"
+
+func TestStreamCursor(t *testing.T) {
+	tests := []struct {
+		name     string
+		floor    int64
+		batches  [][]int64
+		want     []int64
+		wantFrom int64
+	}{
+		{
+			name:     "in order",
+			floor:    10,
+			batches:  [][]int64{{11, 12}, {13}},
+			want:     []int64{11, 12, 13},
+			wantFrom: 10,
+		},
+		{
+			name:     "late commit inside window",
+			floor:    0,
+			batches:  [][]int64{{1, 3}, {1, 2, 3, 4}},
+			want:     []int64{1, 3, 2, 4},
+			wantFrom: 0,
+		},
+		{
+			name:     "nothing at or before floor",
+			floor:    100,
+			batches:  [][]int64{{99, 100, 101}},
+			want:     []int64{101},
+			wantFrom: 100,
+		},
+		{
+			name:     "window moves forward",
+			floor:    0,
+			batches:  [][]int64{{1000}, {600, 1000, 1001}},
+			want:     []int64{1000, 600, 1001},
+			wantFrom: 1001 - streamWindow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := newStreamCursor(tt.floor)
+			var got []int64
+			for _, batch := range tt.batches {
+				for _, id := range batch {
+					if cursor.mark(id) {
+						got = append(got, id)
+					}
+				}
+				cursor.prune()
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("sent = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("sent = %v, want %v", got, tt.want)
+				}
+			}
+			if from := cursor.scanFrom(); from != tt.wantFrom {
+				t.Errorf("scanFrom() = %d, want %d", from, tt.wantFrom)
+			}
+			for id := range cursor.sent {
+				if id <= cursor.scanFrom() {
+					t.Errorf("sent still holds %d outside the window", id)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,837 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// 团队角色：owner 管理成员和团队，editor 管理团队订阅和文件夹，viewer 只能阅读
+const (
+	teamRoleOwner  = "owner"
+	teamRoleEditor = "editor"
+	teamRoleViewer = "viewer"
+)
+
+var teamRoleLevels = map[string]int{
+	teamRoleViewer: 1,
+	teamRoleEditor: 2,
+	teamRoleOwner:  3,
+}
+
+const errLastTeamOwner = "A team must keep at least one owner"
+
+// teamForMember 按路径中的 teamID 查找请求者所在的团队，并要求其角色不低于 required
+// 不是成员时返回 404，不暴露团队是否存在
+func (apiCfg *ApiConfig) teamForMember(w http.ResponseWriter, r *http.Request, user db.User, required string) (db.GetTeamForMemberRow, bool) {
+	teamID, err := uuid.Parse(chi.URLParam(r, "teamID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing team_id: %v", err))
+		return db.GetTeamForMemberRow{}, false
+	}
+	team, err := apiCfg.DB.GetTeamForMember(r.Context(), db.GetTeamForMemberParams{
+		ID:     teamID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Team not found")
+		return db.GetTeamForMemberRow{}, false
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting team: %v", err))
+		return db.GetTeamForMemberRow{}, false
+	}
+	if teamRoleLevels[team.Role] < teamRoleLevels[required] {
+		respondWithError(w, 403, fmt.Sprintf("Team role %q required", required))
+		return db.GetTeamForMemberRow{}, false
+	}
+	return team, true
+}
+
+func teamFromRow(row db.GetTeamForMemberRow) db.Team {
+	return db.Team{
+		ID:        row.ID,
+		Name:      row.Name,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+}
+
+// CreateTeam 创建团队，创建者成为 owner
+// POST /v1/teams
+func (apiCfg *ApiConfig) CreateTeam(w http.ResponseWriter, r *http.Request, user db.User) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		respondWithError(w, 400, "Team name is required")
+		return
+	}
+
+	now := time.Now().UTC()
+	team, err := apiCfg.DB.CreateTeam(r.Context(), db.CreateTeamParams{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedAt: now,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error creating team: %v", err))
+		return
+	}
+	_, err = apiCfg.DB.AddTeamMember(r.Context(), db.AddTeamMemberParams{
+		TeamID:    team.ID,
+		UserID:    user.ID,
+		Role:      teamRoleOwner,
+		CreatedAt: now,
+	})
+	if err != nil {
+		// 没有 owner 的团队无人能管理，直接删除
+		apiCfg.DB.DeleteTeam(r.Context(), team.ID)
+		respondWithError(w, 400, fmt.Sprintf("Error creating team: %v", err))
+		return
+	}
+	respondWithJSON(w, 201, api.NewTeam(team, teamRoleOwner))
+}
+
+// GetTeams 获取用户所在的团队
+// GET /v1/teams
+func (apiCfg *ApiConfig) GetTeams(w http.ResponseWriter, r *http.Request, user db.User) {
+	teams, err := apiCfg.DB.GetTeamsByUserID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting teams: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(teams, api.NewTeamListItem))
+}
+
+// GetTeam 获取团队详情和成员列表
+// GET /v1/teams/{teamID}
+func (apiCfg *ApiConfig) GetTeam(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleViewer)
+	if !ok {
+		return
+	}
+	members, err := apiCfg.DB.GetTeamMembers(r.Context(), team.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting team members: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewTeamWithMembers(teamFromRow(team), team.Role, members))
+}
+
+// UpdateTeam 重命名团队，需要 owner 角色
+// PUT /v1/teams/{teamID}
+func (apiCfg *ApiConfig) UpdateTeam(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleOwner)
+	if !ok {
+		return
+	}
+	type parameters struct {
+		Name string `json:"name"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		respondWithError(w, 400, "Team name is required")
+		return
+	}
+
+	updated, err := apiCfg.DB.UpdateTeam(r.Context(), db.UpdateTeamParams{
+		ID:   team.ID,
+		Name: name,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error updating team: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewTeam(updated, team.Role))
+}
+
+// DeleteTeam 删除团队及其订阅和文件夹，成员的阅读状态保留，需要 owner 角色
+// DELETE /v1/teams/{teamID}
+func (apiCfg *ApiConfig) DeleteTeam(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleOwner)
+	if !ok {
+		return
+	}
+	if err := apiCfg.DB.DeleteTeam(r.Context(), team.ID); err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting team: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// AddTeamMember 按用户名邀请成员，默认角色为 viewer，需要 owner 角色
+// POST /v1/teams/{teamID}/members
+// 被邀请人接受后才会成为成员，团队订阅才会出现在其个人视图中
+func (apiCfg *ApiConfig) AddTeamMember(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleOwner)
+	if !ok {
+		return
+	}
+	type parameters struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	if params.Role == "" {
+		params.Role = teamRoleViewer
+	}
+	if _, ok := teamRoleLevels[params.Role]; !ok {
+		respondWithError(w, 400, fmt.Sprintf("Invalid role: %q", params.Role))
+		return
+	}
+	member, err := apiCfg.DB.GetUserByUsername(r.Context(), strings.TrimSpace(params.Username))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && member.DisabledAt.Valid) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting user: %v", err))
+		return
+	}
+	_, err = apiCfg.DB.GetTeamMember(r.Context(), db.GetTeamMemberParams{
+		TeamID: team.ID,
+		UserID: member.ID,
+	})
+	if err == nil {
+		respondWithError(w, 409, "User is already a member of this team")
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 400, fmt.Sprintf("Error getting team member: %v", err))
+		return
+	}
+
+	invitation, err := apiCfg.DB.CreateTeamInvitation(r.Context(), db.CreateTeamInvitationParams{
+		TeamID:    team.ID,
+		UserID:    member.ID,
+		Role:      params.Role,
+		InvitedBy: uuid.NullUUID{UUID: user.ID, Valid: true},
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error inviting team member: %v", err))
+		return
+	}
+	respondWithJSON(w, 202, api.NewTeamInvitation(invitation))
+}
+
+// GetTeamInvitations 列出团队发出、尚未接受的邀请，需要 owner 角色
+// GET /v1/teams/{teamID}/invitations
+func (apiCfg *ApiConfig) GetTeamInvitations(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleOwner)
+	if !ok {
+		return
+	}
+	invitations, err := apiCfg.DB.GetTeamInvitations(r.Context(), team.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting team invitations: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(invitations, api.NewPendingTeamInvitation))
+}
+
+// CancelTeamInvitation 撤回团队邀请，需要 owner 角色
+// DELETE /v1/teams/{teamID}/invitations/{userID}
+func (apiCfg *ApiConfig) CancelTeamInvitation(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleOwner)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing user_id: %v", err))
+		return
+	}
+	apiCfg.deleteTeamInvitation(w, r, team.ID, memberID)
+}
+
+// GetIncomingTeamInvitations 列出发给当前用户、等待接受的团队邀请
+// GET /v1/team_invitations
+func (apiCfg *ApiConfig) GetIncomingTeamInvitations(w http.ResponseWriter, r *http.Request, user db.User) {
+	invitations, err := apiCfg.DB.GetIncomingTeamInvitations(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting team invitations: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(invitations, api.NewIncomingTeamInvitation))
+}
+
+// AcceptTeamInvitation 接受团队邀请，以邀请中的角色加入团队
+// POST /v1/team_invitations/{teamID}/accept
+func (apiCfg *ApiConfig) AcceptTeamInvitation(w http.ResponseWriter, r *http.Request, user db.User) {
+	teamID, err := uuid.Parse(chi.URLParam(r, "teamID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing team_id: %v", err))
+		return
+	}
+	var membership db.TeamMember
+	err = apiCfg.withTx(r.Context(), func(q *db.Queries) error {
+		invitation, err := q.TakeTeamInvitation(r.Context(), db.TakeTeamInvitationParams{
+			TeamID: teamID,
+			UserID: user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		membership, err = q.AddTeamMember(r.Context(), db.AddTeamMemberParams{
+			TeamID:    invitation.TeamID,
+			UserID:    user.ID,
+			Role:      invitation.Role,
+			CreatedAt: time.Now().UTC(),
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Team invitation not found")
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "You are already a member of this team")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error accepting team invitation: %v", err))
+		return
+	}
+	respondWithJSON(w, 201, api.NewTeamMembership(membership))
+}
+
+// DeclineTeamInvitation 拒绝团队邀请
+// DELETE /v1/team_invitations/{teamID}
+func (apiCfg *ApiConfig) DeclineTeamInvitation(w http.ResponseWriter, r *http.Request, user db.User) {
+	teamID, err := uuid.Parse(chi.URLParam(r, "teamID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing team_id: %v", err))
+		return
+	}
+	apiCfg.deleteTeamInvitation(w, r, teamID, user.ID)
+}
+
+func (apiCfg *ApiConfig) deleteTeamInvitation(w http.ResponseWriter, r *http.Request, teamID, userID uuid.UUID) {
+	n, err := apiCfg.DB.DeleteTeamInvitation(r.Context(), db.DeleteTeamInvitationParams{
+		TeamID: teamID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting team invitation: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Team invitation not found")
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// UpdateTeamMember 修改成员角色，需要 owner 角色，不能降级最后一个 owner
+// PUT /v1/teams/{teamID}/members/{userID}
+func (apiCfg *ApiConfig) UpdateTeamMember(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleOwner)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing user_id: %v", err))
+		return
+	}
+	type parameters struct {
+		Role string `json:"role"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	if _, ok := teamRoleLevels[params.Role]; !ok {
+		respondWithError(w, 400, fmt.Sprintf("Invalid role: %q", params.Role))
+		return
+	}
+
+	// 先锁住现有 owner，并发降级时后提交的请求能看到前一个的结果
+	var membership db.TeamMember
+	err = apiCfg.withTx(r.Context(), func(q *db.Queries) error {
+		if err := q.LockTeamOwners(r.Context(), team.ID); err != nil {
+			return err
+		}
+		var err error
+		membership, err = q.UpdateTeamMemberRole(r.Context(), db.UpdateTeamMemberRoleParams{
+			Role:   params.Role,
+			TeamID: team.ID,
+			UserID: memberID,
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		apiCfg.respondTeamMemberNotChanged(w, r.Context(), team.ID, memberID)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error updating team member: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewTeamMembership(membership))
+}
+
+// RemoveTeamMember 移除成员，需要 owner 角色；成员也可以移除自己以退出团队
+// DELETE /v1/teams/{teamID}/members/{userID}
+func (apiCfg *ApiConfig) RemoveTeamMember(w http.ResponseWriter, r *http.Request, user db.User) {
+	memberID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing user_id: %v", err))
+		return
+	}
+	required := teamRoleOwner
+	if memberID == user.ID {
+		required = teamRoleViewer
+	}
+	team, ok := apiCfg.teamForMember(w, r, user, required)
+	if !ok {
+		return
+	}
+
+	var n int64
+	err = apiCfg.withTx(r.Context(), func(q *db.Queries) error {
+		if err := q.LockTeamOwners(r.Context(), team.ID); err != nil {
+			return err
+		}
+		var err error
+		n, err = q.RemoveTeamMember(r.Context(), db.RemoveTeamMemberParams{
+			TeamID: team.ID,
+			UserID: memberID,
+		})
+		return err
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error removing team member: %v", err))
+		return
+	}
+	if n == 0 {
+		apiCfg.respondTeamMemberNotChanged(w, r.Context(), team.ID, memberID)
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// respondTeamMemberNotChanged 区分成员不存在和成员是最后一个 owner
+func (apiCfg *ApiConfig) respondTeamMemberNotChanged(w http.ResponseWriter, ctx context.Context, teamID, userID uuid.UUID) {
+	_, err := apiCfg.DB.GetTeamMember(ctx, db.GetTeamMemberParams{
+		TeamID: teamID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Team member not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting team member: %v", err))
+		return
+	}
+	respondWithError(w, 409, errLastTeamOwner)
+}
+
+// CreateTeamFollow 为团队订阅订阅源，所有成员都能看到，需要 editor 角色，占用创建者的关注配额
+// POST /v1/teams/{teamID}/follows
+func (apiCfg *ApiConfig) CreateTeamFollow(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleEditor)
+	if !ok {
+		return
+	}
+	type parameters struct {
+		FeedID uuid.UUID `json:"feed_id"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	if _, err := apiCfg.DB.GetFeedByID(r.Context(), params.FeedID); errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Feed not found")
+		return
+	}
+
+	// 团队订阅计入创建者的关注配额，创建者已经关注的订阅源不重复计算
+	var follow db.TeamFollow
+	err = apiCfg.withQuota(r.Context(), user.ID, func(q *db.Queries) error {
+		if err := checkFollowQuota(r.Context(), q, user.ID, params.FeedID); err != nil {
+			return err
+		}
+		var err error
+		follow, err = q.CreateTeamFollow(r.Context(), db.CreateTeamFollowParams{
+			ID:        uuid.New(),
+			TeamID:    team.ID,
+			FeedID:    params.FeedID,
+			CreatedBy: uuid.NullUUID{UUID: user.ID, Valid: true},
+			CreatedAt: time.Now().UTC(),
+		})
+		return err
+	})
+	if isQuotaExceeded(err) {
+		respondWithError(w, 403, err.Error())
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "The team already follows this feed")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error creating team follow: %v", err))
+		return
+	}
+	apiCfg.audit(r, user, auditEvent{
+		Action:     auditTeamFollowCreate,
+		TargetType: auditTargetTeam,
+		TargetID:   team.ID,
+		Details:    map[string]any{"feed_id": follow.FeedID},
+	})
+	respondWithJSON(w, 201, api.NewTeamFollow(follow))
+}
+
+// GetTeamFollows 获取团队订阅的订阅源，未读数按请求者自己的阅读状态计算
+// GET /v1/teams/{teamID}/follows
+func (apiCfg *ApiConfig) GetTeamFollows(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleViewer)
+	if !ok {
+		return
+	}
+	follows, err := apiCfg.DB.GetTeamFollows(r.Context(), db.GetTeamFollowsParams{
+		UserID: user.ID,
+		TeamID: team.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting team follows: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(follows, api.NewTeamFollowedFeed))
+}
+
+// DeleteTeamFollow 取消团队订阅，同时移出团队文件夹，需要 editor 角色
+// DELETE /v1/teams/{teamID}/follows/{feedID}
+func (apiCfg *ApiConfig) DeleteTeamFollow(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleEditor)
+	if !ok {
+		return
+	}
+	feedID, err := uuid.Parse(chi.URLParam(r, "feedID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing feed_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.DeleteTeamFollow(r.Context(), db.DeleteTeamFollowParams{
+		TeamID: team.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting team follow: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Team follow not found")
+		return
+	}
+	apiCfg.audit(r, user, auditEvent{
+		Action:     auditTeamFollowDelete,
+		TargetType: auditTargetTeam,
+		TargetID:   team.ID,
+		Details:    map[string]any{"feed_id": feedID},
+	})
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// CreateTeamFolder 创建团队文件夹，新文件夹排在末尾，需要 editor 角色
+// POST /v1/teams/{teamID}/folders
+func (apiCfg *ApiConfig) CreateTeamFolder(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleEditor)
+	if !ok {
+		return
+	}
+	type parameters struct {
+		Name string `json:"name"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		respondWithError(w, 400, "Folder name is required")
+		return
+	}
+
+	folder, err := apiCfg.DB.CreateTeamFolder(r.Context(), db.CreateTeamFolderParams{
+		ID:        uuid.New(),
+		TeamID:    team.ID,
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "The team already has a folder with this name")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error creating folder: %v", err))
+		return
+	}
+	respondWithJSON(w, 201, api.NewTeamFolder(folder))
+}
+
+// GetTeamFolders 获取团队文件夹及其中的订阅源，未读数按请求者自己的阅读状态计算
+// GET /v1/teams/{teamID}/folders
+func (apiCfg *ApiConfig) GetTeamFolders(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleViewer)
+	if !ok {
+		return
+	}
+	folders, err := apiCfg.DB.GetTeamFolders(r.Context(), db.GetTeamFoldersParams{
+		UserID: user.ID,
+		TeamID: team.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting folders: %v", err))
+		return
+	}
+	feeds, err := apiCfg.DB.GetTeamFolderFeeds(r.Context(), db.GetTeamFolderFeedsParams{
+		UserID: user.ID,
+		TeamID: team.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting folder feeds: %v", err))
+		return
+	}
+
+	feedsByFolder := make(map[uuid.UUID][]db.GetTeamFolderFeedsRow)
+	for _, feed := range feeds {
+		feedsByFolder[feed.FolderID] = append(feedsByFolder[feed.FolderID], feed)
+	}
+	resp := make([]api.FolderWithFeeds, 0, len(folders))
+	for _, folder := range folders {
+		resp = append(resp, api.NewTeamFolderWithFeeds(folder, feedsByFolder[folder.ID]))
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+// UpdateTeamFolder 重命名团队文件夹，需要 editor 角色
+// PUT /v1/teams/{teamID}/folders/{folderID}
+func (apiCfg *ApiConfig) UpdateTeamFolder(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleEditor)
+	if !ok {
+		return
+	}
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+		return
+	}
+	type parameters struct {
+		Name string `json:"name"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		respondWithError(w, 400, "Folder name is required")
+		return
+	}
+
+	folder, err := apiCfg.DB.UpdateTeamFolder(r.Context(), db.UpdateTeamFolderParams{
+		ID:     folderID,
+		TeamID: team.ID,
+		Name:   name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Folder not found")
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "The team already has a folder with this name")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error updating folder: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewTeamFolder(folder))
+}
+
+// DeleteTeamFolder 删除团队文件夹，其中的团队订阅不受影响，需要 editor 角色
+// DELETE /v1/teams/{teamID}/folders/{folderID}
+func (apiCfg *ApiConfig) DeleteTeamFolder(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleEditor)
+	if !ok {
+		return
+	}
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.DeleteTeamFolder(r.Context(), db.DeleteTeamFolderParams{
+		ID:     folderID,
+		TeamID: team.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting folder: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Folder not found")
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// AddFeedToTeamFolder 把团队订阅的订阅源加入团队文件夹，需要 editor 角色
+// POST /v1/teams/{teamID}/folders/{folderID}/feeds
+func (apiCfg *ApiConfig) AddFeedToTeamFolder(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleEditor)
+	if !ok {
+		return
+	}
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+		return
+	}
+	type parameters struct {
+		FeedID uuid.UUID `json:"feed_id"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+
+	folderFeed, err := apiCfg.DB.AddFeedToTeamFolder(r.Context(), db.AddFeedToTeamFolderParams{
+		FolderID: folderID,
+		TeamID:   team.ID,
+		FeedID:   params.FeedID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Folder not found or feed not followed by the team")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error adding feed to folder: %v", err))
+		return
+	}
+	respondWithJSON(w, 201, api.NewTeamFolderFeed(folderFeed))
+}
+
+// RemoveFeedFromTeamFolder 把订阅源移出团队文件夹，不会取消团队订阅，需要 editor 角色
+// DELETE /v1/teams/{teamID}/folders/{folderID}/feeds/{feedID}
+func (apiCfg *ApiConfig) RemoveFeedFromTeamFolder(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleEditor)
+	if !ok {
+		return
+	}
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+		return
+	}
+	feedID, err := uuid.Parse(chi.URLParam(r, "feedID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing feed_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.RemoveFeedFromTeamFolder(r.Context(), db.RemoveFeedFromTeamFolderParams{
+		FolderID: folderID,
+		TeamID:   team.ID,
+		FeedID:   feedID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error removing feed from folder: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Feed not found in folder")
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// GetTeamPosts 获取团队时间线，参数与个人时间线相同
+// GET /v1/teams/{teamID}/posts?folder_id=&unread=true&starred=true&tag=&limit=&offset=
+// 阅读、星标、标签和隐藏状态都是请求者自己的，仍通过 /v1/posts/{postID}/... 修改
+func (apiCfg *ApiConfig) GetTeamPosts(w http.ResponseWriter, r *http.Request, user db.User) {
+	team, ok := apiCfg.teamForMember(w, r, user, teamRoleViewer)
+	if !ok {
+		return
+	}
+	folderID := uuid.NullUUID{}
+	if s := r.URL.Query().Get("folder_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+			return
+		}
+		folderID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	limit, offset, err := parsePagination(r, 50, 200)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	posts, err := apiCfg.DB.GetTeamPosts(r.Context(), db.GetTeamPostsParams{
+		UserID:      user.ID,
+		TeamID:      team.ID,
+		FolderID:    folderID,
+		UnreadOnly:  r.URL.Query().Get("unread") == "true",
+		StarredOnly: r.URL.Query().Get("starred") == "true",
+		Tag:         nullString(r.URL.Query().Get("tag")),
+		PageLimit:   limit,
+		PageOffset:  offset,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting posts: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(posts, api.NewTeamTimelinePost))
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// withURLParams 模拟 chi 路由解析出的路径参数
+func withURLParams(r *http.Request, params map[string]string) *http.Request {
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// fakeTeamMember 模拟请求者以 role 角色所在的团队
+func fakeTeamMember(teamID uuid.UUID, role string) fakeQuery {
+	return func(args []driver.Value) ([][]driver.Value, error) {
+		if args[0] != teamID.String() {
+			return nil, nil
+		}
+		now := time.Now()
+		return [][]driver.Value{{teamID.String(), "Newsroom", now, now, role}}, nil
+	}
+}
+
+func TestCreateTeamFollowQuota(t *testing.T) {
+	user := db.User{ID: uuid.New(), Username: "alice", Role: "user"}
+	teamID, feedID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name       string
+		role       string
+		maxFollows any
+		follows    int64
+		following  bool
+		wantCode   int
+	}{
+		{"within quota", teamRoleEditor, int64(2), 1, false, 201},
+		{"unlimited", teamRoleOwner, nil, 500, false, 201},
+		{"quota reached", teamRoleEditor, int64(1), 1, false, 403},
+		{"feed already counted for the creator", teamRoleEditor, int64(1), 1, true, 201},
+		{"viewer", teamRoleViewer, int64(2), 0, false, 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, apiCfg := newFakeDB(t, map[string]fakeQuery{
+				"GetTeamForMember": fakeTeamMember(teamID, tt.role),
+				"GetFeedByID": func(args []driver.Value) ([][]driver.Value, error) {
+					now := time.Now()
+					return [][]driver.Value{{feedID.String(), "Example", "https://example.com/feed.xml", now, now, uuid.NewString(),
+						nil, nil, nil, nil, nil, nil, nil, int64(0), int64(1), nil}}, nil
+				},
+				"LockUserQuota": fakeNoRows,
+				"IsFollowingFeed": func(args []driver.Value) ([][]driver.Value, error) {
+					return [][]driver.Value{{tt.following}}, nil
+				},
+				"GetUserQuota": func(args []driver.Value) ([][]driver.Value, error) {
+					return [][]driver.Value{{nil, tt.maxFollows, nil, int64(0), tt.follows, int64(0)}}, nil
+				},
+				"CreateTeamFollow": func(args []driver.Value) ([][]driver.Value, error) {
+					return [][]driver.Value{{args[0], args[1], args[2], args[3], args[4]}}, nil
+				},
+				"CreateAuditEvent": fakeNoRows,
+			})
+			r := httptest.NewRequest("POST", "/v1/teams/"+teamID.String()+"/follows", strings.NewReader(`{"feed_id":"`+feedID.String()+`"}`))
+			r = withURLParams(r, map[string]string{"teamID": teamID.String()})
+			w := httptest.NewRecorder()
+			apiCfg.CreateTeamFollow(w, r, user)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			created := slices.Contains(fake.called(), "CreateTeamFollow")
+			if created != (tt.wantCode == 201) {
+				t.Errorf("CreateTeamFollow called = %v, queries %v", created, fake.called())
+			}
+			if created {
+				locks := fake.callsTo("LockUserQuota")
+				if len(locks) != 1 || locks[0][0] != user.ID.String() {
+					t.Errorf("LockUserQuota calls = %v, want the creator's quota locked", locks)
+				}
+			}
+		})
+	}
+}
+
+func TestAddTeamMemberInvites(t *testing.T) {
+	owner := db.User{ID: uuid.New(), Username: "alice", Role: "user"}
+	teamID := uuid.New()
+	invitee := db.User{ID: uuid.New(), Username: "bob", Role: "user"}
+	disabled := db.User{ID: uuid.New(), Username: "carol", Role: "user", DisabledAt: sql.NullTime{Time: time.Now(), Valid: true}}
+
+	tests := []struct {
+		name     string
+		body     string
+		member   bool
+		wantCode int
+	}{
+		{"invites with the default role", `{"username":"bob"}`, false, 202},
+		{"invites with a role", `{"username":"bob","role":"editor"}`, false, 202},
+		{"already a member", `{"username":"bob"}`, true, 409},
+		{"disabled user", `{"username":"carol"}`, false, 404},
+		{"unknown user", `{"username":"dave"}`, false, 404},
+		{"invalid role", `{"username":"bob","role":"admin"}`, false, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, apiCfg := newFakeDB(t, map[string]fakeQuery{
+				"GetTeamForMember": fakeTeamMember(teamID, teamRoleOwner),
+				"GetUserByUsername": func(args []driver.Value) ([][]driver.Value, error) {
+					for _, u := range []db.User{invitee, disabled} {
+						if args[0] == u.Username {
+							return [][]driver.Value{fakeUserRow(u)}, nil
+						}
+					}
+					return nil, nil
+				},
+				"GetTeamMember": func(args []driver.Value) ([][]driver.Value, error) {
+					if !tt.member {
+						return nil, nil
+					}
+					return [][]driver.Value{{args[0], args[1], teamRoleViewer, time.Now()}}, nil
+				},
+				"CreateTeamInvitation": func(args []driver.Value) ([][]driver.Value, error) {
+					return [][]driver.Value{{args[0], args[1], args[2], args[3], args[4]}}, nil
+				},
+			})
+			r := httptest.NewRequest("POST", "/v1/teams/"+teamID.String()+"/members", strings.NewReader(tt.body))
+			r = withURLParams(r, map[string]string{"teamID": teamID.String()})
+			w := httptest.NewRecorder()
+			apiCfg.AddTeamMember(w, r, owner)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if slices.Contains(fake.called(), "AddTeamMember") {
+				t.Errorf("AddTeamMember called before the invitee accepted")
+			}
+			invitations := fake.callsTo("CreateTeamInvitation")
+			if (len(invitations) == 1) != (tt.wantCode == 202) {
+				t.Fatalf("CreateTeamInvitation calls = %v", invitations)
+			}
+			if len(invitations) == 1 && (invitations[0][1] != invitee.ID.String() || invitations[0][3] != owner.ID.String()) {
+				t.Errorf("CreateTeamInvitation args = %v, want bob invited by alice", invitations[0])
+			}
+		})
+	}
+}
+
+func TestAcceptTeamInvitation(t *testing.T) {
+	user := db.User{ID: uuid.New(), Username: "bob", Role: "user"}
+	teamID := uuid.New()
+
+	tests := []struct {
+		name     string
+		invited  bool
+		teamID   string
+		wantCode int
+	}{
+		{"joins with the invited role", true, teamID.String(), 201},
+		{"no invitation", false, teamID.String(), 404},
+		{"invalid team id", true, "not-a-uuid", 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, apiCfg := newFakeDB(t, map[string]fakeQuery{
+				"TakeTeamInvitation": func(args []driver.Value) ([][]driver.Value, error) {
+					if !tt.invited || args[0] != teamID.String() || args[1] != user.ID.String() {
+						return nil, nil
+					}
+					return [][]driver.Value{{args[0], args[1], teamRoleEditor, uuid.NewString(), time.Now()}}, nil
+				},
+				"AddTeamMember": func(args []driver.Value) ([][]driver.Value, error) {
+					return [][]driver.Value{{args[0], args[1], args[2], args[3]}}, nil
+				},
+			})
+			r := httptest.NewRequest("POST", "/v1/team_invitations/"+tt.teamID+"/accept", nil)
+			r = withURLParams(r, map[string]string{"teamID": tt.teamID})
+			w := httptest.NewRecorder()
+			apiCfg.AcceptTeamInvitation(w, r, user)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			added := fake.callsTo("AddTeamMember")
+			if tt.wantCode != 201 {
+				if len(added) != 0 {
+					t.Errorf("AddTeamMember calls = %v, want none", added)
+				}
+				return
+			}
+			if len(added) != 1 || added[0][1] != user.ID.String() || added[0][2] != teamRoleEditor {
+				t.Errorf("AddTeamMember calls = %v, want bob added as editor", added)
+			}
+		})
+	}
+}
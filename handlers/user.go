@@ -2,19 +2,47 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
+	"github.com/djchanahcjd/go-rss/api"
 	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/ratelimit"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// 用户角色
+const (
+	roleUser  = "user"
+	roleAdmin = "admin"
+)
+
+const errPasswordLoginDisabled = "Password login is disabled, sign in with single sign-on"
+
+// errUserDisabled 被管理员停用的用户不能登录或使用任何凭据
+var errUserDisabled = errors.New("account is disabled")
+
+var errUserNotFound = errors.New("user not found")
+
+// CreateUser 注册新用户，REGISTRATION_MODE 为 invite 时需要有效的邀请码，为 closed 时不允许注册
+// POST /v1/users {"username": "...", "password": "...", "invite_code": "..."}
 func (apiCfg *ApiConfig) CreateUser(w http.ResponseWriter, r *http.Request) {
+	if apiCfg.PasswordLoginDisabled {
+		respondWithError(w, 403, errPasswordLoginDisabled)
+		return
+	}
+	if apiCfg.RegistrationMode == registrationClosed {
+		respondWithError(w, 403, "Registration is closed")
+		return
+	}
 	type parameters struct {
-		UserName string `json:"username"`
-		Password string `json:"password"`
+		UserName   string `json:"username"`
+		Password   string `json:"password"`
+		InviteCode string `json:"invite_code"`
 	}
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
@@ -29,6 +57,15 @@ func (apiCfg *ApiConfig) CreateUser(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, 400, fmt.Sprintf("Error hashing password: %v", err))
 		return
 	}
+	inviteID, err := apiCfg.redeemInvite(r.Context(), params.InviteCode)
+	if errors.Is(err, errInvalidInvite) {
+		respondWithError(w, 403, "Invite code is invalid, expired or used up")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Error redeeming invite: %v", err))
+		return
+	}
 	user, err := apiCfg.DB.CreateUser(r.Context(), db.CreateUserParams{
 		ID:        uuid.New(),
 		Username:  params.UserName,
@@ -37,14 +74,31 @@ func (apiCfg *ApiConfig) CreateUser(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt: time.Now().UTC(),
 	})
 	if err != nil {
+		// 注册失败时归还邀请码的使用次数
+		if inviteID.Valid {
+			if err := apiCfg.DB.ReleaseInvite(r.Context(), inviteID.UUID); err != nil {
+				log.Printf("[AUTH] Error releasing invite %s: %v", inviteID.UUID, err)
+			}
+		}
 		respondWithError(w, 400, fmt.Sprintf("Error creating user: %v", err))
 		return
 	}
+	if inviteID.Valid {
+		err = apiCfg.DB.SetUserInvite(r.Context(), db.SetUserInviteParams{InviteID: inviteID, ID: user.ID})
+		if err != nil {
+			log.Printf("[AUTH] Error recording invite of %s: %v", user.Username, err)
+		}
+		user.InviteID = inviteID
+	}
 
-	respondWithJSON(w, 201, user)
+	apiCfg.startSession(w, r, 201, user)
 }
 
 func (apiCfg *ApiConfig) LoginUser(w http.ResponseWriter, r *http.Request) {
+	if apiCfg.PasswordLoginDisabled {
+		respondWithError(w, 403, errPasswordLoginDisabled)
+		return
+	}
 	type parameters struct {
 		UserName string `json:"username"`
 		Password string `json:"password"`
@@ -56,33 +110,75 @@ func (apiCfg *ApiConfig) LoginUser(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
 		return
 	}
+	// 连续失败被锁定期间不再校验密码
+	lockKey := ratelimit.LoginKey(params.UserName, apiCfg.clientIP(r))
+	if apiCfg.loginLocked(w, r, lockKey) {
+		apiCfg.auditLoginResult(r, "password", params.UserName, db.User{}, "locked")
+		respondWithError(w, 429, "Too many failed login attempts, try again later")
+		return
+	}
 	user, err := apiCfg.DB.GetUserByUsername(r.Context(), params.UserName)
 	if err != nil {
+		apiCfg.loginFailed(r, lockKey)
+		apiCfg.auditLoginResult(r, "password", params.UserName, db.User{}, "unknown_user")
 		respondWithError(w, 400, fmt.Sprintf("Error getting user: %v", err))
 		return
 	}
 	// 密码校验
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(params.Password))
 	if err != nil {
+		apiCfg.loginFailed(r, lockKey)
+		apiCfg.auditLoginResult(r, "password", params.UserName, user, "invalid_password")
 		respondWithError(w, 400, "Incorrect password")
 		return
 	}
+	apiCfg.loginSucceeded(r, lockKey)
+	// 密码正确后才提示账号已停用，避免泄露账号状态
+	if user.DisabledAt.Valid {
+		apiCfg.auditLoginResult(r, "password", params.UserName, user, "disabled")
+		respondWithError(w, 403, "Account is disabled")
+		return
+	}
+	apiCfg.auditLoginResult(r, "password", params.UserName, user, "")
 	// 登录成功，返回用户信息
-	respondWithJSON(w, 200, user)
+	apiCfg.startSession(w, r, 200, user)
 }
 
 func (apiCfg *ApiConfig) GetUser(w http.ResponseWriter, r *http.Request, user db.User) {
-	respondWithJSON(w, 200, user)
+	respondWithJSON(w, 200, api.NewUser(user))
 }
 
+// GetPostsForUser 获取用户的文章时间线
+// GET /v1/posts?folder_id=&unread=true&starred=true&tag=&limit=&offset=
+// 被隐藏的文章不会返回
 func (apiCfg *ApiConfig) GetPostsForUser(w http.ResponseWriter, r *http.Request, user db.User) {
+	folderID := uuid.NullUUID{}
+	if s := r.URL.Query().Get("folder_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Error parsing folder_id: %v", err))
+			return
+		}
+		folderID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	limit, offset, err := parsePagination(r, 50, 200)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
 	posts, err := apiCfg.DB.GetPostsForUser(r.Context(), db.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  50,
+		UserID:      user.ID,
+		FolderID:    folderID,
+		UnreadOnly:  r.URL.Query().Get("unread") == "true",
+		StarredOnly: r.URL.Query().Get("starred") == "true",
+		Tag:         nullString(r.URL.Query().Get("tag")),
+		PageLimit:   limit,
+		PageOffset:  offset,
 	})
 	if err != nil {
 		respondWithError(w, 400, fmt.Sprintf("Error getting posts: %v", err))
 		return
 	}
-	respondWithJSON(w, 200, posts)
+	respondWithJSON(w, 200, api.List(posts, api.NewTimelinePost))
 }
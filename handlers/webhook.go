@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/api"
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/webhooks"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// CreateWebhook 注册 webhook，secret 为空时自动生成
+// feed_id、folder_id、rule_id 为可选过滤条件
+func (apiCfg *ApiConfig) CreateWebhook(w http.ResponseWriter, r *http.Request, user db.User) {
+	type parameters struct {
+		Url      string        `json:"url"`
+		Secret   string        `json:"secret"`
+		FeedID   uuid.NullUUID `json:"feed_id"`
+		FolderID uuid.NullUUID `json:"folder_id"`
+		RuleID   uuid.NullUUID `json:"rule_id"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing JSON: %v", err))
+		return
+	}
+
+	// 拒绝本机和内网地址，投递时还会再次检查实际连接的地址
+	target, err := webhooks.ValidateURL(r.Context(), strings.TrimSpace(params.Url))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Invalid webhook url: %v", err))
+		return
+	}
+	secret := params.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Error generating secret: %v", err))
+			return
+		}
+		secret = hex.EncodeToString(buf)
+	}
+	if len(secret) < 16 || len(secret) > 128 {
+		respondWithError(w, 400, "Webhook secret must be 16-128 characters")
+		return
+	}
+
+	if params.FeedID.Valid {
+		_, err := apiCfg.DB.GetFeedByID(r.Context(), params.FeedID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 404, "Feed not found")
+			return
+		}
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Error getting feed: %v", err))
+			return
+		}
+	}
+	if params.FolderID.Valid {
+		_, err := apiCfg.DB.GetFolderByID(r.Context(), db.GetFolderByIDParams{
+			ID:     params.FolderID.UUID,
+			UserID: user.ID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 404, "Folder not found")
+			return
+		}
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Error getting folder: %v", err))
+			return
+		}
+	}
+	if params.RuleID.Valid {
+		_, err := apiCfg.DB.GetRuleByID(r.Context(), db.GetRuleByIDParams{
+			ID:     params.RuleID.UUID,
+			UserID: user.ID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 404, "Rule not found")
+			return
+		}
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("Error getting rule: %v", err))
+			return
+		}
+	}
+	var hook db.Webhook
+	err = apiCfg.withQuota(r.Context(), user.ID, func(q *db.Queries) error {
+		if err := checkQuota(r.Context(), q, user.ID, quotaWebhooks); err != nil {
+			return err
+		}
+		var err error
+		hook, err = q.CreateWebhook(r.Context(), db.CreateWebhookParams{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			Url:       target.String(),
+			Secret:    secret,
+			FeedID:    params.FeedID,
+			FolderID:  params.FolderID,
+			RuleID:    params.RuleID,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		})
+		return err
+	})
+	if isQuotaExceeded(err) {
+		respondWithError(w, 403, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error creating webhook: %v", err))
+		return
+	}
+	resp := api.NewWebhook(hook)
+	resp.Secret = hook.Secret
+	respondWithJSON(w, 201, resp)
+}
+
+// GetWebhooks 获取用户的 webhook
+func (apiCfg *ApiConfig) GetWebhooks(w http.ResponseWriter, r *http.Request, user db.User) {
+	hooks, err := apiCfg.DB.GetWebhooksByUserID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting webhooks: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(hooks, api.NewWebhook))
+}
+
+// DeleteWebhook 删除 webhook 及其投递记录
+func (apiCfg *ApiConfig) DeleteWebhook(w http.ResponseWriter, r *http.Request, user db.User) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing webhook_id: %v", err))
+		return
+	}
+	n, err := apiCfg.DB.DeleteWebhook(r.Context(), db.DeleteWebhookParams{
+		ID:     webhookID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error deleting webhook: %v", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Webhook not found")
+		return
+	}
+	respondWithJSON(w, 200, struct{}{})
+}
+
+// GetWebhookDeliveries 投递记录，按创建时间倒序
+// GET /v1/webhooks/{webhookID}/deliveries?status=pending|succeeded|dead&limit=&offset=
+func (apiCfg *ApiConfig) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request, user db.User) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing webhook_id: %v", err))
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", webhooks.StatusPending, webhooks.StatusSucceeded, webhooks.StatusDead:
+	default:
+		respondWithError(w, 400, fmt.Sprintf("Invalid status: %q", status))
+		return
+	}
+	limit, offset, err := parsePagination(r, 50, 200)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	_, err = apiCfg.DB.GetWebhookByID(r.Context(), db.GetWebhookByIDParams{
+		ID:     webhookID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Webhook not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting webhook: %v", err))
+		return
+	}
+	deliveries, err := apiCfg.DB.GetWebhookDeliveries(r.Context(), db.GetWebhookDeliveriesParams{
+		WebhookID:  webhookID,
+		Status:     nullString(status),
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error getting deliveries: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.List(deliveries, api.NewWebhookDelivery))
+}
+
+// RetryWebhookDelivery 立即重新投递，可用于恢复 dead 状态的投递
+func (apiCfg *ApiConfig) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request, user db.User) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing webhook_id: %v", err))
+		return
+	}
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error parsing delivery_id: %v", err))
+		return
+	}
+	delivery, err := apiCfg.DB.RetryWebhookDelivery(r.Context(), db.RetryWebhookDeliveryParams{
+		ID:        deliveryID,
+		WebhookID: webhookID,
+		UserID:    user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Delivery not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Error retrying delivery: %v", err))
+		return
+	}
+	respondWithJSON(w, 200, api.NewWebhookDelivery(delivery))
+}
@@ -149,3 +149,87 @@ func (q *Queries) GetFeedFollowsByUserID(ctx context.Context, userID uuid.UUID)
 	}
 	return items, nil
 }
+
+const getNextFeedOwners = `-- name: GetNextFeedOwners :many
+SELECT user_id FROM (
+  SELECT ff.user_id, 0 AS priority, ff.created_at AS followed_at, ff.created_at AS joined_at FROM feed_follows ff
+  WHERE ff.feed_id = $1 AND ff.user_id <> $2
+  UNION ALL
+  SELECT tm.user_id, 1 AS priority, tf.created_at AS followed_at, tm.created_at AS joined_at FROM team_follows tf
+  JOIN team_members tm ON tm.team_id = tf.team_id AND tm.role = 'owner'
+  WHERE tf.feed_id = $1 AND tm.user_id <> $2
+) candidates
+ORDER BY priority ASC, followed_at ASC, joined_at ASC
+`
+
+type GetNextFeedOwnersParams struct {
+	FeedID uuid.UUID
+	UserID uuid.UUID
+}
+
+// 所有者删除仍有人关注的订阅源时的接手候选：先是按关注时间排列的其他用户，再是关注该订阅源的团队的 owner
+func (q *Queries) GetNextFeedOwners(ctx context.Context, arg GetNextFeedOwnersParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedOwners, arg.FeedID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		items = append(items, userID)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertFeedFollow = `-- name: UpsertFeedFollow :one
+INSERT INTO feed_follows (
+  id,
+  created_at,
+  updated_at,
+  user_id,
+  feed_id
+)
+VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, feed_id) DO UPDATE SET updated_at = feed_follows.updated_at
+RETURNING id, created_at, updated_at, user_id, feed_id
+`
+
+type UpsertFeedFollowParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+}
+
+// 已关注时返回原有记录
+func (q *Queries) UpsertFeedFollow(ctx context.Context, arg UpsertFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, upsertFeedFollow,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+	)
+	return i, err
+}
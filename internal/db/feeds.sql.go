@@ -20,12 +20,13 @@ INSERT INTO feeds (
   url,
   created_at,
   updated_at,
-  user_id
+  user_id,
+  category
 )
 VALUES (
-  $1, $2, $3, $4, $5, $6
+  $1, $2, $3, $4, $5, $6, $7
 )
-RETURNING id, name, url, created_at, updated_at, user_id, last_fetched_at
+RETURNING id, name, url, created_at, updated_at, user_id, last_fetched_at, description, language, link, category, last_fetch_succeeded_at, last_fetch_error, fetch_error_count, short_id, refetch_requested_at
 `
 
 type CreateFeedParams struct {
@@ -35,6 +36,7 @@ type CreateFeedParams struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserID    uuid.UUID
+	Category  sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -45,6 +47,7 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.UserID,
+		arg.Category,
 	)
 	var i Feed
 	err := row.Scan(
@@ -55,37 +58,180 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Description,
+		&i.Language,
+		&i.Link,
+		&i.Category,
+		&i.LastFetchSucceededAt,
+		&i.LastFetchError,
+		&i.FetchErrorCount,
+		&i.ShortID,
+		&i.RefetchRequestedAt,
 	)
 	return i, err
 }
 
-const getAllFeeds = `-- name: GetAllFeeds :many
-SELECT f.id, f.name, f.url, f.created_at, f.updated_at, f.user_id, f.last_fetched_at, COUNT(ff.feed_id) AS follows_count FROM feeds f
-LEFT JOIN feed_follows ff ON f.id = ff.feed_id
-GROUP BY f.id
-ORDER BY follows_count DESC, created_at DESC
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, name, url, created_at, updated_at, user_id, last_fetched_at, description, language, link, category, last_fetch_succeeded_at, last_fetch_error, fetch_error_count, short_id, refetch_requested_at FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Description,
+		&i.Language,
+		&i.Link,
+		&i.Category,
+		&i.LastFetchSucceededAt,
+		&i.LastFetchError,
+		&i.FetchErrorCount,
+		&i.ShortID,
+		&i.RefetchRequestedAt,
+	)
+	return i, err
+}
+
+const getFeedByURL = `-- name: GetFeedByURL :one
+SELECT id, name, url, created_at, updated_at, user_id, last_fetched_at, description, language, link, category, last_fetch_succeeded_at, last_fetch_error, fetch_error_count, short_id, refetch_requested_at FROM feeds
+WHERE url = $1
+ORDER BY created_at ASC
+LIMIT 1
+`
+
+// 同一个 URL 可能被多个用户添加，优先复用最早创建的订阅源
+func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURL, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Description,
+		&i.Language,
+		&i.Link,
+		&i.Category,
+		&i.LastFetchSucceededAt,
+		&i.LastFetchError,
+		&i.FetchErrorCount,
+		&i.ShortID,
+		&i.RefetchRequestedAt,
+	)
+	return i, err
+}
+
+const getFeedDetail = `-- name: GetFeedDetail :one
+SELECT f.id, f.name, f.url, f.created_at, f.updated_at, f.user_id, f.last_fetched_at, f.description, f.language, f.link, f.category, f.last_fetch_succeeded_at, f.last_fetch_error, f.fetch_error_count, f.short_id, f.refetch_requested_at,
+  (SELECT COUNT(*) FROM feed_follows ff WHERE ff.feed_id = f.id) AS follows_count,
+  (SELECT COUNT(*) FROM posts p WHERE p.feed_id = f.id) AS posts_count,
+  (SELECT COUNT(*) FROM posts p WHERE p.feed_id = f.id AND p.published_at > NOW() - INTERVAL '30 days') AS posts_last_30_days,
+  (SELECT COUNT(*) FROM posts p WHERE p.feed_id = f.id AND p.published_at > NOW() - INTERVAL '90 days') AS posts_last_90_days,
+  (SELECT MAX(p.published_at) FROM posts p WHERE p.feed_id = f.id)::timestamptz AS last_post_at,
+  EXISTS (
+    SELECT 1 FROM user_feeds uf
+    WHERE uf.feed_id = f.id AND uf.user_id = $1
+  ) AS is_following
+FROM feeds f
+WHERE f.id = $2
 `
 
-type GetAllFeedsRow struct {
-	ID            uuid.UUID
-	Name          string
-	Url           string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
-	FollowsCount  sql.NullInt64
+type GetFeedDetailParams struct {
+	UserID uuid.NullUUID
+	ID     uuid.UUID
 }
 
-func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllFeeds)
+type GetFeedDetailRow struct {
+	ID                   uuid.UUID
+	Name                 string
+	Url                  string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	Description          sql.NullString
+	Language             sql.NullString
+	Link                 sql.NullString
+	Category             sql.NullString
+	LastFetchSucceededAt sql.NullTime
+	LastFetchError       sql.NullString
+	FetchErrorCount      int32
+	ShortID              int64
+	RefetchRequestedAt   sql.NullTime
+	FollowsCount         int64
+	PostsCount           int64
+	PostsLast30Days      int64
+	PostsLast90Days      int64
+	LastPostAt           sql.NullTime
+	IsFollowing          bool
+}
+
+func (q *Queries) GetFeedDetail(ctx context.Context, arg GetFeedDetailParams) (GetFeedDetailRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedDetail, arg.UserID, arg.ID)
+	var i GetFeedDetailRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Description,
+		&i.Language,
+		&i.Link,
+		&i.Category,
+		&i.LastFetchSucceededAt,
+		&i.LastFetchError,
+		&i.FetchErrorCount,
+		&i.ShortID,
+		&i.RefetchRequestedAt,
+		&i.FollowsCount,
+		&i.PostsCount,
+		&i.PostsLast30Days,
+		&i.PostsLast90Days,
+		&i.LastPostAt,
+		&i.IsFollowing,
+	)
+	return i, err
+}
+
+const getFeedsByUserID = `-- name: GetFeedsByUserID :many
+SELECT id, name, url, created_at, updated_at, user_id, last_fetched_at, description, language, link, category, last_fetch_succeeded_at, last_fetch_error, fetch_error_count, short_id, refetch_requested_at FROM feeds
+WHERE user_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetFeedsByUserID(ctx context.Context, userID uuid.UUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByUserID, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetAllFeedsRow
+	var items []Feed
 	for rows.Next() {
-		var i GetAllFeedsRow
+		var i Feed
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
@@ -94,7 +240,15 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.LastFetchedAt,
-			&i.FollowsCount,
+			&i.Description,
+			&i.Language,
+			&i.Link,
+			&i.Category,
+			&i.LastFetchSucceededAt,
+			&i.LastFetchError,
+			&i.FetchErrorCount,
+			&i.ShortID,
+			&i.RefetchRequestedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -109,14 +263,15 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
 	return items, nil
 }
 
-const getFeedsByUserID = `-- name: GetFeedsByUserID :many
-SELECT id, name, url, created_at, updated_at, user_id, last_fetched_at FROM feeds
-WHERE user_id = $1
-ORDER BY created_at ASC
+const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
+SELECT id, name, url, created_at, updated_at, user_id, last_fetched_at, description, language, link, category, last_fetch_succeeded_at, last_fetch_error, fetch_error_count, short_id, refetch_requested_at FROM feeds
+ORDER BY refetch_requested_at ASC NULLS LAST, last_fetched_at ASC NULLS FIRST
+LIMIT $1
 `
 
-func (q *Queries) GetFeedsByUserID(ctx context.Context, userID uuid.UUID) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getFeedsByUserID, userID)
+// 管理员排队重新抓取的订阅源优先，其余按上次抓取时间
+func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int64) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -132,6 +287,15 @@ func (q *Queries) GetFeedsByUserID(ctx context.Context, userID uuid.UUID) ([]Fee
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Description,
+			&i.Language,
+			&i.Link,
+			&i.Category,
+			&i.LastFetchSucceededAt,
+			&i.LastFetchError,
+			&i.FetchErrorCount,
+			&i.ShortID,
+			&i.RefetchRequestedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -146,21 +310,85 @@ func (q *Queries) GetFeedsByUserID(ctx context.Context, userID uuid.UUID) ([]Fee
 	return items, nil
 }
 
-const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
-SELECT id, name, url, created_at, updated_at, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT $1
+const listFeeds = `-- name: ListFeeds :many
+SELECT f.id, f.name, f.url, f.created_at, f.updated_at, f.user_id, f.last_fetched_at, f.description, f.language, f.link, f.category, f.last_fetch_succeeded_at, f.last_fetch_error, f.fetch_error_count, f.short_id, f.refetch_requested_at, fc.follows_count, lp.last_post_at,
+  EXISTS (
+    SELECT 1 FROM user_feeds uf
+    WHERE uf.feed_id = f.id AND uf.user_id = $1
+  ) AS is_following,
+  COUNT(*) OVER () AS total_count
+FROM feeds f
+LEFT JOIN LATERAL (
+  SELECT COUNT(*) AS follows_count FROM feed_follows WHERE feed_id = f.id
+) fc ON true
+LEFT JOIN LATERAL (
+  SELECT MAX(published_at)::timestamptz AS last_post_at FROM posts WHERE feed_id = f.id
+) lp ON true
+WHERE ($2::text IS NULL
+    OR strpos(lower(f.name), lower($2::text)) > 0
+    OR strpos(lower(f.url), lower($2::text)) > 0
+    OR strpos(lower(f.description), lower($2::text)) > 0)
+  AND ($3::text IS NULL OR starts_with(lower(f.language), lower($3::text)))
+  AND ($4::text IS NULL OR f.category = $4::text)
+ORDER BY
+  CASE WHEN $5::text = 'active' THEN lp.last_post_at END DESC NULLS LAST,
+  CASE WHEN $5::text = 'newest' THEN f.created_at END DESC,
+  fc.follows_count DESC,
+  f.created_at DESC
+LIMIT $6 OFFSET $7
 `
 
-func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int64) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, limit)
+type ListFeedsParams struct {
+	UserID     uuid.NullUUID
+	Query      sql.NullString
+	Language   sql.NullString
+	Category   sql.NullString
+	Sort       string
+	PageLimit  int64
+	PageOffset int64
+}
+
+type ListFeedsRow struct {
+	ID                   uuid.UUID
+	Name                 string
+	Url                  string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	Description          sql.NullString
+	Language             sql.NullString
+	Link                 sql.NullString
+	Category             sql.NullString
+	LastFetchSucceededAt sql.NullTime
+	LastFetchError       sql.NullString
+	FetchErrorCount      int32
+	ShortID              int64
+	RefetchRequestedAt   sql.NullTime
+	FollowsCount         int64
+	LastPostAt           sql.NullTime
+	IsFollowing          bool
+	TotalCount           int64
+}
+
+// 订阅源广场：支持关键字、语言、分类筛选和排序（popular 按关注数，newest 按创建时间，active 按最近发文）
+func (q *Queries) ListFeeds(ctx context.Context, arg ListFeedsParams) ([]ListFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFeeds,
+		arg.UserID,
+		arg.Query,
+		arg.Language,
+		arg.Category,
+		arg.Sort,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Feed
+	var items []ListFeedsRow
 	for rows.Next() {
-		var i Feed
+		var i ListFeedsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
@@ -169,6 +397,19 @@ func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int64) ([]Feed,
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Description,
+			&i.Language,
+			&i.Link,
+			&i.Category,
+			&i.LastFetchSucceededAt,
+			&i.LastFetchError,
+			&i.FetchErrorCount,
+			&i.ShortID,
+			&i.RefetchRequestedAt,
+			&i.FollowsCount,
+			&i.LastPostAt,
+			&i.IsFollowing,
+			&i.TotalCount,
 		); err != nil {
 			return nil, err
 		}
@@ -183,11 +424,39 @@ func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int64) ([]Feed,
 	return items, nil
 }
 
+const markFeedFetchFailed = `-- name: MarkFeedFetchFailed :exec
+UPDATE feeds
+SET last_fetch_error = $2, fetch_error_count = fetch_error_count + 1
+WHERE id = $1
+`
+
+type MarkFeedFetchFailedParams struct {
+	ID             uuid.UUID
+	LastFetchError sql.NullString
+}
+
+// 记录抓取失败原因，fetch_error_count 为连续失败次数
+func (q *Queries) MarkFeedFetchFailed(ctx context.Context, arg MarkFeedFetchFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetchFailed, arg.ID, arg.LastFetchError)
+	return err
+}
+
+const markFeedFetchSucceeded = `-- name: MarkFeedFetchSucceeded :exec
+UPDATE feeds
+SET last_fetch_succeeded_at = NOW(), last_fetch_error = NULL, fetch_error_count = 0
+WHERE id = $1
+`
+
+func (q *Queries) MarkFeedFetchSucceeded(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetchSucceeded, id)
+	return err
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :one
 UPDATE feeds
-SET last_fetched_at = NOW()
+SET last_fetched_at = NOW(), refetch_requested_at = NULL
 WHERE id = $1
-RETURNING id, name, url, created_at, updated_at, user_id, last_fetched_at
+RETURNING id, name, url, created_at, updated_at, user_id, last_fetched_at, description, language, link, category, last_fetch_succeeded_at, last_fetch_error, fetch_error_count, short_id, refetch_requested_at
 `
 
 func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
@@ -201,6 +470,152 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, erro
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Description,
+		&i.Language,
+		&i.Link,
+		&i.Category,
+		&i.LastFetchSucceededAt,
+		&i.LastFetchError,
+		&i.FetchErrorCount,
+		&i.ShortID,
+		&i.RefetchRequestedAt,
+	)
+	return i, err
+}
+
+const transferFeed = `-- name: TransferFeed :one
+UPDATE feeds
+SET user_id = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, url, created_at, updated_at, user_id, last_fetched_at, description, language, link, category, last_fetch_succeeded_at, last_fetch_error, fetch_error_count, short_id, refetch_requested_at
+`
+
+type TransferFeedParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) TransferFeed(ctx context.Context, arg TransferFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, transferFeed, arg.ID, arg.UserID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Description,
+		&i.Language,
+		&i.Link,
+		&i.Category,
+		&i.LastFetchSucceededAt,
+		&i.LastFetchError,
+		&i.FetchErrorCount,
+		&i.ShortID,
+		&i.RefetchRequestedAt,
+	)
+	return i, err
+}
+
+const transferFeedUnlessConflict = `-- name: TransferFeedUnlessConflict :execrows
+UPDATE feeds
+SET user_id = $2, updated_at = NOW()
+WHERE feeds.id = $1 AND NOT EXISTS (
+  SELECT 1 FROM feeds other
+  WHERE other.user_id = $2 AND (other.name = feeds.name OR other.url = feeds.url)
+)
+`
+
+type TransferFeedUnlessConflictParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// 新所有者已有同名或同 URL 的订阅源时不转让，避免唯一约束冲突中止所在事务
+func (q *Queries) TransferFeedUnlessConflict(ctx context.Context, arg TransferFeedUnlessConflictParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, transferFeedUnlessConflict, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateFeed = `-- name: UpdateFeed :one
+UPDATE feeds
+SET name = $2,
+  url = $3,
+  category = $4,
+  updated_at = NOW(),
+  last_fetched_at = CASE WHEN url = $3 THEN last_fetched_at END,
+  last_fetch_error = CASE WHEN url = $3 THEN last_fetch_error END,
+  fetch_error_count = CASE WHEN url = $3 THEN fetch_error_count ELSE 0 END
+WHERE id = $1 AND (url = $3 OR NOT EXISTS (
+  SELECT 1 FROM user_feeds uf WHERE uf.feed_id = feeds.id AND uf.user_id <> feeds.user_id
+))
+RETURNING id, name, url, created_at, updated_at, user_id, last_fetched_at, description, language, link, category, last_fetch_succeeded_at, last_fetch_error, fetch_error_count, short_id, refetch_requested_at
+`
+
+type UpdateFeedParams struct {
+	ID       uuid.UUID
+	Name     string
+	Url      string
+	Category sql.NullString
+}
+
+// 修改 URL 后清空抓取状态，让抓取器尽快重新抓取；还有其他人关注（含团队关注）时不允许修改 URL
+func (q *Queries) UpdateFeed(ctx context.Context, arg UpdateFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeed,
+		arg.ID,
+		arg.Name,
+		arg.Url,
+		arg.Category,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Description,
+		&i.Language,
+		&i.Link,
+		&i.Category,
+		&i.LastFetchSucceededAt,
+		&i.LastFetchError,
+		&i.FetchErrorCount,
+		&i.ShortID,
+		&i.RefetchRequestedAt,
 	)
 	return i, err
 }
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET description = COALESCE(NULLIF($1::text, ''), description),
+  language = COALESCE(NULLIF($2::text, ''), language),
+  link = COALESCE(NULLIF($3::text, ''), link)
+WHERE id = $4
+`
+
+type UpdateFeedMetadataParams struct {
+	Description string
+	Language    string
+	Link        string
+	ID          uuid.UUID
+}
+
+// 抓取成功后用频道信息补充订阅源描述，空值不覆盖已有内容
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata,
+		arg.Description,
+		arg.Language,
+		arg.Link,
+		arg.ID,
+	)
+	return err
+}
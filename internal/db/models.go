@@ -6,19 +6,80 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-type Feed struct {
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scope      string
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+	ExpiresAt  sql.NullTime
+}
+
+type AuditEvent struct {
 	ID            uuid.UUID
-	Name          string
-	Url           string
+	ActorID       uuid.NullUUID
+	ActorUsername sql.NullString
+	Action        string
+	TargetType    sql.NullString
+	TargetID      uuid.NullUUID
+	Details       json.RawMessage
+	Ip            string
+	UserAgent     string
 	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+}
+
+type DigestSetting struct {
+	UserID           uuid.UUID
+	Email            string
+	Frequency        string
+	SendHour         int32
+	Weekday          int32
+	Timezone         string
+	FolderIds        []uuid.UUID
+	Enabled          bool
+	UnsubscribeToken string
+	LastSentAt       sql.NullTime
+	NextSendAt       time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type ExternalIdentity struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Issuer      string
+	Subject     string
+	Email       sql.NullString
+	CreatedAt   time.Time
+	LastLoginAt time.Time
+}
+
+type Feed struct {
+	ID                   uuid.UUID
+	Name                 string
+	Url                  string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	Description          sql.NullString
+	Language             sql.NullString
+	Link                 sql.NullString
+	Category             sql.NullString
+	LastFetchSucceededAt sql.NullTime
+	LastFetchError       sql.NullString
+	FetchErrorCount      int32
+	ShortID              int64
+	RefetchRequestedAt   sql.NullTime
 }
 
 type FeedFollow struct {
@@ -29,22 +90,220 @@ type FeedFollow struct {
 	FeedID    uuid.UUID
 }
 
+type FeedSimilarity struct {
+	FeedID        uuid.UUID
+	SimilarFeedID uuid.UUID
+	CoFollows     int32
+	Score         float64
+	ComputedAt    time.Time
+}
+
+type FeedTransfer struct {
+	FeedID     uuid.UUID
+	FromUserID uuid.UUID
+	ToUserID   uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Folder struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	Position  int32
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ShortID   int64
+}
+
+type FolderFeed struct {
+	FolderID     uuid.UUID
+	FeedFollowID uuid.UUID
+	Position     int32
+	CreatedAt    time.Time
+}
+
+type Invite struct {
+	ID        uuid.UUID
+	CodeHash  string
+	Prefix    string
+	Note      string
+	MaxUses   int32
+	Uses      int32
+	CreatedBy uuid.NullUUID
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	RevokedAt sql.NullTime
+}
+
+type LoginFailure struct {
+	Key         string
+	Failures    int32
+	LockedUntil sql.NullTime
+	UpdatedAt   time.Time
+}
+
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  time.Time
+	FeedID       uuid.UUID
+	SearchTitle  sql.NullString
+	SearchBody   sql.NullString
+	SearchVector interface{}
+	Author       sql.NullString
+	Categories   []string
+	ShortID      int64
+}
+
+type PostState struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	ReadAt    sql.NullTime
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	StarredAt sql.NullTime
+	HiddenAt  sql.NullTime
+	Tags      []string
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	Allowed   bool
+	UpdatedAt time.Time
+}
+
+type RoleQuota struct {
+	Role        string
+	MaxFeeds    sql.NullInt32
+	MaxFollows  sql.NullInt32
+	MaxWebhooks sql.NullInt32
 	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+}
+
+type Rule struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	Field     string
+	MatchType string
+	Pattern   string
+	Action    string
+	Tag       sql.NullString
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Session struct {
+	ID                uuid.UUID
+	UserID            uuid.UUID
+	RefreshTokenHash  string
+	PreviousTokenHash sql.NullString
+	UserAgent         string
+	Ip                string
+	CreatedAt         time.Time
+	LastUsedAt        time.Time
+	ExpiresAt         time.Time
+	RevokedAt         sql.NullTime
+}
+
+type Team struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type TeamFolder struct {
+	ID        uuid.UUID
+	TeamID    uuid.UUID
+	Name      string
+	Position  int32
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type TeamFolderFeed struct {
+	FolderID     uuid.UUID
+	TeamFollowID uuid.UUID
+	Position     int32
+	CreatedAt    time.Time
+}
+
+type TeamFollow struct {
+	ID        uuid.UUID
+	TeamID    uuid.UUID
+	FeedID    uuid.UUID
+	CreatedBy uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type TeamInvitation struct {
+	TeamID    uuid.UUID
+	UserID    uuid.UUID
+	Role      string
+	InvitedBy uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type TeamMember struct {
+	TeamID    uuid.UUID
+	UserID    uuid.UUID
+	Role      string
+	CreatedAt time.Time
 }
 
 type User struct {
+	ID            uuid.UUID
+	Username      string
+	Password      string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	FeedTokenHash sql.NullString
+	FeverApiKey   sql.NullString
+	DisplayName   sql.NullString
+	Email         sql.NullString
+	Role          string
+	DisabledAt    sql.NullTime
+	InviteID      uuid.NullUUID
+	MaxFeeds      sql.NullInt32
+	MaxFollows    sql.NullInt32
+	MaxWebhooks   sql.NullInt32
+}
+
+type UserFeed struct {
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Webhook struct {
 	ID        uuid.UUID
-	Username  string
-	Password  string
+	UserID    uuid.UUID
+	Url       string
+	Secret    string
+	FeedID    uuid.NullUUID
+	FolderID  uuid.NullUUID
+	RuleID    uuid.NullUUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	ApiKey    string
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	WebhookID      uuid.UUID
+	PostID         uuid.UUID
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastStatusCode sql.NullInt32
+	LastError      sql.NullString
+	DeliveredAt    sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :one
@@ -22,12 +23,16 @@ INSERT INTO posts (
   url,
   description,
   published_at,
-  feed_id
+  feed_id,
+  search_title,
+  search_body,
+  author,
+  categories
 )
 VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, search_title, search_body, search_vector, author, categories, short_id
 `
 
 type CreatePostParams struct {
@@ -39,6 +44,10 @@ type CreatePostParams struct {
 	Description sql.NullString
 	PublishedAt time.Time
 	FeedID      uuid.UUID
+	SearchTitle sql.NullString
+	SearchBody  sql.NullString
+	Author      sql.NullString
+	Categories  []string
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -51,6 +60,10 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.SearchTitle,
+		arg.SearchBody,
+		arg.Author,
+		pq.Array(arg.Categories),
 	)
 	var i Post
 	err := row.Scan(
@@ -62,22 +75,109 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.SearchTitle,
+		&i.SearchBody,
+		&i.SearchVector,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.ShortID,
 	)
 	return i, err
 }
 
+const getMaxPostShortID = `-- name: GetMaxPostShortID :one
+SELECT COALESCE(MAX(short_id), 0)::bigint FROM posts
+`
+
+func (q *Queries) GetMaxPostShortID(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getMaxPostShortID)
+	var column1 int64
+	err := row.Scan(&column1)
+	return column1, err
+}
+
+const getPostsForRules = `-- name: GetPostsForRules :many
+SELECT p.id, p.title, p.description, p.author, p.categories, feeds.name AS feed_name, feeds.url AS feed_url FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+JOIN feeds ON p.feed_id = feeds.id
+WHERE uf.user_id = $1 AND p.published_at >= $2
+ORDER BY p.published_at DESC
+`
+
+type GetPostsForRulesParams struct {
+	UserID      uuid.UUID
+	PublishedAt time.Time
+}
+
+type GetPostsForRulesRow struct {
+	ID          uuid.UUID
+	Title       string
+	Description sql.NullString
+	Author      sql.NullString
+	Categories  []string
+	FeedName    string
+	FeedUrl     string
+}
+
+// 规则变更后回溯匹配：用户关注的订阅源中 since 之后发布的文章
+func (q *Queries) GetPostsForRules(ctx context.Context, arg GetPostsForRulesParams) ([]GetPostsForRulesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForRules, arg.UserID, arg.PublishedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForRulesRow
+	for rows.Next() {
+		var i GetPostsForRulesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.FeedName,
+			&i.FeedUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, feeds.name as feed_name FROM posts p
-JOIN feed_follows ff ON p.feed_id = ff.feed_id
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, feeds.name as feed_name, ps.read_at, ps.starred_at, COALESCE(ps.tags, '{}')::text[] AS tags FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
 JOIN feeds ON p.feed_id = feeds.id
-WHERE ff.user_id = $1
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE uf.user_id = $1
+  AND ($2::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM folder_feeds fd
+    JOIN feed_follows ff ON ff.id = fd.feed_follow_id
+    WHERE ff.user_id = uf.user_id AND ff.feed_id = p.feed_id AND fd.folder_id = $2::uuid
+  ))
+  AND (NOT $3::boolean OR ps.read_at IS NULL)
+  AND (NOT $4::boolean OR ps.starred_at IS NOT NULL)
+  AND ($5::text IS NULL OR $5::text = ANY(ps.tags))
+  AND ps.hidden_at IS NULL
 ORDER BY p.published_at DESC
-LIMIT $2
+LIMIT $6 OFFSET $7
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int64
+	UserID      uuid.UUID
+	FolderID    uuid.NullUUID
+	UnreadOnly  bool
+	StarredOnly bool
+	Tag         sql.NullString
+	PageLimit   int64
+	PageOffset  int64
 }
 
 type GetPostsForUserRow struct {
@@ -90,10 +190,21 @@ type GetPostsForUserRow struct {
 	PublishedAt time.Time
 	FeedID      uuid.UUID
 	FeedName    string
+	ReadAt      sql.NullTime
+	StarredAt   sql.NullTime
+	Tags        []string
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.FolderID,
+		arg.UnreadOnly,
+		arg.StarredOnly,
+		arg.Tag,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +222,254 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.PublishedAt,
 			&i.FeedID,
 			&i.FeedName,
+			&i.ReadAt,
+			&i.StarredAt,
+			pq.Array(&i.Tags),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsMissingSearchText = `-- name: GetPostsMissingSearchText :many
+SELECT id, title, description FROM posts
+WHERE search_title IS NULL
+LIMIT $1
+`
+
+type GetPostsMissingSearchTextRow struct {
+	ID          uuid.UUID
+	Title       string
+	Description sql.NullString
+}
+
+func (q *Queries) GetPostsMissingSearchText(ctx context.Context, limit int64) ([]GetPostsMissingSearchTextRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsMissingSearchText, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsMissingSearchTextRow
+	for rows.Next() {
+		var i GetPostsMissingSearchTextRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentPostsByFeed = `-- name: GetRecentPostsByFeed :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
+WHERE feed_id = $1
+ORDER BY published_at DESC
+LIMIT $2
+`
+
+type GetRecentPostsByFeedParams struct {
+	FeedID uuid.UUID
+	Limit  int64
+}
+
+type GetRecentPostsByFeedRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt time.Time
+	FeedID      uuid.UUID
+}
+
+func (q *Queries) GetRecentPostsByFeed(ctx context.Context, arg GetRecentPostsByFeedParams) ([]GetRecentPostsByFeedRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostsByFeed, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRecentPostsByFeedRow
+	for rows.Next() {
+		var i GetRecentPostsByFeedRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStreamPosts = `-- name: GetStreamPosts :many
+SELECT p.id, p.short_id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.author, p.feed_id, feeds.name AS feed_name FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+JOIN feeds ON p.feed_id = feeds.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE uf.user_id = $1 AND p.short_id > $2 AND ps.hidden_at IS NULL
+ORDER BY p.short_id ASC
+LIMIT $3
+`
+
+type GetStreamPostsParams struct {
+	UserID    uuid.UUID
+	AfterID   int64
+	PageLimit int64
+}
+
+type GetStreamPostsRow struct {
+	ID          uuid.UUID
+	ShortID     int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt time.Time
+	Author      sql.NullString
+	FeedID      uuid.UUID
+	FeedName    string
+}
+
+// 实时推送：用户关注的订阅源中 short_id 大于 after_id 的文章，按入库顺序返回
+func (q *Queries) GetStreamPosts(ctx context.Context, arg GetStreamPostsParams) ([]GetStreamPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getStreamPosts, arg.UserID, arg.AfterID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetStreamPostsRow
+	for rows.Next() {
+		var i GetStreamPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ShortID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Author,
+			&i.FeedID,
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notifyNewPost = `-- name: NotifyNewPost :exec
+SELECT pg_notify('new_posts', $1::text)
+`
+
+func (q *Queries) NotifyNewPost(ctx context.Context, payload string) error {
+	_, err := q.db.ExecContext(ctx, notifyNewPost, payload)
+	return err
+}
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, feeds.name AS feed_name,
+  ts_rank_cd(p.search_vector, to_tsquery('simple', $1::text)) AS rank
+FROM posts p
+JOIN feeds ON p.feed_id = feeds.id
+WHERE p.search_vector @@ to_tsquery('simple', $1::text)
+  AND ($2::boolean OR EXISTS (
+    SELECT 1 FROM user_feeds uf
+    WHERE uf.feed_id = p.feed_id AND uf.user_id = $3
+  ))
+ORDER BY rank DESC, p.published_at DESC
+LIMIT $4 OFFSET $5
+`
+
+type SearchPostsParams struct {
+	Query      string
+	AllFeeds   bool
+	UserID     uuid.UUID
+	PageLimit  int64
+	PageOffset int64
+}
+
+type SearchPostsRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt time.Time
+	FeedID      uuid.UUID
+	FeedName    string
+	Rank        float32
+}
+
+// 全文检索文章，默认只搜索用户关注的订阅源，all_feeds 为 true 时搜索全部公开订阅源
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.Query,
+		arg.AllFeeds,
+		arg.UserID,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeedName,
+			&i.Rank,
 		); err != nil {
 			return nil, err
 		}
@@ -124,3 +483,20 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const updatePostSearchText = `-- name: UpdatePostSearchText :exec
+UPDATE posts
+SET search_title = $2, search_body = $3
+WHERE id = $1
+`
+
+type UpdatePostSearchTextParams struct {
+	ID          uuid.UUID
+	SearchTitle sql.NullString
+	SearchBody  sql.NullString
+}
+
+func (q *Queries) UpdatePostSearchText(ctx context.Context, arg UpdatePostSearchTextParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostSearchText, arg.ID, arg.SearchTitle, arg.SearchBody)
+	return err
+}
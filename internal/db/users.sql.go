@@ -7,24 +7,68 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const createExternalUser = `-- name: CreateExternalUser :one
+INSERT INTO users (id, username, password, display_name, email, created_at, updated_at)
+VALUES ($1, $2, '', $3, $4, $5, $5)
+RETURNING id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks
+`
+
+type CreateExternalUserParams struct {
+	ID          uuid.UUID
+	Username    string
+	DisplayName sql.NullString
+	Email       sql.NullString
+	CreatedAt   time.Time
+}
+
+// 单点登录自动创建的用户没有密码
+func (q *Queries) CreateExternalUser(ctx context.Context, arg CreateExternalUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createExternalUser,
+		arg.ID,
+		arg.Username,
+		arg.DisplayName,
+		arg.Email,
+		arg.CreatedAt,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
   id,
   username,
   password,
   created_at,
-  updated_at,
-  api_key
+  updated_at
 )
 VALUES (
-  $1, $2, $3, $4, $5, encode(sha256(random()::text::bytea), 'hex')
+  $1, $2, $3, $4, $5
 )
-RETURNING id, username, password, created_at, updated_at, api_key
+RETURNING id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks
 `
 
 type CreateUserParams struct {
@@ -50,18 +94,96 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Password,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
+	)
+	return i, err
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = $1
+`
+
+// 关注、文件夹、阅读状态、规则、webhook、API Key 和会话通过外键级联删除
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getUserByFeedToken = `-- name: GetUserByFeedToken :one
+SELECT id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks FROM users
+WHERE feed_token_hash = $1 AND disabled_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetUserByFeedToken(ctx context.Context, feedTokenHash sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByFeedToken, feedTokenHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
+	)
+	return i, err
+}
+
+const getUserByFeverAPIKey = `-- name: GetUserByFeverAPIKey :one
+SELECT id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks FROM users
+WHERE fever_api_key = $1 AND disabled_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetUserByFeverAPIKey(ctx context.Context, feverApiKey sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByFeverAPIKey, feverApiKey)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
 	)
 	return i, err
 }
 
-const getUserByAPIKey = `-- name: GetUserByAPIKey :one
-SELECT id, username, password, created_at, updated_at, api_key FROM users
-WHERE api_key = $1 LIMIT 1
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks FROM users
+WHERE id = $1 LIMIT 1
 `
 
-func (q *Queries) GetUserByAPIKey(ctx context.Context, apiKey string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserByAPIKey, apiKey)
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -69,13 +191,22 @@ func (q *Queries) GetUserByAPIKey(ctx context.Context, apiKey string) (User, err
 		&i.Password,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password, created_at, updated_at, api_key FROM users
+SELECT id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -88,7 +219,199 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.Password,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
+	)
+	return i, err
+}
+
+const setUserFeedToken = `-- name: SetUserFeedToken :one
+UPDATE users
+SET feed_token_hash = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks
+`
+
+type SetUserFeedTokenParams struct {
+	ID            uuid.UUID
+	FeedTokenHash sql.NullString
+}
+
+func (q *Queries) SetUserFeedToken(ctx context.Context, arg SetUserFeedTokenParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserFeedToken, arg.ID, arg.FeedTokenHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
+	)
+	return i, err
+}
+
+const setUserFeverAPIKey = `-- name: SetUserFeverAPIKey :one
+UPDATE users
+SET fever_api_key = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks
+`
+
+type SetUserFeverAPIKeyParams struct {
+	ID          uuid.UUID
+	FeverApiKey sql.NullString
+}
+
+func (q *Queries) SetUserFeverAPIKey(ctx context.Context, arg SetUserFeverAPIKeyParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserFeverAPIKey, arg.ID, arg.FeverApiKey)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
+	)
+	return i, err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET role = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks
+`
+
+type SetUserRoleParams struct {
+	Role string
+	ID   uuid.UUID
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.Role, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET password = $1, fever_api_key = NULL, updated_at = NOW()
+WHERE id = $2
+RETURNING id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks
+`
+
+type UpdateUserPasswordParams struct {
+	Password string
+	ID       uuid.UUID
+}
+
+// Fever 凭据由旧密码计算得出，修改密码后一并停用
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.Password, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET username = $1, display_name = $2, email = $3, updated_at = NOW()
+WHERE id = $4
+RETURNING id, username, password, created_at, updated_at, feed_token_hash, fever_api_key, display_name, email, role, disabled_at, invite_id, max_feeds, max_follows, max_webhooks
+`
+
+type UpdateUserProfileParams struct {
+	Username    string
+	DisplayName sql.NullString
+	Email       sql.NullString
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.Username,
+		arg.DisplayName,
+		arg.Email,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedTokenHash,
+		&i.FeverApiKey,
+		&i.DisplayName,
+		&i.Email,
+		&i.Role,
+		&i.DisabledAt,
+		&i.InviteID,
+		&i.MaxFeeds,
+		&i.MaxFollows,
+		&i.MaxWebhooks,
 	)
 	return i, err
 }
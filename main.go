@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/djchanahcjd/go-rss/apikeys"
 	"github.com/djchanahcjd/go-rss/config"
+	"github.com/djchanahcjd/go-rss/digest"
 	"github.com/djchanahcjd/go-rss/handlers"
 	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/oidc"
+	"github.com/djchanahcjd/go-rss/ratelimit"
+	"github.com/djchanahcjd/go-rss/recommend"
 	"github.com/djchanahcjd/go-rss/rss"
+	"github.com/djchanahcjd/go-rss/stream"
+	"github.com/djchanahcjd/go-rss/webhooks"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
 	_ "github.com/lib/pq"
@@ -26,11 +35,70 @@ func main() {
 
 	db := db.New(conn)
 	apiCfg := handlers.ApiConfig{
-		DB: db,
+		DB:               db,
+		Conn:             conn,
+		BaseURL:          config.BaseURL,
+		SessionSecret:    []byte(config.SessionSecret),
+		RateLimits:       ratelimit.PostgresStore{Query: db},
+		RegistrationMode: config.RegistrationMode,
+		TrustedProxies:   config.TrustedProxies,
+	}
+	if err := ratelimit.Configure(config.RateLimits); err != nil {
+		log.Fatal("Invalid rate limit: ", err)
+	}
+	if config.OIDCIssuer != "" {
+		apiCfg.OIDC = oidc.NewProvider(oidc.Config{
+			Issuer:       config.OIDCIssuer,
+			ClientID:     config.OIDCClientID,
+			ClientSecret: config.OIDCClientSecret,
+			RedirectURL:  config.OIDCRedirectURL,
+			Scopes:       config.OIDCScopes,
+			GroupsClaim:  config.OIDCGroupsClaim,
+			AdminGroup:   config.OIDCAdminGroup,
+		})
+		if config.RegistrationMode == "invite" {
+			// 单点登录首次登录不需要邀请码，谁能注册由身份提供方决定
+			log.Println("REGISTRATION_MODE=invite does not apply to single sign-on, restrict who can sign in at the identity provider")
+		}
+	}
+	if config.PasswordLoginDisabled {
+		if apiCfg.OIDC == nil {
+			log.Fatal("PASSWORD_LOGIN_DISABLED requires OIDC_ISSUER")
+		}
+		apiCfg.PasswordLoginDisabled = true
+	}
+	if config.SessionSecret == "" {
+		// 未配置时使用随机密钥，重启后所有访问令牌失效，需要通过刷新令牌重新获取
+		log.Println("SESSION_SECRET not set, using a random secret")
+		apiCfg.SessionSecret = make([]byte, 32)
+		if _, err := rand.Read(apiCfg.SessionSecret); err != nil {
+			log.Fatal("Cannot generate session secret:", err)
+		}
+	}
+	promoteAdmins(db, config.AdminUsers)
+	hub, err := stream.NewHub(config.DBUrl)
+	if err != nil {
+		log.Println("Cannot listen for new posts, post stream disabled:", err)
+	} else {
+		apiCfg.Stream = hub
 	}
 	r := setupRouter(apiCfg)
 
 	go rss.StartScraping(db, 10, time.Minute)
+	go webhooks.StartDelivering(db, 10, 10*time.Second)
+	go recommend.StartRefreshing(db, time.Hour)
+	go ratelimit.StartPruning(db, 10*time.Minute)
+	if config.SMTPHost != "" {
+		go digest.StartScheduler(db, digest.SMTPMailer{
+			Host:     config.SMTPHost,
+			Port:     config.SMTPPort,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.SMTPFrom,
+		}, config.BaseURL)
+	} else {
+		log.Println("SMTP_HOST not set, email digests disabled")
+	}
 
 	log.Printf("Server is running on port: %s\n", config.Port)
 	log.Fatal(http.ListenAndServe(":"+config.Port, r))
@@ -43,9 +111,9 @@ func setupRouter(apiCfg handlers.ApiConfig) *chi.Mux {
 		// AllowedOrigins:   []string{"https://foo.com"}, // Use this to allow specific origin hosts
 		AllowedOrigins: []string{"*"},
 		// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Last-Event-ID"},
+		ExposedHeaders:   []string{"Link", "X-Total-Count", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
@@ -55,6 +123,12 @@ func setupRouter(apiCfg handlers.ApiConfig) *chi.Mux {
 
 	// 静态文件服务
 	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir("frontend/static"))))
+	// 个人输出订阅，通过 feed token 访问
+	r.Get("/feeds/u/{token}.{format}", apiCfg.GetUserOutputFeed)
+	// 邮件摘要退订：GET 只显示确认页面，避免邮件扫描和链接预取误退订；POST 退订，同时支持 List-Unsubscribe-Post 一键退订
+	r.Get("/digest/unsubscribe/{token}", apiCfg.ConfirmUnsubscribeDigest)
+	r.Post("/digest/unsubscribe/{token}", apiCfg.UnsubscribeDigest)
+
 	// 前端入口页面
 	r.Get("/*", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/" {
@@ -65,23 +139,183 @@ func setupRouter(apiCfg handlers.ApiConfig) *chi.Mux {
 	})
 
 	v1Router := chi.NewRouter()
+	// 接口按读写区分限流，先按 IP 计数，认证通过后再按用户计数
+	v1Router.Use(apiCfg.RateLimitByMethod)
 	v1Router.Get("/healthz", handlers.HealthzHandler) //  检查服务是否准备好
 
-	v1Router.Post("/users", apiCfg.CreateUser)
-	v1Router.Post("/users/login", apiCfg.LoginUser)
+	// 注册、登录和刷新会话另外按 IP 严格限流
+	v1Router.Group(func(r chi.Router) {
+		r.Use(apiCfg.RateLimit(ratelimit.Auth))
+		r.Post("/users", apiCfg.CreateUser)
+		r.Post("/users/login", apiCfg.LoginUser)
+		r.Post("/sessions/refresh", apiCfg.RefreshSession)
+		r.Post("/sessions/logout", apiCfg.Logout)
+		r.Get("/auth/oidc/login", apiCfg.OIDCLogin)
+		r.Get("/auth/oidc/callback", apiCfg.OIDCCallback)
+	})
+	v1Router.Get("/auth/methods", apiCfg.GetAuthMethods)
+	v1Router.Get("/sessions", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.GetSessions))
+	v1Router.Delete("/sessions/{sessionID}", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.DeleteSession))
 	v1Router.Get("/users", apiCfg.AuthMiddleware(apiCfg.GetUser))
+	v1Router.Patch("/users", apiCfg.AuthMiddleware(apiCfg.UpdateProfile))
+	v1Router.Delete("/users", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.DeleteAccount))
+	v1Router.Put("/users/password", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.ChangePassword))
+	v1Router.Get("/users/export", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.ExportAccount))
+	v1Router.Get("/users/audit", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.GetAuditEvents))
+	v1Router.Get("/users/quota", apiCfg.AuthMiddleware(apiCfg.GetQuota))
+	v1Router.Post("/users/feed_token", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.RotateFeedToken))
+	v1Router.Delete("/users/feed_token", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.RevokeFeedToken))
+	v1Router.Put("/users/fever", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.SetFeverCredentials))
+	v1Router.Delete("/users/fever", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.DeleteFeverCredentials))
+
+	// API Key 管理需要 admin 权限
+	v1Router.Post("/api-keys", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.CreateAPIKey))
+	v1Router.Get("/api-keys", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.GetAPIKeys))
+	v1Router.Delete("/api-keys/{keyID}", apiCfg.ScopedAuthMiddleware(apikeys.ScopeAdmin, apiCfg.DeleteAPIKey))
 
 	v1Router.Post("/feeds", apiCfg.AuthMiddleware(apiCfg.CreateFeed))
 	v1Router.Get("/feeds", apiCfg.GetAllFeeds)
+	v1Router.Get("/feeds/recommended", apiCfg.AuthMiddleware(apiCfg.GetRecommendedFeeds))
     v1Router.Get("/feeds/by-user", apiCfg.AuthMiddleware(apiCfg.GetFeedsByUser))    // 获取用户创建的订阅源
+	v1Router.Get("/feeds/{feedID}", apiCfg.GetFeed)
+	v1Router.Put("/feeds/{feedID}", apiCfg.AuthMiddleware(apiCfg.UpdateFeed))
+	v1Router.Delete("/feeds/{feedID}", apiCfg.AuthMiddleware(apiCfg.DeleteFeed))
+	v1Router.Post("/feeds/{feedID}/transfer", apiCfg.AuthMiddleware(apiCfg.TransferFeed))
+	v1Router.Get("/feed_transfers", apiCfg.AuthMiddleware(apiCfg.GetFeedTransfers))
+	v1Router.Post("/feed_transfers/{feedID}/accept", apiCfg.AuthMiddleware(apiCfg.AcceptFeedTransfer))
+	v1Router.Delete("/feed_transfers/{feedID}", apiCfg.AuthMiddleware(apiCfg.DeleteFeedTransfer))
 
 	v1Router.Post("/feed_follows", apiCfg.AuthMiddleware(apiCfg.CreateFeedFollows))
 	v1Router.Get("/feed_follows", apiCfg.AuthMiddleware(apiCfg.GetFeedFollowsByUser))
 	v1Router.Delete("/feed_follows/{feedID}", apiCfg.AuthMiddleware(apiCfg.DeleteFeedFollow))
 
+	v1Router.Post("/opml/import", apiCfg.AuthMiddleware(apiCfg.ImportOPML))
+	v1Router.Get("/opml/export", apiCfg.AuthMiddleware(apiCfg.ExportOPML))
+
 	v1Router.Get("/posts", apiCfg.AuthMiddleware(apiCfg.GetPostsForUser))
+	v1Router.Get("/posts/search", apiCfg.AuthMiddleware(apiCfg.SearchPosts))
+	v1Router.Get("/posts/stream", apiCfg.StreamAuthMiddleware(apiCfg.StreamPosts))
+	v1Router.Put("/posts/{postID}/read", apiCfg.AuthMiddleware(apiCfg.MarkPostRead))
+	v1Router.Delete("/posts/{postID}/read", apiCfg.AuthMiddleware(apiCfg.MarkPostUnread))
+	v1Router.Put("/posts/{postID}/star", apiCfg.AuthMiddleware(apiCfg.StarPost))
+	v1Router.Delete("/posts/{postID}/star", apiCfg.AuthMiddleware(apiCfg.UnstarPost))
+	v1Router.Put("/posts/{postID}/hidden", apiCfg.AuthMiddleware(apiCfg.HidePost))
+	v1Router.Delete("/posts/{postID}/hidden", apiCfg.AuthMiddleware(apiCfg.UnhidePost))
+
+	v1Router.Post("/rules", apiCfg.AuthMiddleware(apiCfg.CreateRule))
+	v1Router.Get("/rules", apiCfg.AuthMiddleware(apiCfg.GetRules))
+	v1Router.Put("/rules/{ruleID}", apiCfg.AuthMiddleware(apiCfg.UpdateRule))
+	v1Router.Delete("/rules/{ruleID}", apiCfg.AuthMiddleware(apiCfg.DeleteRule))
+
+	v1Router.Get("/digest", apiCfg.AuthMiddleware(apiCfg.GetDigestSettings))
+	v1Router.Put("/digest", apiCfg.AuthMiddleware(apiCfg.UpdateDigestSettings))
+	v1Router.Delete("/digest", apiCfg.AuthMiddleware(apiCfg.DeleteDigestSettings))
+	v1Router.Get("/digest/preview", apiCfg.AuthMiddleware(apiCfg.PreviewDigest))
+
+	v1Router.Post("/webhooks", apiCfg.AuthMiddleware(apiCfg.CreateWebhook))
+	v1Router.Get("/webhooks", apiCfg.AuthMiddleware(apiCfg.GetWebhooks))
+	v1Router.Delete("/webhooks/{webhookID}", apiCfg.AuthMiddleware(apiCfg.DeleteWebhook))
+	v1Router.Get("/webhooks/{webhookID}/deliveries", apiCfg.AuthMiddleware(apiCfg.GetWebhookDeliveries))
+	v1Router.Post("/webhooks/{webhookID}/deliveries/{deliveryID}/retry", apiCfg.AuthMiddleware(apiCfg.RetryWebhookDelivery))
+
+	v1Router.Post("/folders", apiCfg.AuthMiddleware(apiCfg.CreateFolder))
+	v1Router.Get("/folders", apiCfg.AuthMiddleware(apiCfg.GetFolders))
+	v1Router.Put("/folders/order", apiCfg.AuthMiddleware(apiCfg.ReorderFolders))
+	v1Router.Put("/folders/{folderID}", apiCfg.AuthMiddleware(apiCfg.UpdateFolder))
+	v1Router.Delete("/folders/{folderID}", apiCfg.AuthMiddleware(apiCfg.DeleteFolder))
+	v1Router.Post("/folders/{folderID}/feeds", apiCfg.AuthMiddleware(apiCfg.AddFeedToFolder))
+	v1Router.Put("/folders/{folderID}/feeds/order", apiCfg.AuthMiddleware(apiCfg.ReorderFolderFeeds))
+	v1Router.Delete("/folders/{folderID}/feeds/{feedID}", apiCfg.AuthMiddleware(apiCfg.RemoveFeedFromFolder))
+
+	v1Router.Post("/teams", apiCfg.AuthMiddleware(apiCfg.CreateTeam))
+	v1Router.Get("/teams", apiCfg.AuthMiddleware(apiCfg.GetTeams))
+	v1Router.Get("/teams/{teamID}", apiCfg.AuthMiddleware(apiCfg.GetTeam))
+	v1Router.Put("/teams/{teamID}", apiCfg.AuthMiddleware(apiCfg.UpdateTeam))
+	v1Router.Delete("/teams/{teamID}", apiCfg.AuthMiddleware(apiCfg.DeleteTeam))
+	v1Router.Post("/teams/{teamID}/members", apiCfg.AuthMiddleware(apiCfg.AddTeamMember))
+	v1Router.Put("/teams/{teamID}/members/{userID}", apiCfg.AuthMiddleware(apiCfg.UpdateTeamMember))
+	v1Router.Delete("/teams/{teamID}/members/{userID}", apiCfg.AuthMiddleware(apiCfg.RemoveTeamMember))
+	v1Router.Get("/teams/{teamID}/invitations", apiCfg.AuthMiddleware(apiCfg.GetTeamInvitations))
+	v1Router.Delete("/teams/{teamID}/invitations/{userID}", apiCfg.AuthMiddleware(apiCfg.CancelTeamInvitation))
+	v1Router.Get("/team_invitations", apiCfg.AuthMiddleware(apiCfg.GetIncomingTeamInvitations))
+	v1Router.Post("/team_invitations/{teamID}/accept", apiCfg.AuthMiddleware(apiCfg.AcceptTeamInvitation))
+	v1Router.Delete("/team_invitations/{teamID}", apiCfg.AuthMiddleware(apiCfg.DeclineTeamInvitation))
+	v1Router.Post("/teams/{teamID}/follows", apiCfg.AuthMiddleware(apiCfg.CreateTeamFollow))
+	v1Router.Get("/teams/{teamID}/follows", apiCfg.AuthMiddleware(apiCfg.GetTeamFollows))
+	v1Router.Delete("/teams/{teamID}/follows/{feedID}", apiCfg.AuthMiddleware(apiCfg.DeleteTeamFollow))
+	v1Router.Post("/teams/{teamID}/folders", apiCfg.AuthMiddleware(apiCfg.CreateTeamFolder))
+	v1Router.Get("/teams/{teamID}/folders", apiCfg.AuthMiddleware(apiCfg.GetTeamFolders))
+	v1Router.Put("/teams/{teamID}/folders/{folderID}", apiCfg.AuthMiddleware(apiCfg.UpdateTeamFolder))
+	v1Router.Delete("/teams/{teamID}/folders/{folderID}", apiCfg.AuthMiddleware(apiCfg.DeleteTeamFolder))
+	v1Router.Post("/teams/{teamID}/folders/{folderID}/feeds", apiCfg.AuthMiddleware(apiCfg.AddFeedToTeamFolder))
+	v1Router.Delete("/teams/{teamID}/folders/{folderID}/feeds/{feedID}", apiCfg.AuthMiddleware(apiCfg.RemoveFeedFromTeamFolder))
+	v1Router.Get("/teams/{teamID}/posts", apiCfg.AuthMiddleware(apiCfg.GetTeamPosts))
+
+	// 管理接口，需要管理员角色
+	adminRouter := chi.NewRouter()
+	adminRouter.Use(apiCfg.AdminMiddleware)
+	adminRouter.Get("/users", apiCfg.AdminHandler(apiCfg.AdminGetUsers))
+	adminRouter.Patch("/users/{userID}", apiCfg.AdminHandler(apiCfg.AdminUpdateUser))
+	adminRouter.Put("/users/{userID}/quota", apiCfg.AdminHandler(apiCfg.AdminUpdateUserQuota))
+	adminRouter.Get("/quotas", apiCfg.AdminHandler(apiCfg.AdminGetRoleQuotas))
+	adminRouter.Put("/quotas/{role}", apiCfg.AdminHandler(apiCfg.AdminUpdateRoleQuota))
+	adminRouter.Post("/invites", apiCfg.AdminHandler(apiCfg.AdminCreateInvite))
+	adminRouter.Get("/invites", apiCfg.AdminHandler(apiCfg.AdminGetInvites))
+	adminRouter.Delete("/invites/{inviteID}", apiCfg.AdminHandler(apiCfg.AdminRevokeInvite))
+	adminRouter.Get("/feeds", apiCfg.AdminHandler(apiCfg.AdminGetFeeds))
+	adminRouter.Post("/feeds/refetch", apiCfg.AdminHandler(apiCfg.AdminQueueRefetch))
+	adminRouter.Put("/feeds/{feedID}", apiCfg.AdminHandler(apiCfg.AdminUpdateFeed))
+	adminRouter.Delete("/feeds/{feedID}", apiCfg.AdminHandler(apiCfg.AdminDeleteFeed))
+	adminRouter.Post("/feeds/{feedID}/refetch", apiCfg.AdminHandler(apiCfg.AdminRefetchFeed))
+	adminRouter.Get("/scraper", apiCfg.AdminHandler(apiCfg.AdminGetScraperHealth))
+	adminRouter.Get("/stats", apiCfg.AdminHandler(apiCfg.AdminGetStats))
+	adminRouter.Get("/audit", apiCfg.AdminHandler(apiCfg.AdminGetAuditEvents))
+	v1Router.Mount("/admin", adminRouter)
 
 	r.Mount("/v1", v1Router)
 
+	// Google Reader API 兼容接口
+	r.With(apiCfg.RateLimit(ratelimit.Auth)).Post("/accounts/ClientLogin", apiCfg.GReaderClientLogin)
+	greaderRouter := chi.NewRouter()
+	greaderRouter.Use(apiCfg.RateLimitByMethod)
+	greaderRouter.Get("/token", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderToken))
+	greaderRouter.Get("/user-info", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderUserInfo))
+	greaderRouter.Get("/subscription/list", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderSubscriptionList))
+	greaderRouter.Post("/subscription/edit", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderSubscriptionEdit))
+	greaderRouter.Post("/subscription/quickadd", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderQuickAdd))
+	greaderRouter.Get("/stream/contents", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderStreamContents))
+	greaderRouter.Get("/stream/contents/*", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderStreamContents))
+	greaderRouter.Get("/stream/items/ids", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderStreamItemIDs))
+	greaderRouter.Post("/stream/items/contents", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderStreamItemContents))
+	greaderRouter.Post("/edit-tag", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderEditTag))
+	greaderRouter.Post("/mark-all-as-read", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderMarkAllAsRead))
+	greaderRouter.Get("/tag/list", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderTagList))
+	greaderRouter.Get("/unread-count", apiCfg.GReaderAuthMiddleware(apiCfg.GReaderUnreadCount))
+	r.Mount("/reader/api/0", greaderRouter)
+
+	// Fever API 兼容接口，客户端可能请求 /fever 或 /fever/，同步请求都是 POST，按读取类限流
+	r.With(apiCfg.RateLimit(ratelimit.Read)).HandleFunc("/fever", apiCfg.FeverAPI)
+	r.With(apiCfg.RateLimit(ratelimit.Read)).HandleFunc("/fever/", apiCfg.FeverAPI)
+
 	return r
 }
+
+// promoteAdmins 把配置中的用户设为管理员，用于创建第一个管理员
+func promoteAdmins(query *db.Queries, usernames []string) {
+	ctx := context.Background()
+	for _, username := range usernames {
+		user, err := query.GetUserByUsername(ctx, username)
+		if err != nil {
+			log.Printf("Cannot promote %s to admin: %v", username, err)
+			continue
+		}
+		if user.Role == "admin" {
+			continue
+		}
+		if _, err := query.SetUserRole(ctx, db.SetUserRoleParams{Role: "admin", ID: user.ID}); err != nil {
+			log.Printf("Cannot promote %s to admin: %v", username, err)
+			continue
+		}
+		log.Printf("Promoted %s to admin", username)
+	}
+}
@@ -0,0 +1,447 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testClientID = "go-rss"
+
+// testIssuer 是一个模拟的身份提供方，提供发现文档、JWKS 和令牌接口，
+// 令牌接口按授权请求中的 code_challenge 校验 code_verifier
+type testIssuer struct {
+	*httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+
+	mu         sync.Mutex
+	challenges map[string]string
+	claims     map[string]map[string]any
+	jwksHits   int
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &testIssuer{
+		rsaKey:     rsaKey,
+		ecKey:      ecKey,
+		challenges: map[string]string{},
+		claims:     map[string]map[string]any{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 iss.URL,
+			"authorization_endpoint": iss.URL + "/authorize",
+			"token_endpoint":         iss.URL + "/token",
+			"jwks_uri":               iss.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		iss.mu.Lock()
+		iss.jwksHits++
+		iss.mu.Unlock()
+		b64 := base64.RawURLEncoding.EncodeToString
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+			{"kty": "RSA", "kid": "enc", "use": "enc", "n": b64(rsaKey.N.Bytes()), "e": "AQAB"},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		code := r.PostForm.Get("code")
+		iss.mu.Lock()
+		challenge, ok := iss.challenges[code]
+		claims := iss.claims[code]
+		delete(iss.challenges, code)
+		iss.mu.Unlock()
+		if !ok || r.PostForm.Get("grant_type") != "authorization_code" {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		if Challenge(r.PostForm.Get("code_verifier")) != challenge {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": iss.sign(t, "RS256", "rsa", claims)})
+	})
+	iss.Server = httptest.NewServer(mux)
+	t.Cleanup(iss.Close)
+	return iss
+}
+
+// authorize 模拟用户在身份提供方登录，记录 code_challenge 并返回授权码
+func (iss *testIssuer) authorize(t *testing.T, authURL string, claims map[string]any) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != testClientID {
+		t.Fatalf("unexpected authorization request %s", authURL)
+	}
+	claims["nonce"] = q.Get("nonce")
+	code := "code-" + q.Get("state")
+	iss.mu.Lock()
+	iss.challenges[code] = q.Get("code_challenge")
+	iss.claims[code] = claims
+	iss.mu.Unlock()
+	return code
+}
+
+func (iss *testIssuer) claimsFor(now time.Time) map[string]any {
+	return map[string]any{
+		"iss":                iss.URL,
+		"sub":                "user-1",
+		"aud":                testClientID,
+		"exp":                now.Add(time.Hour).Unix(),
+		"iat":                now.Unix(),
+		"nonce":              "nonce",
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"groups":             []string{"staff", "rss-admins"},
+	}
+}
+
+func (iss *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(unsigned))
+	var sig []byte
+	switch alg {
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, iss.rsaKey, crypto.SHA256, digest[:])
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, iss.ecKey, digest[:])
+		if err == nil {
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (iss *testIssuer) provider() *Provider {
+	p := NewProvider(Config{
+		Issuer:      iss.URL,
+		ClientID:    testClientID,
+		RedirectURL: "https://rss.example.com/v1/auth/oidc/callback",
+		GroupsClaim: "groups",
+		AdminGroup:  "rss-admins",
+	})
+	p.Client = iss.Client()
+	return p
+}
+
+func TestExchange(t *testing.T) {
+	iss := newTestIssuer(t)
+	p := iss.provider()
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		verifier func(state LoginState) string
+		nonce    func(state LoginState) string
+		wantErr  bool
+	}{
+		{"valid", func(s LoginState) string { return s.Verifier }, func(s LoginState) string { return s.Nonce }, false},
+		{"wrong code_verifier", func(s LoginState) string { return s.Verifier + "x" }, func(s LoginState) string { return s.Nonce }, true},
+		{"wrong nonce", func(s LoginState) string { return s.Verifier }, func(s LoginState) string { return "other" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := NewLoginState(time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			authURL, err := p.AuthCodeURL(ctx, state.State, state.Nonce, state.Verifier, false)
+			if err != nil {
+				t.Fatalf("AuthCodeURL() error = %v", err)
+			}
+			code := iss.authorize(t, authURL, iss.claimsFor(time.Now()))
+			identity, err := p.Exchange(ctx, code, tt.verifier(state), tt.nonce(state))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Exchange() = %+v, want error", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange() error = %v", err)
+			}
+			if identity.Subject != "user-1" || identity.PreferredUsername != "alice" || !identity.EmailVerified {
+				t.Errorf("Exchange() = %+v", identity)
+			}
+			if admin, ok := p.IsAdmin(identity); !admin || !ok {
+				t.Errorf("IsAdmin() = %v, %v, want true, true", admin, ok)
+			}
+		})
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	iss := newTestIssuer(t)
+	p := iss.provider()
+	for _, reauth := range []bool{false, true} {
+		authURL, err := p.AuthCodeURL(context.Background(), "state", "nonce", "verifier", reauth)
+		if err != nil {
+			t.Fatalf("AuthCodeURL() error = %v", err)
+		}
+		u, _ := url.Parse(authURL)
+		q := u.Query()
+		if !strings.HasPrefix(authURL, iss.URL+"/authorize?") || q.Get("code_challenge") != Challenge("verifier") || q.Get("state") != "state" || q.Get("nonce") != "nonce" {
+			t.Errorf("AuthCodeURL() = %s", authURL)
+		}
+		if got := q.Get("prompt") == "login" && q.Get("max_age") == "0"; got != reauth {
+			t.Errorf("AuthCodeURL(reauth=%v) prompt=%q max_age=%q", reauth, q.Get("prompt"), q.Get("max_age"))
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	iss := newTestIssuer(t)
+	now := time.Now().Truncate(time.Second)
+	with := func(changes map[string]any) map[string]any {
+		claims := iss.claimsFor(now)
+		for k, v := range changes {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		wantErr bool
+	}{
+		{"rs256", func() string { return iss.sign(t, "RS256", "rsa", with(nil)) }, false},
+		{"es256", func() string { return iss.sign(t, "ES256", "ec", with(nil)) }, false},
+		{"aud array with azp", func() string {
+			return iss.sign(t, "RS256", "rsa", with(map[string]any{"aud": []string{testClientID, "other"}, "azp": testClientID}))
+		}, false},
+		{"issuer with trailing slash", func() string { return iss.sign(t, "RS256", "rsa", with(map[string]any{"iss": iss.URL + "/"})) }, false},
+		{"within clock skew", func() string {
+			return iss.sign(t, "RS256", "rsa", with(map[string]any{"exp": now.Add(-30 * time.Second).Unix()}))
+		}, false},
+		{"wrong issuer", func() string {
+			return iss.sign(t, "RS256", "rsa", with(map[string]any{"iss": "https://evil.example.com"}))
+		}, true},
+		{"wrong audience", func() string { return iss.sign(t, "RS256", "rsa", with(map[string]any{"aud": "other"})) }, true},
+		{"aud array without azp", func() string {
+			return iss.sign(t, "RS256", "rsa", with(map[string]any{"aud": []string{testClientID, "other"}}))
+		}, true},
+		{"expired", func() string {
+			return iss.sign(t, "RS256", "rsa", with(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()}))
+		}, true},
+		{"issued in the future", func() string {
+			return iss.sign(t, "RS256", "rsa", with(map[string]any{"iat": now.Add(time.Hour).Unix()}))
+		}, true},
+		{"nonce mismatch", func() string { return iss.sign(t, "RS256", "rsa", with(map[string]any{"nonce": "replayed"})) }, true},
+		{"missing nonce", func() string { return iss.sign(t, "RS256", "rsa", with(map[string]any{"nonce": nil})) }, true},
+		{"missing sub", func() string { return iss.sign(t, "RS256", "rsa", with(map[string]any{"sub": nil})) }, true},
+		{"alg does not match key", func() string { return iss.sign(t, "ES256", "rsa", with(nil)) }, true},
+		{"encryption key", func() string { return iss.sign(t, "RS256", "enc", with(nil)) }, true},
+		{"unknown kid", func() string { return iss.sign(t, "RS256", "missing", with(nil)) }, true},
+		{"tampered payload", func() string {
+			parts := strings.Split(iss.sign(t, "RS256", "rsa", with(nil)), ".")
+			payload, _ := json.Marshal(with(map[string]any{"sub": "admin"}))
+			return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+		}, true},
+		{"alg none", func() string {
+			parts := strings.Split(iss.sign(t, "RS256", "rsa", with(nil)), ".")
+			header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa"}`))
+			return header + "." + parts[1] + "."
+		}, true},
+		{"not a jwt", func() string { return "garbage" }, true},
+	}
+	p := iss.provider()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := p.Verify(context.Background(), tt.token(), "nonce", now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Errorf("Verify() = %+v, %v, want ErrInvalidIDToken", identity, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if identity.Issuer != iss.URL || identity.Subject != "user-1" || identity.Email != "alice@example.com" {
+				t.Errorf("Verify() = %+v", identity)
+			}
+		})
+	}
+	// 未知 kid 不会在 keysRefreshInterval 内反复获取 JWKS
+	iss.mu.Lock()
+	hits := iss.jwksHits
+	iss.mu.Unlock()
+	if hits != 1 {
+		t.Errorf("jwks fetched %d times, want 1", hits)
+	}
+}
+
+func TestVerifyAuthTime(t *testing.T) {
+	iss := newTestIssuer(t)
+	p := iss.provider()
+	now := time.Now().Truncate(time.Second)
+	tests := []struct {
+		name     string
+		authTime any
+		want     time.Time
+	}{
+		{"present", now.Add(-time.Minute).Unix(), now.Add(-time.Minute)},
+		{"absent", nil, time.Time{}},
+	}
+	for _, tt := range tests {
+		claims := iss.claimsFor(now)
+		if tt.authTime != nil {
+			claims["auth_time"] = tt.authTime
+		}
+		identity, err := p.Verify(context.Background(), iss.sign(t, "RS256", "rsa", claims), "nonce", now)
+		if err != nil {
+			t.Fatalf("%s: Verify() error = %v", tt.name, err)
+		}
+		if !identity.AuthTime.Equal(tt.want) {
+			t.Errorf("%s: AuthTime = %v, want %v", tt.name, identity.AuthTime, tt.want)
+		}
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	iss := newTestIssuer(t)
+	p := NewProvider(Config{Issuer: iss.URL + "/realms/other", ClientID: testClientID})
+	p.Client = iss.Client()
+	if _, err := p.AuthCodeURL(context.Background(), "s", "n", "v", false); err == nil {
+		t.Errorf("AuthCodeURL() with mismatched issuer succeeded")
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	// RFC 7636 附录 B 的示例
+	tests := []struct {
+		verifier string
+		want     string
+	}{
+		{"dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+	}
+	for _, tt := range tests {
+		if got := Challenge(tt.verifier); got != tt.want {
+			t.Errorf("Challenge(%q) = %q, want %q", tt.verifier, got, tt.want)
+		}
+	}
+	v1, err := NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, _ := NewVerifier()
+	if len(v1) != 43 || v1 == v2 {
+		t.Errorf("NewVerifier() = %q, %q, want distinct 43-character values", v1, v2)
+	}
+}
+
+func TestLoginState(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now()
+	state, err := NewLoginState(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Reauth = true
+	sealed, err := state.Seal(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, sig, _ := strings.Cut(sealed, ".")
+	tests := []struct {
+		name    string
+		secret  []byte
+		value   string
+		now     time.Time
+		wantErr bool
+	}{
+		{"valid", secret, sealed, now, false},
+		{"expired", secret, sealed, now.Add(LoginStateTTL), true},
+		{"wrong secret", []byte("other"), sealed, now, true},
+		{"tampered", secret, base64.RawURLEncoding.EncodeToString([]byte(`{"state":"x","exp":9999999999}`)) + "." + sig, now, true},
+		{"missing signature", secret, encoded, now, true},
+	}
+	for _, tt := range tests {
+		got, err := OpenLoginState(tt.secret, tt.value, tt.now)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidLoginState) {
+				t.Errorf("%s: OpenLoginState() error = %v, want ErrInvalidLoginState", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || got != state {
+			t.Errorf("%s: OpenLoginState() = %+v, %v, want %+v", tt.name, got, err, state)
+		}
+	}
+}
+
+func TestClaimStrings(t *testing.T) {
+	claims := map[string]any{
+		"groups":       []any{"a", "b", 3},
+		"role":         "admin",
+		"realm_access": map[string]any{"roles": []any{"rss-admins"}},
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"groups", []string{"a", "b"}},
+		{"role", []string{"admin"}},
+		{"realm_access.roles", []string{"rss-admins"}},
+		{"realm_access.missing", nil},
+		{"role.nested", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := claimStrings(claims, tt.path)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || (got == nil) != (tt.want == nil) {
+			t.Errorf("claimStrings(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
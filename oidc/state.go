@@ -0,0 +1,76 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// LoginStateTTL 从跳转到身份提供方到回调的最长时间
+const LoginStateTTL = 10 * time.Minute
+
+var ErrInvalidLoginState = errors.New("invalid or expired login state")
+
+// LoginState 是授权请求的 state、nonce 和 code_verifier，签名后存放在浏览器 Cookie 中，
+// 回调时与身份提供方带回的 state 比对，服务端无需保存
+type LoginState struct {
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ExpiresAt int64  `json:"exp"`
+	// Reauth 为 true 表示这次登录用于确认身份，回调时要求 auth_time 足够新
+	Reauth bool `json:"reauth,omitempty"`
+}
+
+// NewLoginState 生成一次登录使用的随机值
+func NewLoginState(now time.Time) (LoginState, error) {
+	var s LoginState
+	var err error
+	if s.State, err = NewVerifier(); err != nil {
+		return LoginState{}, err
+	}
+	if s.Nonce, err = NewVerifier(); err != nil {
+		return LoginState{}, err
+	}
+	if s.Verifier, err = NewVerifier(); err != nil {
+		return LoginState{}, err
+	}
+	s.ExpiresAt = now.Add(LoginStateTTL).Unix()
+	return s, nil
+}
+
+// Seal 序列化并签名
+func (s LoginState) Seal(secret []byte) (string, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + stateSignature(secret, encoded), nil
+}
+
+// OpenLoginState 校验签名和有效期
+func OpenLoginState(secret []byte, value string, now time.Time) (LoginState, error) {
+	encoded, sig, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(stateSignature(secret, encoded))) {
+		return LoginState{}, ErrInvalidLoginState
+	}
+	var s LoginState
+	if err := decodeSegment(encoded, &s); err != nil {
+		return LoginState{}, ErrInvalidLoginState
+	}
+	if now.Unix() >= s.ExpiresAt {
+		return LoginState{}, ErrInvalidLoginState
+	}
+	return s, nil
+}
+
+func stateSignature(secret []byte, data string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("oidc-state." + data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,143 @@
+package opml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// OPML 是 OPML 1.0/2.0 文档的根节点
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Subscription 是从 OPML 中展开的一条订阅
+type Subscription struct {
+	Title  string
+	XMLURL string
+	// Folder 为最近一层分类 outline 的名称，顶层订阅为空
+	Folder string
+}
+
+// Folder 是导出时的一个分类及其中的订阅
+type Folder struct {
+	Name          string
+	Subscriptions []Subscription
+}
+
+// Parse 解析 OPML 文档
+func Parse(r io.Reader) (OPML, error) {
+	var doc OPML
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+	if err := decoder.Decode(&doc); err != nil {
+		return OPML{}, err
+	}
+	return doc, nil
+}
+
+// Subscriptions 展开所有带 xmlUrl 的 outline
+func (o OPML) Subscriptions() []Subscription {
+	var subs []Subscription
+	var walk func(outlines []Outline, folder string)
+	walk = func(outlines []Outline, folder string) {
+		for _, outline := range outlines {
+			title := strings.TrimSpace(outline.Title)
+			if title == "" {
+				title = strings.TrimSpace(outline.Text)
+			}
+			if url := strings.TrimSpace(outline.XMLURL); url != "" {
+				subs = append(subs, Subscription{Title: title, XMLURL: url, Folder: folder})
+			}
+			if len(outline.Outlines) > 0 {
+				child := folder
+				if outline.XMLURL == "" && title != "" {
+					child = title
+				}
+				walk(outline.Outlines, child)
+			}
+		}
+	}
+	walk(o.Body.Outlines, "")
+	return subs
+}
+
+// Render 生成 OPML 2.0 文档，未分类的订阅放在顶层
+func Render(w io.Writer, title string, folders []Folder, ungrouped []Subscription) error {
+	doc := OPML{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, folder := range folders {
+		outline := Outline{Text: folder.Name, Title: folder.Name}
+		for _, sub := range folder.Subscriptions {
+			outline.Outlines = append(outline.Outlines, feedOutline(sub))
+		}
+		doc.Body.Outlines = append(doc.Body.Outlines, outline)
+	}
+	for _, sub := range ungrouped {
+		doc.Body.Outlines = append(doc.Body.Outlines, feedOutline(sub))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func feedOutline(sub Subscription) Outline {
+	return Outline{
+		Text:   sub.Title,
+		Title:  sub.Title,
+		Type:   "rss",
+		XMLURL: sub.XMLURL,
+	}
+}
+
+// charsetReader 支持老版本 OPML 常见的 ISO-8859-1 编码
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return bytes.NewReader([]byte(string(runes))), nil
+	}
+	return nil, fmt.Errorf("unsupported charset: %s", charset)
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+)
+
+const (
+	// LockoutThreshold 连续登录失败达到该次数后开始锁定
+	LockoutThreshold = 5
+	// 第一次锁定 1 分钟，之后每多失败一次翻倍，最长 1 小时
+	lockoutBase = time.Minute
+	lockoutMax  = time.Hour
+	// failureWindow 超过该时间没有再失败时重新计数
+	failureWindow = 24 * time.Hour
+	// bucketIdle 所有策略的桶在该时间内都会补满
+	bucketIdle = time.Hour
+)
+
+// LoginKey 登录失败按用户名和 IP 组合计数：攻击者无法从别处把用户锁在门外，
+// 换用户名撞库则由 Auth 策略的 IP 限流拦截
+func LoginKey(username, ip string) string {
+	return "login:" + username + "|" + ip
+}
+
+// FeverKey Fever 只提交由用户名和密码计算出的 api_key，认证失败只能按 IP 计数
+func FeverKey(ip string) string {
+	return "fever:" + ip
+}
+
+// LockedUntil 返回登录锁定的截止时间，未锁定时 ok 为 false
+func LockedUntil(ctx context.Context, query *db.Queries, key string) (time.Time, bool, error) {
+	now := time.Now().UTC()
+	lockedUntil, err := query.GetLoginLockedUntil(ctx, db.GetLoginLockedUntilParams{
+		Key: key,
+		Now: sql.NullTime{Time: now, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return lockedUntil.Time, lockedUntil.Valid, nil
+}
+
+// RecordFailure 记录一次登录失败，达到阈值时锁定并返回截止时间
+func RecordFailure(ctx context.Context, query *db.Queries, key string) (time.Time, bool, error) {
+	now := time.Now().UTC()
+	failures, err := query.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		Key:         key,
+		Now:         now,
+		ResetBefore: now.Add(-failureWindow),
+	})
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if failures < LockoutThreshold {
+		return time.Time{}, false, nil
+	}
+	lockedUntil := now.Add(LockoutDuration(failures))
+	err = query.LockLogin(ctx, db.LockLoginParams{
+		LockedUntil: sql.NullTime{Time: lockedUntil, Valid: true},
+		Key:         key,
+	})
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return lockedUntil, true, nil
+}
+
+// ResetFailures 登录成功后清除失败计数
+func ResetFailures(ctx context.Context, query *db.Queries, key string) error {
+	return query.ClearLoginFailures(ctx, key)
+}
+
+// LockoutDuration 第 failures 次连续失败后的锁定时长
+func LockoutDuration(failures int32) time.Duration {
+	if failures < LockoutThreshold {
+		return 0
+	}
+	d := lockoutBase
+	for i := LockoutThreshold; i < int(failures) && d < lockoutMax; i++ {
+		d *= 2
+	}
+	return min(d, lockoutMax)
+}
+
+// StartPruning 定期删除已补满的令牌桶和过期的登录失败记录
+// 参数：
+//   - query: 数据库查询接口
+//   - interval: 清理间隔
+func StartPruning(query *db.Queries, interval time.Duration) {
+	log.Printf("Pruning rate limit state every %s duration", interval)
+	ticker := time.NewTicker(interval)
+	for ; ; <-ticker.C {
+		ctx := context.Background()
+		now := time.Now().UTC()
+		if err := query.DeleteIdleRateLimitBuckets(ctx, now.Add(-bucketIdle)); err != nil {
+			log.Println("Error pruning rate limit buckets:", err)
+		}
+		err := query.DeleteStaleLoginFailures(ctx, db.DeleteStaleLoginFailuresParams{
+			ResetBefore: now.Add(-failureWindow),
+			Now:         sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			log.Println("Error pruning login failures:", err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+)
+
+// Rate 令牌桶参数：桶容量为 Burst，每隔 Interval 补充一个令牌，零值表示不限制
+type Rate struct {
+	Burst    int
+	Interval time.Duration
+}
+
+func (rate Rate) enabled() bool {
+	return rate.Burst > 0 && rate.Interval > 0
+}
+
+// Policy 一组路由共用的限流策略，分别按客户端 IP 和登录用户计数
+type Policy struct {
+	Name string
+	IP   Rate
+	User Rate
+}
+
+var (
+	// Auth 登录、注册等需要校验密码的接口，尚未登录，只按 IP 限制
+	Auth = Policy{
+		Name: "auth",
+		IP:   Rate{Burst: 10, Interval: 6 * time.Second},
+	}
+	// Read 读取类接口
+	Read = Policy{
+		Name: "read",
+		IP:   Rate{Burst: 600, Interval: 100 * time.Millisecond},
+		User: Rate{Burst: 300, Interval: 200 * time.Millisecond},
+	}
+	// Write 写入类接口
+	Write = Policy{
+		Name: "write",
+		IP:   Rate{Burst: 120, Interval: 500 * time.Millisecond},
+		User: Rate{Burst: 60, Interval: time.Second},
+	}
+)
+
+// ParseRate 解析 "突发/间隔" 格式的令牌桶参数，例如 "10/6s" 表示容量 10、每 6 秒补充一个令牌，
+// "off" 或 "0" 表示不限制
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" || s == "0" {
+		return Rate{}, nil
+	}
+	burst, interval, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q, want burst/interval such as 10/6s", s)
+	}
+	n, err := strconv.Atoi(burst)
+	if err != nil || n <= 0 {
+		return Rate{}, fmt.Errorf("invalid burst in rate %q", s)
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		return Rate{}, fmt.Errorf("invalid interval in rate %q", s)
+	}
+	return Rate{Burst: n, Interval: d}, nil
+}
+
+// Configure 按配置覆盖内置策略，键为 "策略名.ip" 或 "策略名.user"（如 auth.ip、read.user），
+// 值的格式见 ParseRate；需要在注册路由前调用
+func Configure(overrides map[string]string) error {
+	policies := map[string]*Policy{Auth.Name: &Auth, Read.Name: &Read, Write.Name: &Write}
+	for key, value := range overrides {
+		name, target, _ := strings.Cut(key, ".")
+		policy, ok := policies[name]
+		if !ok || (target != "ip" && target != "user") {
+			return fmt.Errorf("unknown rate limit %q", key)
+		}
+		rate, err := ParseRate(value)
+		if err != nil {
+			return fmt.Errorf("rate limit %s: %v", key, err)
+		}
+		if target == "ip" {
+			policy.IP = rate
+		} else {
+			policy.User = rate
+		}
+	}
+	return nil
+}
+
+// PolicyForMethod 按请求方法选择读写策略
+func PolicyForMethod(method string) Policy {
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return Read
+	}
+	return Write
+}
+
+// Result 一次取令牌的结果，用于生成 RateLimit-* 响应头
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset 令牌桶补满所需时间
+	Reset time.Duration
+	// RetryAfter 被拒绝时距下一个可用令牌的时间
+	RetryAfter time.Duration
+}
+
+// Store 保存令牌桶状态，多实例部署时需要共享同一个存储
+type Store interface {
+	Take(ctx context.Context, key string, rate Rate) (Result, error)
+}
+
+// PostgresStore 把令牌桶保存在 Postgres 中，每次取令牌是一条原子的 UPSERT
+type PostgresStore struct {
+	Query *db.Queries
+}
+
+func (s PostgresStore) Take(ctx context.Context, key string, rate Rate) (Result, error) {
+	perSecond := float64(time.Second) / float64(rate.Interval)
+	row, err := s.Query.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Key:             key,
+		Burst:           float64(rate.Burst),
+		Now:             time.Now().UTC(),
+		RefillPerSecond: perSecond,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(rate, row.Allowed, row.Tokens), nil
+}
+
+// newResult 由取令牌后桶内剩余的令牌数计算响应头需要的各项数值
+func newResult(rate Rate, allowed bool, tokens float64) Result {
+	perSecond := float64(time.Second) / float64(rate.Interval)
+	result := Result{
+		Allowed:   allowed,
+		Limit:     rate.Burst,
+		Remaining: max(int(math.Floor(tokens)), 0),
+		Reset:     secondsDuration((float64(rate.Burst) - tokens) / perSecond),
+	}
+	if !allowed {
+		result.RetryAfter = secondsDuration((1 - tokens) / perSecond)
+	}
+	return result
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(max(seconds, 0) * float64(time.Second))
+}
+
+// Take 检查 key 对应的令牌桶，rate 为零值时直接放行
+func Take(ctx context.Context, store Store, key string, rate Rate) (Result, error) {
+	if store == nil || !rate.enabled() {
+		return Result{Allowed: true}, nil
+	}
+	return store.Take(ctx, key, rate)
+}
@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		failures int32
+		want     time.Duration
+	}{
+		{0, 0},
+		{LockoutThreshold - 1, 0},
+		{LockoutThreshold, time.Minute},
+		{LockoutThreshold + 1, 2 * time.Minute},
+		{LockoutThreshold + 2, 4 * time.Minute},
+		{LockoutThreshold + 5, 32 * time.Minute},
+		{LockoutThreshold + 6, time.Hour},
+		{LockoutThreshold + 100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := LockoutDuration(tt.failures); got != tt.want {
+			t.Errorf("LockoutDuration(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	rate := Rate{Burst: 10, Interval: 6 * time.Second}
+	tests := []struct {
+		name    string
+		allowed bool
+		tokens  float64
+		want    Result
+	}{
+		{
+			name:    "first request",
+			allowed: true,
+			tokens:  9,
+			want:    Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 6 * time.Second},
+		},
+		{
+			name:    "fractional tokens round down",
+			allowed: true,
+			tokens:  2.5,
+			want:    Result{Allowed: true, Limit: 10, Remaining: 2, Reset: 45 * time.Second},
+		},
+		{
+			name:    "empty bucket",
+			allowed: false,
+			tokens:  0,
+			want:    Result{Allowed: false, Limit: 10, Remaining: 0, Reset: time.Minute, RetryAfter: 6 * time.Second},
+		},
+		{
+			name:    "partly refilled",
+			allowed: false,
+			tokens:  0.5,
+			want:    Result{Allowed: false, Limit: 10, Remaining: 0, Reset: 57 * time.Second, RetryAfter: 3 * time.Second},
+		},
+		{
+			name:    "full bucket",
+			allowed: true,
+			tokens:  10,
+			want:    Result{Allowed: true, Limit: 10, Remaining: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newResult(rate, tt.allowed, tt.tokens); got != tt.want {
+				t.Errorf("newResult() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// countingStore 记录调用次数，用于确认零值策略不会访问存储
+type countingStore struct{ calls int }
+
+func (s *countingStore) Take(ctx context.Context, key string, rate Rate) (Result, error) {
+	s.calls++
+	return Result{Allowed: true, Limit: rate.Burst}, nil
+}
+
+func TestTake(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      Rate
+		wantCalls int
+	}{
+		{"disabled", Rate{}, 0},
+		{"no interval", Rate{Burst: 10}, 0},
+		{"no burst", Rate{Interval: time.Second}, 0},
+		{"enabled", Rate{Burst: 10, Interval: time.Second}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &countingStore{}
+			result, err := Take(context.Background(), store, "key", tt.rate)
+			if err != nil || !result.Allowed {
+				t.Fatalf("Take() = %+v, %v, want allowed", result, err)
+			}
+			if store.calls != tt.wantCalls {
+				t.Errorf("store called %d times, want %d", store.calls, tt.wantCalls)
+			}
+		})
+	}
+	if result, err := Take(context.Background(), nil, "key", Rate{Burst: 1, Interval: time.Second}); err != nil || !result.Allowed {
+		t.Errorf("Take() with nil store = %+v, %v, want allowed", result, err)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rate
+		wantErr bool
+	}{
+		{"10/6s", Rate{Burst: 10, Interval: 6 * time.Second}, false},
+		{" 600/100ms ", Rate{Burst: 600, Interval: 100 * time.Millisecond}, false},
+		{"off", Rate{}, false},
+		{"0", Rate{}, false},
+		{"10", Rate{}, true},
+		{"0/1s", Rate{}, true},
+		{"-1/1s", Rate{}, true},
+		{"10/0s", Rate{}, true},
+		{"10/fast", Rate{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRate(%q) = %+v, %v, want %+v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	saved := []Policy{Auth, Read, Write}
+	t.Cleanup(func() { Auth, Read, Write = saved[0], saved[1], saved[2] })
+
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		wantErr   bool
+	}{
+		{"unknown policy", map[string]string{"admin.ip": "1/1s"}, true},
+		{"unknown target", map[string]string{"auth.key": "1/1s"}, true},
+		{"invalid rate", map[string]string{"auth.ip": "fast"}, true},
+		{"valid", map[string]string{"auth.ip": "5/1m", "read.user": "off", "write.ip": "50/1s"}, false},
+	}
+	for _, tt := range tests {
+		if err := Configure(tt.overrides); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Configure() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if Auth.IP != (Rate{Burst: 5, Interval: time.Minute}) {
+		t.Errorf("Auth.IP = %+v", Auth.IP)
+	}
+	if Read.User != (Rate{}) || Read.IP != saved[1].IP {
+		t.Errorf("Read = %+v", Read)
+	}
+	if Write.IP != (Rate{Burst: 50, Interval: time.Second}) || Write.User != saved[2].User {
+		t.Errorf("Write = %+v", Write)
+	}
+}
@@ -0,0 +1,32 @@
+package recommend
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+)
+
+// StartRefreshing 定期重新计算订阅源之间的共同关注相似度
+// 参数：
+//   - query: 数据库查询接口
+//   - interval: 重新计算的间隔
+func StartRefreshing(query *db.Queries, interval time.Duration) {
+	log.Printf("Refreshing feed similarities every %s duration", interval)
+	ticker := time.NewTicker(interval)
+	for ; ; <-ticker.C {
+		if err := Refresh(context.Background(), query); err != nil {
+			log.Println("Error refreshing feed similarities:", err)
+		}
+	}
+}
+
+// Refresh 重新计算全部相似度，并删除本轮没有再出现的订阅源组合
+func Refresh(ctx context.Context, query *db.Queries) error {
+	computedAt := time.Now().UTC()
+	if err := query.RefreshFeedSimilarities(ctx, computedAt); err != nil {
+		return err
+	}
+	return query.DeleteStaleFeedSimilarities(ctx, computedAt)
+}
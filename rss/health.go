@@ -0,0 +1,27 @@
+package rss
+
+import "database/sql"
+
+// DeadFeedErrorCount 连续抓取失败达到该次数的订阅源视为失效
+const DeadFeedErrorCount = 10
+
+// 订阅源健康状态
+const (
+	FeedHealthPending = "pending" // 尚未抓取
+	FeedHealthHealthy = "healthy" // 最近一次抓取成功
+	FeedHealthFailing = "failing" // 最近抓取失败，仍会继续重试
+	FeedHealthDead    = "dead"    // 连续失败次数过多
+)
+
+// FeedHealth 根据抓取记录判断订阅源的健康状态
+func FeedHealth(lastFetchedAt sql.NullTime, fetchErrorCount int32) string {
+	switch {
+	case !lastFetchedAt.Valid:
+		return FeedHealthPending
+	case fetchErrorCount >= DeadFeedErrorCount:
+		return FeedHealthDead
+	case fetchErrorCount > 0:
+		return FeedHealthFailing
+	}
+	return FeedHealthHealthy
+}
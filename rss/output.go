@@ -0,0 +1,206 @@
+package rss
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// OutputFeed 是对外输出的聚合订阅，可渲染为 RSS 2.0、Atom 1.0 或 JSON Feed 1.1
+type OutputFeed struct {
+	Title       string
+	Description string
+	// Link 为网站首页，FeedURL 为当前订阅自身的地址
+	Link    string
+	FeedURL string
+	Updated time.Time
+	Items   []OutputItem
+}
+
+type OutputItem struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	// Author 使用来源订阅源的名称
+	Author    string
+	Published time.Time
+}
+
+type rssOutput struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Atom    string   `xml:"xmlns:atom,attr"`
+	DC      string   `xml:"xmlns:dc,attr"`
+	Channel struct {
+		Title         string `xml:"title"`
+		Link          string `xml:"link"`
+		Description   string `xml:"description"`
+		LastBuildDate string `xml:"lastBuildDate"`
+		AtomLink      struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"atom:link"`
+		Items []rssOutputItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssOutputItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description,omitempty"`
+	Creator     string `xml:"dc:creator,omitempty"`
+	GUID        struct {
+		Value       string `xml:",chardata"`
+		IsPermaLink bool   `xml:"isPermaLink,attr"`
+	} `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+}
+
+// RenderRSS 输出 RSS 2.0
+func RenderRSS(w io.Writer, feed OutputFeed) error {
+	var doc rssOutput
+	doc.Version = "2.0"
+	doc.Atom = "http://www.w3.org/2005/Atom"
+	doc.DC = "http://purl.org/dc/elements/1.1/"
+	doc.Channel.Title = feed.Title
+	doc.Channel.Link = feed.Link
+	doc.Channel.Description = feed.Description
+	doc.Channel.LastBuildDate = feed.Updated.UTC().Format(time.RFC1123Z)
+	doc.Channel.AtomLink.Href = feed.FeedURL
+	doc.Channel.AtomLink.Rel = "self"
+	doc.Channel.AtomLink.Type = "application/rss+xml"
+	for _, item := range feed.Items {
+		out := rssOutputItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			Creator:     item.Author,
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		}
+		out.GUID.Value = item.ID
+		doc.Channel.Items = append(doc.Channel.Items, out)
+	}
+	return writeXML(w, doc)
+}
+
+type atomOutput struct {
+	XMLName xml.Name          `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string            `xml:"title"`
+	ID      string            `xml:"id"`
+	Updated string            `xml:"updated"`
+	Links   []atomOutputLink  `xml:"link"`
+	Entries []atomOutputEntry `xml:"entry"`
+}
+
+type atomOutputLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomOutputEntry struct {
+	Title     string         `xml:"title"`
+	ID        string         `xml:"id"`
+	Link      atomOutputLink `xml:"link"`
+	Updated   string         `xml:"updated"`
+	Published string         `xml:"published"`
+	Author    struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Summary *struct {
+		Type string `xml:"type,attr"`
+		Body string `xml:",chardata"`
+	} `xml:"summary,omitempty"`
+}
+
+// RenderAtom 输出 Atom 1.0
+func RenderAtom(w io.Writer, feed OutputFeed) error {
+	doc := atomOutput{
+		Title:   feed.Title,
+		ID:      feed.FeedURL,
+		Updated: feed.Updated.UTC().Format(time.RFC3339),
+		Links: []atomOutputLink{
+			{Href: feed.FeedURL, Rel: "self"},
+			{Href: feed.Link, Rel: "alternate"},
+		},
+	}
+	for _, item := range feed.Items {
+		entry := atomOutputEntry{
+			Title:     item.Title,
+			ID:        item.ID,
+			Link:      atomOutputLink{Href: item.Link, Rel: "alternate"},
+			Updated:   item.Published.UTC().Format(time.RFC3339),
+			Published: item.Published.UTC().Format(time.RFC3339),
+		}
+		entry.Author.Name = item.Author
+		if item.Description != "" {
+			entry.Summary = &struct {
+				Type string `xml:"type,attr"`
+				Body string `xml:",chardata"`
+			}{Type: "html", Body: item.Description}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return writeXML(w, doc)
+}
+
+type jsonFeedOutput struct {
+	Version     string               `json:"version"`
+	Title       string               `json:"title"`
+	HomePageURL string               `json:"home_page_url,omitempty"`
+	FeedURL     string               `json:"feed_url"`
+	Description string               `json:"description,omitempty"`
+	Items       []jsonFeedOutputItem `json:"items"`
+}
+
+type jsonFeedOutputItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	ContentHTML   string `json:"content_html,omitempty"`
+	DatePublished string `json:"date_published"`
+	Authors       []struct {
+		Name string `json:"name"`
+	} `json:"authors,omitempty"`
+}
+
+// RenderJSONFeed 输出 JSON Feed 1.1
+func RenderJSONFeed(w io.Writer, feed OutputFeed) error {
+	doc := jsonFeedOutput{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		FeedURL:     feed.FeedURL,
+		Description: feed.Description,
+		Items:       []jsonFeedOutputItem{},
+	}
+	for _, item := range feed.Items {
+		out := jsonFeedOutputItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.Description,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+		}
+		if item.Author != "" {
+			out.Authors = append(out.Authors, struct {
+				Name string `json:"name"`
+			}{Name: item.Author})
+		}
+		doc.Items = append(doc.Items, out)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func writeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
@@ -2,6 +2,7 @@ package rss
 
 import (
 	"encoding/xml"
+	"fmt"
 	"net/http"
 	"time"
 )
@@ -22,6 +23,10 @@ type RSSItem struct {
 	Link string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate string `xml:"pubDate"`
+	Author string `xml:"author"`
+	// Creator 为 Dublin Core 的 dc:creator，多数博客用它代替 author
+	Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories []string `xml:"category"`
 }
 
 func urlToRSSFeed(url string) (RSSFeed, error) {
@@ -33,6 +38,9 @@ func urlToRSSFeed(url string) (RSSFeed, error) {
 		return RSSFeed{}, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return RSSFeed{}, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
 
 	var rssFeed RSSFeed
 	err = xml.NewDecoder(resp.Body).Decode(&rssFeed)
@@ -9,6 +9,10 @@ import (
 	"time"
 
 	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/rules"
+	"github.com/djchanahcjd/go-rss/search"
+	"github.com/djchanahcjd/go-rss/stream"
+	"github.com/djchanahcjd/go-rss/webhooks"
 	"github.com/google/uuid"
 )
 
@@ -23,6 +27,7 @@ func StartScraping(
 	timeBetweenRequest time.Duration,
 ) {
 	log.Printf("Scraping on %v goroutines every %s duration", concurrency, timeBetweenRequest)
+	go backfillSearchText(query)
 	ticker := time.NewTicker(timeBetweenRequest)
 	for ; ; <-ticker.C {
 		feeds, err := query.GetNextFeedsToFetch(
@@ -50,6 +55,11 @@ func StartScraping(
 //   - feed: 要抓取的feed信息
 func scrapeFeed(wg *sync.WaitGroup, query *db.Queries, feed db.Feed) {
 	defer wg.Done()
+	FetchFeed(query, feed)
+}
+
+// FetchFeed 立即抓取单个订阅源，抓取结果记录在订阅源的抓取状态中
+func FetchFeed(query *db.Queries, feed db.Feed) {
 	_, err := query.MarkFeedFetched(context.Background(), feed.ID)
 	if err != nil {
 		log.Println("Error marking feed as fetched:", err)
@@ -59,8 +69,32 @@ func scrapeFeed(wg *sync.WaitGroup, query *db.Queries, feed db.Feed) {
 	rssFeed, err := urlToRSSFeed(feed.Url)
 	if err != nil {
 		log.Printf("Error fetching feed from %s: %v\n", feed.Url, err)
+		err = query.MarkFeedFetchFailed(context.Background(), db.MarkFeedFetchFailedParams{
+			ID:             feed.ID,
+			LastFetchError: sql.NullString{String: err.Error(), Valid: true},
+		})
+		if err != nil {
+			log.Println("Error marking feed fetch failed:", err)
+		}
 		return
 	}
+	err = query.MarkFeedFetchSucceeded(context.Background(), feed.ID)
+	if err != nil {
+		log.Println("Error marking feed fetch succeeded:", err)
+	}
+
+	err = query.UpdateFeedMetadata(context.Background(), db.UpdateFeedMetadataParams{
+		ID:          feed.ID,
+		Description: strings.TrimSpace(search.PlainText(rssFeed.Channel.Description)),
+		Language:    strings.TrimSpace(rssFeed.Channel.Language),
+		Link:        strings.TrimSpace(rssFeed.Channel.Link),
+	})
+	if err != nil {
+		log.Println("Error updating feed metadata:", err)
+	}
+
+	userRules := loadFeedRules(query, feed.ID)
+	hooks := webhooks.TargetsForFeed(context.Background(), query, feed.ID)
 
 	for _, item := range rssFeed.Channel.Items {
 		description := sql.NullString{}
@@ -78,8 +112,19 @@ func scrapeFeed(wg *sync.WaitGroup, query *db.Queries, feed db.Feed) {
 			continue
 		}
 
+		author := strings.TrimSpace(item.Creator)
+		if author == "" {
+			author = strings.TrimSpace(item.Author)
+		}
+		categories := []string{}
+		for _, category := range item.Categories {
+			if category = strings.TrimSpace(category); category != "" {
+				categories = append(categories, category)
+			}
+		}
+
 		// 创建新的文章记录
-		_, err = query.CreatePost(
+		post, err := query.CreatePost(
 			context.Background(),
 			db.CreatePostParams{
 				ID:          uuid.New(),
@@ -90,6 +135,10 @@ func scrapeFeed(wg *sync.WaitGroup, query *db.Queries, feed db.Feed) {
 				Description: description,
 				PublishedAt: publishedAt,
 				FeedID:      feed.ID,
+				SearchTitle: sql.NullString{String: search.Tokenize(item.Title), Valid: true},
+				SearchBody:  sql.NullString{String: search.Tokenize(search.PlainText(item.Description)), Valid: true},
+				Author:      sql.NullString{String: author, Valid: author != ""},
+				Categories:  categories,
 			},
 		)
 		if err != nil {
@@ -99,6 +148,77 @@ func scrapeFeed(wg *sync.WaitGroup, query *db.Queries, feed db.Feed) {
 			log.Printf("Error creating post: %v\n", err)
 			continue
 		}
+
+		// 按关注者各自的规则处理新文章
+		target := rules.Post{
+			FeedName:   feed.Name,
+			FeedURL:    feed.Url,
+			Title:      item.Title,
+			Content:    search.PlainText(item.Description),
+			Author:     author,
+			Categories: categories,
+		}
+		for userID, list := range userRules {
+			result := rules.Evaluate(list, target)
+			if result.Empty() {
+				continue
+			}
+			if err := rules.Save(context.Background(), query, userID, post.ID, result); err != nil {
+				log.Printf("Error applying rules: %v\n", err)
+			}
+		}
+		webhooks.Enqueue(context.Background(), query, hooks, post.ID, target)
+		stream.Publish(context.Background(), query, post)
 	}
 	log.Printf("==> 👀 Feed %s collected, %v posts found", feed.Name, len(rssFeed.Channel.Items))
-}
\ No newline at end of file
+}
+
+// loadFeedRules 按用户分组加载关注了该订阅源的用户的规则，无法编译的规则会被跳过
+func loadFeedRules(query *db.Queries, feedID uuid.UUID) map[uuid.UUID][]rules.Rule {
+	userRules := make(map[uuid.UUID][]rules.Rule)
+	list, err := query.GetEnabledRulesForFeed(context.Background(), feedID)
+	if err != nil {
+		log.Println("Error loading rules:", err)
+		return userRules
+	}
+	for _, r := range list {
+		rule, err := rules.FromDB(r)
+		if err != nil {
+			log.Printf("Skipping rule %s: %v\n", r.ID, err)
+			continue
+		}
+		userRules[r.UserID] = append(userRules[r.UserID], rule)
+	}
+	return userRules
+}
+
+// backfillSearchText 为旧文章补齐检索表示（search_title/search_body），分批处理直到没有遗漏
+func backfillSearchText(query *db.Queries) {
+	const batchSize = 500
+	total := 0
+	for {
+		posts, err := query.GetPostsMissingSearchText(context.Background(), batchSize)
+		if err != nil {
+			log.Println("Error loading posts for search backfill:", err)
+			return
+		}
+		for _, post := range posts {
+			err := query.UpdatePostSearchText(context.Background(), db.UpdatePostSearchTextParams{
+				ID:          post.ID,
+				SearchTitle: sql.NullString{String: search.Tokenize(post.Title), Valid: true},
+				SearchBody:  sql.NullString{String: search.Tokenize(search.PlainText(post.Description.String)), Valid: true},
+			})
+			if err != nil {
+				log.Println("Error updating post search text:", err)
+				return
+			}
+		}
+		total += len(posts)
+		if len(posts) < batchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("==> 🔍 Search text backfilled for %v posts", total)
+	}
+}
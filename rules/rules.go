@@ -0,0 +1,178 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 规则匹配的字段
+const (
+	FieldFeed     = "feed"
+	FieldTitle    = "title"
+	FieldContent  = "content"
+	FieldAuthor   = "author"
+	FieldCategory = "category"
+)
+
+// 匹配方式
+const (
+	MatchContains = "contains"
+	MatchRegex    = "regex"
+)
+
+// 命中后执行的动作
+const (
+	ActionHide = "hide"
+	ActionRead = "read"
+	ActionStar = "star"
+	ActionTag  = "tag"
+)
+
+const maxPatternLength = 500
+
+// Rule 是编译后的过滤规则
+type Rule struct {
+	Field     string
+	MatchType string
+	Pattern   string
+	Action    string
+	Tag       string
+
+	needle string
+	re     *regexp.Regexp
+}
+
+// Post 是参与匹配的文章内容，Content 应为去掉 HTML 标签后的纯文本
+type Post struct {
+	FeedName   string
+	FeedURL    string
+	Title      string
+	Content    string
+	Author     string
+	Categories []string
+}
+
+// Result 是一篇文章命中的所有规则动作的合集
+type Result struct {
+	Hide bool
+	Read bool
+	Star bool
+	Tags []string
+}
+
+// Empty 没有命中任何规则
+func (r Result) Empty() bool {
+	return !r.Hide && !r.Read && !r.Star && len(r.Tags) == 0
+}
+
+// New 校验并编译规则，contains 不区分大小写
+func New(field, matchType, pattern, action, tag string) (Rule, error) {
+	switch field {
+	case FieldFeed, FieldTitle, FieldContent, FieldAuthor, FieldCategory:
+	default:
+		return Rule{}, fmt.Errorf("invalid field: %q", field)
+	}
+	switch action {
+	case ActionHide, ActionRead, ActionStar:
+		tag = ""
+	case ActionTag:
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return Rule{}, fmt.Errorf("tag is required for action %q", action)
+		}
+		if len(tag) > 64 {
+			return Rule{}, fmt.Errorf("tag is too long")
+		}
+	default:
+		return Rule{}, fmt.Errorf("invalid action: %q", action)
+	}
+	if pattern == "" {
+		return Rule{}, fmt.Errorf("pattern is required")
+	}
+	if len(pattern) > maxPatternLength {
+		return Rule{}, fmt.Errorf("pattern is too long")
+	}
+
+	rule := Rule{
+		Field:     field,
+		MatchType: matchType,
+		Pattern:   pattern,
+		Action:    action,
+		Tag:       tag,
+	}
+	switch matchType {
+	case MatchContains:
+		rule.needle = strings.ToLower(pattern)
+	case MatchRegex:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid regex: %v", err)
+		}
+		rule.re = re
+	default:
+		return Rule{}, fmt.Errorf("invalid match_type: %q", matchType)
+	}
+	return rule, nil
+}
+
+// Match 判断文章是否命中规则，feed 字段同时匹配订阅源名称和 URL，category 匹配任一分类
+func (r Rule) Match(post Post) bool {
+	switch r.Field {
+	case FieldFeed:
+		return r.matchText(post.FeedName) || r.matchText(post.FeedURL)
+	case FieldTitle:
+		return r.matchText(post.Title)
+	case FieldContent:
+		return r.matchText(post.Content)
+	case FieldAuthor:
+		return r.matchText(post.Author)
+	case FieldCategory:
+		for _, category := range post.Categories {
+			if r.matchText(category) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r Rule) matchText(s string) bool {
+	if s == "" {
+		return false
+	}
+	if r.re != nil {
+		return r.re.MatchString(s)
+	}
+	return strings.Contains(strings.ToLower(s), r.needle)
+}
+
+// Evaluate 依次匹配所有规则并合并命中的动作
+func Evaluate(rules []Rule, post Post) Result {
+	var result Result
+	for _, rule := range rules {
+		if !rule.Match(post) {
+			continue
+		}
+		switch rule.Action {
+		case ActionHide:
+			result.Hide = true
+		case ActionRead:
+			result.Read = true
+		case ActionStar:
+			result.Star = true
+		case ActionTag:
+			result.Tags = appendUnique(result.Tags, rule.Tag)
+		}
+	}
+	return result
+}
+
+func appendUnique(tags []string, tag string) []string {
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
@@ -0,0 +1,129 @@
+package rules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustNew(t *testing.T, field, matchType, pattern, action, tag string) Rule {
+	t.Helper()
+	rule, err := New(field, matchType, pattern, action, tag)
+	if err != nil {
+		t.Fatalf("New(%q, %q, %q, %q, %q) error: %v", field, matchType, pattern, action, tag, err)
+	}
+	return rule
+}
+
+func TestNewValidation(t *testing.T) {
+	tests := []struct {
+		name                                   string
+		field, matchType, pattern, action, tag string
+	}{
+		{"invalid field", "body", MatchContains, "go", ActionHide, ""},
+		{"invalid match type", FieldTitle, "glob", "go", ActionHide, ""},
+		{"invalid action", FieldTitle, MatchContains, "go", "delete", ""},
+		{"empty pattern", FieldTitle, MatchContains, "", ActionHide, ""},
+		{"pattern too long", FieldTitle, MatchContains, strings.Repeat("a", maxPatternLength+1), ActionHide, ""},
+		{"invalid regex", FieldTitle, MatchRegex, "(", ActionHide, ""},
+		{"tag required", FieldTitle, MatchContains, "go", ActionTag, "  "},
+		{"tag too long", FieldTitle, MatchContains, "go", ActionTag, strings.Repeat("t", 65)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.field, tt.matchType, tt.pattern, tt.action, tt.tag); err == nil {
+				t.Error("New succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestNewClearsTagForOtherActions(t *testing.T) {
+	rule := mustNew(t, FieldTitle, MatchContains, "go", ActionStar, "ignored")
+	if rule.Tag != "" {
+		t.Errorf("Tag = %q, want empty", rule.Tag)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	post := Post{
+		FeedName:   "Go Blog",
+		FeedURL:    "https://go.dev/blog/feed.atom",
+		Title:      "Go 1.24 is released",
+		Content:    "Generic type aliases are now fully supported.",
+		Author:     "The Go Team",
+		Categories: []string{"release", "Announcements"},
+	}
+	tests := []struct {
+		field, matchType, pattern string
+		want                      bool
+	}{
+		{FieldTitle, MatchContains, "RELEASED", true},
+		{FieldTitle, MatchContains, "rust", false},
+		{FieldTitle, MatchRegex, `^Go 1\.\d+`, true},
+		// 正则区分大小写，需要时使用 (?i)
+		{FieldTitle, MatchRegex, `^go`, false},
+		{FieldTitle, MatchRegex, `(?i)^go`, true},
+		{FieldFeed, MatchContains, "go blog", true},
+		{FieldFeed, MatchContains, "go.dev", true},
+		{FieldContent, MatchContains, "aliases", true},
+		{FieldAuthor, MatchContains, "team", true},
+		{FieldCategory, MatchContains, "announce", true},
+		{FieldCategory, MatchRegex, `^security$`, false},
+	}
+	for _, tt := range tests {
+		rule := mustNew(t, tt.field, tt.matchType, tt.pattern, ActionHide, "")
+		if got := rule.Match(post); got != tt.want {
+			t.Errorf("%s %s %q: Match = %v, want %v", tt.field, tt.matchType, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestMatchEmptyField(t *testing.T) {
+	rule := mustNew(t, FieldAuthor, MatchRegex, ".*", ActionHide, "")
+	if rule.Match(Post{Title: "no author"}) {
+		t.Error("empty author matched")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := []Rule{
+		mustNew(t, FieldTitle, MatchContains, "sponsored", ActionHide, ""),
+		mustNew(t, FieldTitle, MatchContains, "go", ActionTag, "golang"),
+		mustNew(t, FieldAuthor, MatchContains, "go team", ActionTag, "golang"),
+		mustNew(t, FieldAuthor, MatchContains, "go team", ActionStar, ""),
+		mustNew(t, FieldContent, MatchContains, "security", ActionTag, "security"),
+	}
+	tests := []struct {
+		name string
+		post Post
+		want Result
+	}{
+		{
+			name: "no match",
+			post: Post{Title: "Rust news"},
+			want: Result{},
+		},
+		{
+			name: "duplicate tags merged",
+			post: Post{Title: "Go 1.24", Author: "The Go Team", Content: "security fixes"},
+			want: Result{Star: true, Tags: []string{"golang", "security"}},
+		},
+		{
+			name: "hide",
+			post: Post{Title: "Sponsored: learn Go"},
+			want: Result{Hide: true, Tags: []string{"golang"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(rules, tt.post)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate = %+v, want %+v", got, tt.want)
+			}
+			if got.Empty() != reflect.DeepEqual(tt.want, Result{}) {
+				t.Errorf("Empty = %v", got.Empty())
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package rules
+
+import (
+	"context"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+// FromDB 编译数据库中保存的规则
+func FromDB(rule db.Rule) (Rule, error) {
+	return New(rule.Field, rule.MatchType, rule.Pattern, rule.Action, rule.Tag.String)
+}
+
+// Save 把命中的动作写入用户的文章状态
+func Save(ctx context.Context, query *db.Queries, userID, postID uuid.UUID, result Result) error {
+	tags := result.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return query.ApplyRuleActions(ctx, db.ApplyRuleActionsParams{
+		UserID:   userID,
+		PostID:   postID,
+		MarkRead: result.Read,
+		Star:     result.Star,
+		Hide:     result.Hide,
+		Tags:     tags,
+	})
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// PlainText 去掉 HTML 标签和实体，并合并连续空白
+func PlainText(s string) string {
+	s = html.UnescapeString(tagPattern.ReplaceAllString(s, " "))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// isCJK 判断字符是否属于需要按二元切分的中日韩文字
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Words 将文本切分为检索词：
+// 非 CJK 文本按字母数字连续段切分并转为小写；
+// 连续的 CJK 字符切分为重叠的二元组（"中文搜索" => 中文 文搜 搜索），单个字符保持原样。
+// 文档和查询使用同一套切分规则，短语在两边得到相同的相邻位置。
+func Words(s string) []string {
+	var words []string
+	var latin []rune
+	var cjk []rune
+	flushLatin := func() {
+		if len(latin) > 0 {
+			words = append(words, string(latin))
+			latin = latin[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			words = append(words, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				words = append(words, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range s {
+		switch {
+		case isCJK(r):
+			flushLatin()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			flushCJK()
+			latin = append(latin, unicode.ToLower(r))
+		default:
+			flushLatin()
+			flushCJK()
+		}
+	}
+	flushLatin()
+	flushCJK()
+	return words
+}
+
+// Tokenize 生成写入 posts.search_title/search_body 的检索表示，
+// 由 'simple' 配置的 to_tsvector 按空格再次切分
+func Tokenize(s string) string {
+	return strings.Join(Words(s), " ")
+}
+
+// isSingleCJK 判断检索词是否为单个 CJK 字符，这类词在文档中只会出现在二元组里，需要前缀匹配
+func isSingleCJK(word string) bool {
+	runes := []rune(word)
+	return len(runes) == 1 && isCJK(runes[0])
+}
@@ -0,0 +1,92 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"中文搜索", []string{"中文", "文搜", "搜索"}},
+		{"中", []string{"中"}},
+		{"Go语言 入门", []string{"go", "语言", "入门"}},
+		{"日本語のテキスト", []string{"日本", "本語", "語の", "のテ", "テキ", "キス", "スト"}},
+		{"한국어", []string{"한국", "국어"}},
+		{"中文，搜索", []string{"中文", "搜索"}},
+		{"ÀB 12", []string{"àb", "12"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Words(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Words(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	if got, want := Tokenize("学习Go语言"), "学习 go 语言"; got != want {
+		t.Errorf("Tokenize = %q, want %q", got, want)
+	}
+}
+
+func TestParseQueryCJK(t *testing.T) {
+	tests := []struct {
+		input   string
+		tsquery string
+	}{
+		// 查询与文档使用相同的二元切分，短语按相邻位置匹配
+		{"中文搜索", "('中文' <-> '文搜' <-> '搜索')"},
+		{`"中文"`, "'中文'"},
+		// 单个字符只出现在文档的二元组中，按前缀匹配
+		{"中", "'中':*"},
+		{"Go语言", "('go' <-> '语言')"},
+		{"编程*", "'编程':*"},
+		{"中文 -日语", "('中文' & !'日语')"},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.input)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q) error: %v", tt.input, err)
+		}
+		if q.TSQuery != tt.tsquery {
+			t.Errorf("ParseQuery(%q) = %q, want %q", tt.input, q.TSQuery, tt.tsquery)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text  string
+		terms []string
+		want  string
+	}{
+		{"Go & rss GO", []string{"go"}, "<mark>Go</mark> &amp; rss <mark>GO</mark>"},
+		{"<b>rss</b>", []string{"rss"}, "&lt;b&gt;<mark>rss</mark>&lt;/b&gt;"},
+		{"学习中文搜索", []string{"中文"}, "学习<mark>中文</mark>搜索"},
+		{"nothing here", []string{"go"}, "nothing here"},
+	}
+	for _, tt := range tests {
+		if got := Highlight(tt.text, tt.terms); got != tt.want {
+			t.Errorf("Highlight(%q, %q) = %q, want %q", tt.text, tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := "aaaaaaaaaa bbbbbbbbbb target cccccccccc dddddddddd"
+	got := Snippet(text, []string{"target"}, 20)
+	want := "…bbbb <mark>target</mark> cccccccc…"
+	if got != want {
+		t.Errorf("Snippet = %q, want %q", got, want)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	if got, want := PlainText("<p>a &amp; <b>b</b></p>\n c"), "a & b c"; got != want {
+		t.Errorf("PlainText = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	markStart = "<mark>"
+	markEnd   = "</mark>"
+)
+
+// Highlight 对纯文本中出现的检索词加 <mark> 标记，其余内容做 HTML 转义
+func Highlight(text string, terms []string) string {
+	return highlightRunes([]rune(text), terms)
+}
+
+// Snippet 从纯文本中截取首个命中位置附近约 maxRunes 个字符的片段并高亮
+func Snippet(text string, terms []string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return highlightRunes(runes, terms)
+	}
+	start := 0
+	if ranges := matchRanges(runes, terms); len(ranges) > 0 {
+		start = max(0, ranges[0][0]-maxRunes/4)
+	}
+	end := min(len(runes), start+maxRunes)
+	start = max(0, end-maxRunes)
+
+	snippet := highlightRunes(runes[start:end], terms)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func highlightRunes(runes []rune, terms []string) string {
+	var b strings.Builder
+	last := 0
+	for _, rg := range matchRanges(runes, terms) {
+		b.WriteString(html.EscapeString(string(runes[last:rg[0]])))
+		b.WriteString(markStart)
+		b.WriteString(html.EscapeString(string(runes[rg[0]:rg[1]])))
+		b.WriteString(markEnd)
+		last = rg[1]
+	}
+	b.WriteString(html.EscapeString(string(runes[last:])))
+	return b.String()
+}
+
+// matchRanges 返回检索词在文本中不重叠的命中区间（忽略大小写），按位置排序
+func matchRanges(runes []rune, terms []string) [][2]int {
+	lower := toLower(runes)
+	needles := make([][]rune, 0, len(terms))
+	for _, t := range terms {
+		if needle := toLower([]rune(strings.TrimSpace(t))); len(needle) > 0 {
+			needles = append(needles, needle)
+		}
+	}
+
+	var ranges [][2]int
+	for i := 0; i < len(lower); {
+		matched := 0
+		for _, needle := range needles {
+			if len(needle) > matched && hasPrefixAt(lower, i, needle) {
+				matched = len(needle)
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		ranges = append(ranges, [2]int{i, i + matched})
+		i += matched
+	}
+	return ranges
+}
+
+// toLower 逐字符转小写，保证与原文的字符位置一一对应
+func toLower(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+func hasPrefixAt(s []rune, at int, prefix []rune) bool {
+	if at+len(prefix) > len(s) {
+		return false
+	}
+	for j, r := range prefix {
+		if s[at+j] != r {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,240 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrEmptyQuery 表示查询中没有任何可检索的词
+var ErrEmptyQuery = errors.New("empty search query")
+
+// Query 是解析后的搜索条件
+type Query struct {
+	// TSQuery 传给 to_tsquery('simple', ...) 的表达式
+	TSQuery string
+	// Terms 用于高亮的正向检索词（不含 NOT 排除的词）
+	Terms []string
+}
+
+// ParseQuery 将用户输入的搜索语法转换为 PostgreSQL to_tsquery 表达式
+// 中文等 CJK 文本按 Words 的规则切分为二元组短语，与入库时的检索表示一致
+// 支持的语法：
+//   - 多个词默认 AND：go rss
+//   - 短语："go rss"
+//   - 前缀匹配：feed*
+//   - 布尔运算：OR / |、AND / &、NOT / - / !，以及括号分组
+func ParseQuery(input string) (Query, error) {
+	p := &parser{tokens: lex(input)}
+	var parts []string
+	for p.pos < len(p.tokens) {
+		if expr := p.parseOr(); expr != "" {
+			parts = append(parts, expr)
+		}
+		// 忽略多余的右括号
+		if tok, ok := p.peek(); ok && tok.kind == tokenRParen {
+			p.pos++
+		}
+	}
+	expr := join(parts, " & ")
+	if expr == "" {
+		return Query{}, ErrEmptyQuery
+	}
+	return Query{TSQuery: expr, Terms: p.terms}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenPhrase
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	words  []string
+	prefix bool
+}
+
+func lex(input string) []token {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen})
+			i++
+		case r == '|':
+			tokens = append(tokens, token{kind: tokenOr})
+			i++
+		case r == '&':
+			tokens = append(tokens, token{kind: tokenAnd})
+			i++
+		case r == '-' || r == '!':
+			tokens = append(tokens, token{kind: tokenNot})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			text := string(runes[i+1 : min(end, len(runes))])
+			tokens = append(tokens, token{kind: tokenPhrase, text: text, words: Words(text)})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()|&"`, runes[end]) {
+				end++
+			}
+			word := string(runes[i:end])
+			i = end
+			switch word {
+			case "OR":
+				tokens = append(tokens, token{kind: tokenOr})
+				continue
+			case "AND":
+				tokens = append(tokens, token{kind: tokenAnd})
+				continue
+			case "NOT":
+				tokens = append(tokens, token{kind: tokenNot})
+				continue
+			}
+			text := strings.TrimRight(word, "*")
+			tokens = append(tokens, token{
+				kind:   tokenTerm,
+				text:   text,
+				words:  Words(text),
+				prefix: strings.HasSuffix(word, "*"),
+			})
+		}
+	}
+	return tokens
+}
+
+type parser struct {
+	tokens  []token
+	pos     int
+	negated int
+	terms   []string
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() string {
+	var parts []string
+	for {
+		if expr := p.parseAnd(); expr != "" {
+			parts = append(parts, expr)
+		}
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokenOr {
+			break
+		}
+		p.pos++
+	}
+	return join(parts, " | ")
+}
+
+func (p *parser) parseAnd() string {
+	var parts []string
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokenOr || tok.kind == tokenRParen {
+			break
+		}
+		if tok.kind == tokenAnd {
+			p.pos++
+			continue
+		}
+		if expr := p.parseUnary(); expr != "" {
+			parts = append(parts, expr)
+		}
+	}
+	return join(parts, " & ")
+}
+
+func (p *parser) parseUnary() string {
+	tok, _ := p.peek()
+	if tok.kind == tokenNot {
+		p.pos++
+		p.negated++
+		defer func() { p.negated-- }()
+		if expr := p.parseUnary(); expr != "" {
+			return "!" + expr
+		}
+		return ""
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() string {
+	tok, ok := p.peek()
+	if !ok {
+		return ""
+	}
+	p.pos++
+	switch tok.kind {
+	case tokenLParen:
+		expr := p.parseOr()
+		if next, ok := p.peek(); ok && next.kind == tokenRParen {
+			p.pos++
+		}
+		return expr
+	case tokenPhrase, tokenTerm:
+		expr := phrase(tok.words, tok.prefix)
+		if expr != "" && p.negated%2 == 0 {
+			p.terms = append(p.terms, tok.text)
+		}
+		return expr
+	}
+	return ""
+}
+
+// phrase 将多个词用 <-> 连接为相邻匹配，prefix 作用于最后一个词
+// 单个 CJK 字符在文档中只存在于二元组内，总是按前缀匹配
+func phrase(words []string, prefix bool) string {
+	if len(words) == 0 {
+		return ""
+	}
+	lexemes := make([]string, len(words))
+	for i, w := range words {
+		lexemes[i] = "'" + w + "'"
+		if isSingleCJK(w) {
+			lexemes[i] += ":*"
+		}
+	}
+	if prefix && !strings.HasSuffix(lexemes[len(lexemes)-1], ":*") {
+		lexemes[len(lexemes)-1] += ":*"
+	}
+	if len(lexemes) == 1 {
+		return lexemes[0]
+	}
+	return "(" + strings.Join(lexemes, " <-> ") + ")"
+}
+
+func join(parts []string, op string) string {
+	switch len(parts) {
+	case 0:
+		return ""
+	case 1:
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, op) + ")"
+}
@@ -0,0 +1,51 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		input   string
+		tsquery string
+		terms   []string
+	}{
+		{"go rss", "('go' & 'rss')", []string{"go", "rss"}},
+		{`"go rss"`, "('go' <-> 'rss')", []string{"go rss"}},
+		{"feed*", "'feed':*", []string{"feed"}},
+		{"go OR rss", "('go' | 'rss')", []string{"go", "rss"}},
+		{"go | rss", "('go' | 'rss')", []string{"go", "rss"}},
+		{"go -rss", "('go' & !'rss')", []string{"go"}},
+		{"go AND (rss | atom)", "('go' & ('rss' | 'atom'))", []string{"go", "rss", "atom"}},
+		{"NOT go", "!'go'", nil},
+		{"!(a b)", "!('a' & 'b')", nil},
+		// 多余的右括号被忽略
+		{"a ) b", "('a' & 'b')", []string{"a", "b"}},
+		// 标点不进入检索词，也不会破坏 tsquery 语法
+		{"C++ it's", "('c' & ('it' <-> 's'))", []string{"C++", "it's"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			q, err := ParseQuery(tt.input)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error: %v", tt.input, err)
+			}
+			if q.TSQuery != tt.tsquery {
+				t.Errorf("TSQuery = %q, want %q", q.TSQuery, tt.tsquery)
+			}
+			if !reflect.DeepEqual(q.Terms, tt.terms) {
+				t.Errorf("Terms = %q, want %q", q.Terms, tt.terms)
+			}
+		})
+	}
+}
+
+func TestParseQueryEmpty(t *testing.T) {
+	for _, input := range []string{"", "   ", "()", "-", `""`, "&& ||"} {
+		if _, err := ParseQuery(input); !errors.Is(err, ErrEmptyQuery) {
+			t.Errorf("ParseQuery(%q) error = %v, want ErrEmptyQuery", input, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package sessions
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// AccessTokenTTL 访问令牌有效期，过期后使用刷新令牌换取新令牌
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL 刷新令牌有效期，每次刷新后重新计算
+	RefreshTokenTTL = 30 * 24 * time.Hour
+	// RefreshGracePeriod 刷新令牌轮换后，旧令牌在这段时间内再次使用视为并发刷新而不是泄露
+	RefreshGracePeriod = 30 * time.Second
+)
+
+var ErrInvalidToken = errors.New("invalid or expired access token")
+
+// jwtHeader 固定使用 HS256
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims 是访问令牌携带的信息
+type Claims struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	SessionID string `json:"sid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// IsAccessToken 判断令牌是否为 JWT 格式，用于与 API Key 区分
+func IsAccessToken(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Sign 签发 HS256 JWT 访问令牌
+func Sign(secret []byte, userID, sessionID uuid.UUID, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(AccessTokenTTL)
+	payload, err := json.Marshal(jwtClaims{
+		Subject:   userID.String(),
+		SessionID: sessionID.String(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signature(secret, unsigned), expiresAt, nil
+}
+
+// Verify 校验签名和有效期，不检查会话是否已被吊销
+func Verify(secret []byte, token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return Claims{}, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(signature(secret, parts[0]+"."+parts[1]))) {
+		return Claims{}, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	var c jwtClaims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	if now.Unix() >= c.ExpiresAt {
+		return Claims{}, ErrInvalidToken
+	}
+	userID, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	sessionID, err := uuid.Parse(c.SessionID)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	return Claims{UserID: userID, SessionID: sessionID, ExpiresAt: time.Unix(c.ExpiresAt, 0)}, nil
+}
+
+// NewRefreshToken 生成随机刷新令牌及其哈希
+func NewRefreshToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// NextRefreshToken 由当前刷新令牌派生轮换后的新令牌及其哈希
+// 同一个令牌的并发刷新会得到相同的新令牌，服务器可以在宽限期内把已轮换的结果再返回一次
+func NextRefreshToken(secret []byte, token string) (next, hash string) {
+	next = signature(secret, "refresh."+HashToken(token))
+	return next, HashToken(next)
+}
+
+// HashToken 计算刷新令牌的 SHA-256 哈希
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CSRFToken 由刷新令牌派生 CSRF 令牌，刷新令牌轮换时随之变化，无需额外存储
+func CSRFToken(secret []byte, refreshToken string) string {
+	return signature(secret, "csrf."+HashToken(refreshToken))
+}
+
+func signature(secret []byte, data string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,123 @@
+package sessions
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var testSecret = []byte("test secret")
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	userID, sessionID := uuid.New(), uuid.New()
+	token, expiresAt, err := Sign(testSecret, userID, sessionID, now)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if !expiresAt.Equal(now.Add(AccessTokenTTL)) {
+		t.Errorf("expiresAt = %v, want %v", expiresAt, now.Add(AccessTokenTTL))
+	}
+	if !IsAccessToken(token) {
+		t.Errorf("IsAccessToken(%q) = false", token)
+	}
+	parts := strings.Split(token, ".")
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"`+uuid.NewString()+`","sid":"`+sessionID.String()+`","exp":9999999999}`)) + "." + parts[2]
+
+	tests := []struct {
+		name    string
+		secret  []byte
+		token   string
+		now     time.Time
+		wantErr bool
+	}{
+		{"valid", testSecret, token, now, false},
+		{"just before expiry", testSecret, token, now.Add(AccessTokenTTL - time.Second), false},
+		{"expired", testSecret, token, now.Add(AccessTokenTTL), true},
+		{"wrong secret", []byte("other secret"), token, now, true},
+		{"tampered payload", testSecret, forged, now, true},
+		{"truncated signature", testSecret, token[:len(token)-2], now, true},
+		{"alg none", testSecret, base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + ".", now, true},
+		{"not a jwt", testSecret, "rss_0123456789", now, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := Verify(tt.secret, tt.token, tt.now)
+			if tt.wantErr {
+				if err != ErrInvalidToken {
+					t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if claims.UserID != userID || claims.SessionID != sessionID || !claims.ExpiresAt.Equal(expiresAt) {
+				t.Errorf("Verify() = %+v, want user %s, session %s, expires %v", claims, userID, sessionID, expiresAt)
+			}
+		})
+	}
+}
+
+func TestIsAccessToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  bool
+	}{
+		{"a.b.c", true},
+		{"rss_0123456789abcdef", false},
+		{"a.b", false},
+		{"a.b.c.d", false},
+	}
+	for _, tt := range tests {
+		if got := IsAccessToken(tt.token); got != tt.want {
+			t.Errorf("IsAccessToken(%q) = %v, want %v", tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestNextRefreshToken(t *testing.T) {
+	token, hash, err := NewRefreshToken()
+	if err != nil {
+		t.Fatalf("NewRefreshToken() error = %v", err)
+	}
+	if hash != HashToken(token) {
+		t.Errorf("NewRefreshToken() hash = %q, want HashToken(token)", hash)
+	}
+
+	// 并发刷新的请求必须得到同一个新令牌，宽限期内的重复刷新才能返回相同的结果
+	next, nextHash := NextRefreshToken(testSecret, token)
+	if again, againHash := NextRefreshToken(testSecret, token); again != next || againHash != nextHash {
+		t.Errorf("NextRefreshToken() = %q, then %q, want the same token", next, again)
+	}
+	if nextHash != HashToken(next) {
+		t.Errorf("NextRefreshToken() hash = %q, want HashToken(next)", nextHash)
+	}
+	if next == token {
+		t.Errorf("NextRefreshToken() returned the original token")
+	}
+	if third, _ := NextRefreshToken(testSecret, next); third == next || third == token {
+		t.Errorf("NextRefreshToken(next) = %q, want a new token", third)
+	}
+	// 不知道密钥就无法从泄露的旧令牌推算出新令牌
+	if other, _ := NextRefreshToken([]byte("other secret"), token); other == next {
+		t.Errorf("NextRefreshToken() does not depend on the secret")
+	}
+}
+
+func TestCSRFToken(t *testing.T) {
+	token := CSRFToken(testSecret, "refresh-a")
+	if got := CSRFToken(testSecret, "refresh-a"); got != token {
+		t.Errorf("CSRFToken() = %q, then %q, want a stable token", token, got)
+	}
+	// 刷新令牌轮换后旧的 CSRF 令牌随之失效
+	if got := CSRFToken(testSecret, "refresh-b"); got == token {
+		t.Errorf("CSRFToken() for a rotated refresh token = %q, want a different token", got)
+	}
+	if got := CSRFToken([]byte("other secret"), "refresh-a"); got == token {
+		t.Errorf("CSRFToken() with another secret = %q, want a different token", got)
+	}
+}
@@ -0,0 +1,85 @@
+-- name: AdminListUsers :many
+-- 按用户名、显示名称或邮箱搜索，可按角色和是否停用筛选
+SELECT u.*,
+  (SELECT COUNT(*) FROM feeds f WHERE f.user_id = u.id) AS feeds_count,
+  (SELECT COUNT(*) FROM feed_follows ff WHERE ff.user_id = u.id) AS follows_count,
+  (SELECT MAX(s.last_used_at) FROM sessions s WHERE s.user_id = u.id)::timestamptz AS last_seen_at,
+  COUNT(*) OVER () AS total_count
+FROM users u
+WHERE (sqlc.narg(query)::text IS NULL
+    OR strpos(lower(u.username), lower(sqlc.narg(query)::text)) > 0
+    OR strpos(lower(u.display_name), lower(sqlc.narg(query)::text)) > 0
+    OR strpos(lower(u.email), lower(sqlc.narg(query)::text)) > 0)
+  AND (sqlc.narg(role)::text IS NULL OR u.role = sqlc.narg(role)::text)
+  AND (sqlc.narg(disabled)::boolean IS NULL OR (u.disabled_at IS NOT NULL) = sqlc.narg(disabled)::boolean)
+ORDER BY u.created_at DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: SetUserDisabled :one
+UPDATE users
+SET disabled_at = @disabled_at, updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- name: AdminListFeeds :many
+-- 所有用户的订阅源，health 与 rss.FeedHealth 的判断一致
+SELECT f.*, u.username AS owner_username,
+  (SELECT COUNT(*) FROM feed_follows ff WHERE ff.feed_id = f.id) AS follows_count,
+  COUNT(*) OVER () AS total_count
+FROM feeds f
+JOIN users u ON u.id = f.user_id
+WHERE (sqlc.narg(query)::text IS NULL
+    OR strpos(lower(f.name), lower(sqlc.narg(query)::text)) > 0
+    OR strpos(lower(f.url), lower(sqlc.narg(query)::text)) > 0)
+  AND (sqlc.narg(owner_id)::uuid IS NULL OR f.user_id = sqlc.narg(owner_id)::uuid)
+  AND (sqlc.narg(health)::text IS NULL OR sqlc.narg(health)::text = CASE
+    WHEN f.last_fetched_at IS NULL THEN 'pending'
+    WHEN f.fetch_error_count >= @dead_error_count THEN 'dead'
+    WHEN f.fetch_error_count > 0 THEN 'failing'
+    ELSE 'healthy' END)
+ORDER BY f.fetch_error_count DESC, f.created_at DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: QueueFeedsForRefetch :execrows
+-- 记录排队时间，抓取器下一轮优先抓取，抓取时间和健康状态保持不变；health 为空时全部重新抓取
+UPDATE feeds f
+SET refetch_requested_at = COALESCE(f.refetch_requested_at, NOW())
+WHERE sqlc.narg(health)::text IS NULL OR sqlc.narg(health)::text = CASE
+  WHEN f.last_fetched_at IS NULL THEN 'pending'
+  WHEN f.fetch_error_count >= @dead_error_count THEN 'dead'
+  WHEN f.fetch_error_count > 0 THEN 'failing'
+  ELSE 'healthy' END;
+
+-- name: QueueFeedForRefetch :one
+-- 单个订阅源排队重新抓取，已在排队时保留原来的排队时间
+UPDATE feeds
+SET refetch_requested_at = COALESCE(refetch_requested_at, NOW())
+WHERE id = $1
+RETURNING *;
+
+-- name: GetScraperHealth :one
+SELECT
+  COUNT(*) FILTER (WHERE last_fetched_at IS NULL) AS pending,
+  COUNT(*) FILTER (WHERE last_fetched_at IS NOT NULL AND fetch_error_count = 0) AS healthy,
+  COUNT(*) FILTER (WHERE last_fetched_at IS NOT NULL AND fetch_error_count > 0 AND fetch_error_count < @dead_error_count) AS failing,
+  COUNT(*) FILTER (WHERE last_fetched_at IS NOT NULL AND fetch_error_count >= @dead_error_count) AS dead,
+  COUNT(*) FILTER (WHERE last_fetched_at > @since) AS fetched_recently,
+  MIN(last_fetched_at)::timestamptz AS oldest_fetched_at,
+  MAX(last_fetched_at)::timestamptz AS newest_fetched_at
+FROM feeds;
+
+-- name: GetSystemStats :one
+SELECT
+  (SELECT COUNT(*) FROM users) AS users,
+  (SELECT COUNT(*) FROM users WHERE disabled_at IS NOT NULL) AS disabled_users,
+  (SELECT COUNT(*) FROM users WHERE role = 'admin') AS admins,
+  (SELECT COUNT(*) FROM users WHERE created_at > @since) AS new_users,
+  (SELECT COUNT(DISTINCT user_id) FROM sessions WHERE last_used_at > @since) AS active_users,
+  (SELECT COUNT(*) FROM feeds) AS feeds,
+  (SELECT COUNT(*) FROM feed_follows) AS follows,
+  (SELECT COUNT(*) FROM posts) AS posts,
+  (SELECT COUNT(*) FROM posts WHERE created_at > @since) AS new_posts,
+  (SELECT COUNT(*) FROM sessions WHERE revoked_at IS NULL AND expires_at > NOW()) AS active_sessions,
+  (SELECT COUNT(*) FROM api_keys) AS api_keys,
+  (SELECT COUNT(*) FROM webhooks) AS webhooks,
+  (SELECT COUNT(*) FROM webhook_deliveries WHERE status = 'pending') AS pending_deliveries;
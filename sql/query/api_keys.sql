@@ -0,0 +1,37 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scope, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetAPIKeysByUserID :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteAPIKey :execrows
+DELETE FROM api_keys
+WHERE id = $1 AND user_id = $2;
+
+-- name: UseAPIKey :one
+-- 认证时按哈希查找未过期的 API Key，并记录最近使用时间
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE key_hash = $1 AND (expires_at IS NULL OR expires_at > NOW())
+RETURNING *;
+
+-- name: DeleteAPIKeysByUserID :many
+-- 修改密码后吊销用户的全部 API Key，返回被删除的 Key 用于审计
+DELETE FROM api_keys
+WHERE user_id = $1
+RETURNING *;
+
+-- name: PruneAPIKeysByName :many
+-- 同名 API Key 只保留最近使用（未使用过的按创建时间）的 keep 个，返回被删除的 Key
+DELETE FROM api_keys
+WHERE user_id = $1 AND name = $2 AND id NOT IN (
+    SELECT id FROM api_keys
+    WHERE user_id = $1 AND name = $2
+    ORDER BY COALESCE(last_used_at, created_at) DESC
+    LIMIT $3
+)
+RETURNING *;
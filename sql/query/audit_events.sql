@@ -0,0 +1,29 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (
+  id,
+  actor_id,
+  actor_username,
+  action,
+  target_type,
+  target_id,
+  details,
+  ip,
+  user_agent,
+  created_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: ListAuditEvents :many
+-- action 可以是完整的动作名或前缀（admin 匹配所有 admin.* 动作）；subject_id 匹配该用户执行的以及以该用户为目标的操作
+SELECT *, COUNT(*) OVER () AS total_count FROM audit_events
+WHERE (sqlc.narg(actor_id)::uuid IS NULL OR actor_id = sqlc.narg(actor_id)::uuid)
+  AND (sqlc.narg(subject_id)::uuid IS NULL OR actor_id = sqlc.narg(subject_id)::uuid
+    OR (target_type = 'user' AND target_id = sqlc.narg(subject_id)::uuid))
+  AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action)::text
+    OR action LIKE sqlc.narg(action)::text || '.%')
+  AND (sqlc.narg(target_type)::text IS NULL OR target_type = sqlc.narg(target_type)::text)
+  AND (sqlc.narg(target_id)::uuid IS NULL OR target_id = sqlc.narg(target_id)::uuid)
+  AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since)::timestamptz)
+  AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until)::timestamptz)
+ORDER BY created_at DESC
+LIMIT @page_limit OFFSET @page_offset;
@@ -0,0 +1,78 @@
+-- name: UpsertDigestSettings :one
+-- 退订令牌只在首次创建时生成
+INSERT INTO digest_settings (
+  user_id,
+  email,
+  frequency,
+  send_hour,
+  weekday,
+  timezone,
+  folder_ids,
+  enabled,
+  unsubscribe_token,
+  next_send_at,
+  created_at,
+  updated_at
+)
+VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW()
+)
+ON CONFLICT (user_id) DO UPDATE
+SET email = EXCLUDED.email,
+  frequency = EXCLUDED.frequency,
+  send_hour = EXCLUDED.send_hour,
+  weekday = EXCLUDED.weekday,
+  timezone = EXCLUDED.timezone,
+  folder_ids = EXCLUDED.folder_ids,
+  enabled = EXCLUDED.enabled,
+  next_send_at = EXCLUDED.next_send_at,
+  updated_at = NOW()
+RETURNING *;
+
+-- name: GetDigestSettings :one
+SELECT * FROM digest_settings
+WHERE user_id = $1;
+
+-- name: DeleteDigestSettings :execrows
+DELETE FROM digest_settings
+WHERE user_id = $1;
+
+-- name: GetDueDigestSettings :many
+SELECT * FROM digest_settings
+WHERE enabled AND next_send_at <= NOW()
+ORDER BY next_send_at ASC
+LIMIT $1;
+
+-- name: MarkDigestSent :exec
+UPDATE digest_settings
+SET last_sent_at = $2, next_send_at = $3, updated_at = NOW()
+WHERE user_id = $1;
+
+-- name: PostponeDigest :exec
+-- 发送失败时推迟重试，不更新 last_sent_at
+UPDATE digest_settings
+SET next_send_at = $2, updated_at = NOW()
+WHERE user_id = $1;
+
+-- name: UnsubscribeDigest :execrows
+UPDATE digest_settings
+SET enabled = FALSE, updated_at = NOW()
+WHERE unsubscribe_token = $1;
+
+-- name: GetDigestPosts :many
+-- 摘要内容：since 之后入库、仍未读且未隐藏的文章，folder_ids 为空时不限文件夹
+SELECT p.id, p.title, p.url, p.description, p.published_at, feeds.name AS feed_name FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+JOIN feeds ON p.feed_id = feeds.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE uf.user_id = @user_id
+  AND p.created_at > @since
+  AND ps.read_at IS NULL
+  AND ps.hidden_at IS NULL
+  AND (cardinality(@folder_ids::uuid[]) = 0 OR EXISTS (
+    SELECT 1 FROM folder_feeds fd
+    JOIN feed_follows ff ON ff.id = fd.feed_follow_id
+    WHERE ff.user_id = uf.user_id AND ff.feed_id = p.feed_id AND fd.folder_id = ANY(@folder_ids::uuid[])
+  ))
+ORDER BY feeds.name ASC, p.published_at DESC
+LIMIT @page_limit;
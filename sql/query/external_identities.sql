@@ -0,0 +1,20 @@
+-- name: GetUserByExternalIdentity :one
+SELECT u.* FROM external_identities e
+JOIN users u ON u.id = e.user_id
+WHERE e.issuer = @issuer AND e.subject = @subject;
+
+-- name: CreateExternalIdentity :one
+INSERT INTO external_identities (id, user_id, issuer, subject, email, created_at, last_login_at)
+VALUES (@id, @user_id, @issuer, @subject, @email, @created_at, @created_at)
+RETURNING *;
+
+-- name: TouchExternalIdentity :exec
+-- 每次登录更新身份提供方返回的邮箱和登录时间
+UPDATE external_identities
+SET email = @email, last_login_at = @last_login_at
+WHERE issuer = @issuer AND subject = @subject;
+
+-- name: GetExternalIdentitiesByUserID :many
+SELECT * FROM external_identities
+WHERE user_id = $1
+ORDER BY created_at;
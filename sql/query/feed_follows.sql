@@ -24,3 +24,30 @@ SELECT ff.*, feeds.name as feed_name, feeds.url as feed_url FROM feed_follows ff
 JOIN feeds ON ff.feed_id = feeds.id
 WHERE ff.user_id = $1
 ORDER BY created_at DESC;
+
+-- name: UpsertFeedFollow :one
+-- 已关注时返回原有记录
+INSERT INTO feed_follows (
+  id,
+  created_at,
+  updated_at,
+  user_id,
+  feed_id
+)
+VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, feed_id) DO UPDATE SET updated_at = feed_follows.updated_at
+RETURNING *;
+
+-- name: GetNextFeedOwners :many
+-- 所有者删除仍有人关注的订阅源时的接手候选：先是按关注时间排列的其他用户，再是关注该订阅源的团队的 owner
+SELECT user_id FROM (
+  SELECT ff.user_id, 0 AS priority, ff.created_at AS followed_at, ff.created_at AS joined_at FROM feed_follows ff
+  WHERE ff.feed_id = $1 AND ff.user_id <> $2
+  UNION ALL
+  SELECT tm.user_id, 1 AS priority, tf.created_at AS followed_at, tm.created_at AS joined_at FROM team_follows tf
+  JOIN team_members tm ON tm.team_id = tf.team_id AND tm.role = 'owner'
+  WHERE tf.feed_id = $1 AND tm.user_id <> $2
+) candidates
+ORDER BY priority ASC, followed_at ASC, joined_at ASC;
@@ -0,0 +1,30 @@
+-- name: CreateFeedTransfer :one
+-- 同一订阅源的新请求覆盖旧请求
+INSERT INTO feed_transfers (feed_id, from_user_id, to_user_id, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (feed_id) DO UPDATE SET
+  from_user_id = EXCLUDED.from_user_id,
+  to_user_id = EXCLUDED.to_user_id,
+  created_at = EXCLUDED.created_at
+RETURNING *;
+
+-- name: GetIncomingFeedTransfers :many
+-- 发给用户且发起人仍是所有者的转让请求
+SELECT t.*, f.name AS feed_name, f.url AS feed_url, u.username AS from_username
+FROM feed_transfers t
+JOIN feeds f ON f.id = t.feed_id AND f.user_id = t.from_user_id
+JOIN users u ON u.id = t.from_user_id
+WHERE t.to_user_id = $1
+ORDER BY t.created_at DESC;
+
+-- name: TakeFeedTransfer :one
+-- 接受转让时删除请求并锁定订阅源，发起人已不是所有者时没有结果
+DELETE FROM feed_transfers t
+WHERE t.feed_id = $1 AND t.to_user_id = $2
+  AND t.from_user_id = (SELECT f.user_id FROM feeds f WHERE f.id = t.feed_id FOR UPDATE)
+RETURNING *;
+
+-- name: DeleteFeedTransfer :execrows
+-- 发起人撤回或接收人拒绝
+DELETE FROM feed_transfers
+WHERE feed_id = @feed_id AND (from_user_id = @user_id OR to_user_id = @user_id);
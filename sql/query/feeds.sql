@@ -5,18 +5,41 @@ INSERT INTO feeds (
   url,
   created_at,
   updated_at,
-  user_id
+  user_id,
+  category
 )
 VALUES (
-  $1, $2, $3, $4, $5, $6
+  $1, $2, $3, $4, $5, $6, $7
 )
 RETURNING *;
 
--- name: GetAllFeeds :many
-SELECT f.*, COUNT(ff.feed_id) AS follows_count FROM feeds f
-LEFT JOIN feed_follows ff ON f.id = ff.feed_id
-GROUP BY f.id
-ORDER BY follows_count DESC, created_at DESC;
+-- name: ListFeeds :many
+-- 订阅源广场：支持关键字、语言、分类筛选和排序（popular 按关注数，newest 按创建时间，active 按最近发文）
+SELECT f.*, fc.follows_count, lp.last_post_at,
+  EXISTS (
+    SELECT 1 FROM user_feeds uf
+    WHERE uf.feed_id = f.id AND uf.user_id = sqlc.narg(user_id)
+  ) AS is_following,
+  COUNT(*) OVER () AS total_count
+FROM feeds f
+LEFT JOIN LATERAL (
+  SELECT COUNT(*) AS follows_count FROM feed_follows WHERE feed_id = f.id
+) fc ON true
+LEFT JOIN LATERAL (
+  SELECT MAX(published_at)::timestamptz AS last_post_at FROM posts WHERE feed_id = f.id
+) lp ON true
+WHERE (sqlc.narg(query)::text IS NULL
+    OR strpos(lower(f.name), lower(sqlc.narg(query)::text)) > 0
+    OR strpos(lower(f.url), lower(sqlc.narg(query)::text)) > 0
+    OR strpos(lower(f.description), lower(sqlc.narg(query)::text)) > 0)
+  AND (sqlc.narg(language)::text IS NULL OR starts_with(lower(f.language), lower(sqlc.narg(language)::text)))
+  AND (sqlc.narg(category)::text IS NULL OR f.category = sqlc.narg(category)::text)
+ORDER BY
+  CASE WHEN @sort::text = 'active' THEN lp.last_post_at END DESC NULLS LAST,
+  CASE WHEN @sort::text = 'newest' THEN f.created_at END DESC,
+  fc.follows_count DESC,
+  f.created_at DESC
+LIMIT @page_limit OFFSET @page_offset;
 
 -- name: GetFeedsByUserID :many
 SELECT * FROM feeds
@@ -24,12 +47,91 @@ WHERE user_id = $1
 ORDER BY created_at ASC;
 
 -- name: GetNextFeedsToFetch :many
+-- 管理员排队重新抓取的订阅源优先，其余按上次抓取时间
 SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
+ORDER BY refetch_requested_at ASC NULLS LAST, last_fetched_at ASC NULLS FIRST
 LIMIT $1;
 
 -- name: MarkFeedFetched :one
 UPDATE feeds
-SET last_fetched_at = NOW()
+SET last_fetched_at = NOW(), refetch_requested_at = NULL
 WHERE id = $1
 RETURNING *;
+
+-- name: GetFeedByURL :one
+-- 同一个 URL 可能被多个用户添加，优先复用最早创建的订阅源
+SELECT * FROM feeds
+WHERE url = $1
+ORDER BY created_at ASC
+LIMIT 1;
+
+-- name: UpdateFeedMetadata :exec
+-- 抓取成功后用频道信息补充订阅源描述，空值不覆盖已有内容
+UPDATE feeds
+SET description = COALESCE(NULLIF(@description::text, ''), description),
+  language = COALESCE(NULLIF(@language::text, ''), language),
+  link = COALESCE(NULLIF(@link::text, ''), link)
+WHERE id = @id;
+
+-- name: MarkFeedFetchSucceeded :exec
+UPDATE feeds
+SET last_fetch_succeeded_at = NOW(), last_fetch_error = NULL, fetch_error_count = 0
+WHERE id = $1;
+
+-- name: MarkFeedFetchFailed :exec
+-- 记录抓取失败原因，fetch_error_count 为连续失败次数
+UPDATE feeds
+SET last_fetch_error = $2, fetch_error_count = fetch_error_count + 1
+WHERE id = $1;
+
+-- name: GetFeedDetail :one
+SELECT f.*,
+  (SELECT COUNT(*) FROM feed_follows ff WHERE ff.feed_id = f.id) AS follows_count,
+  (SELECT COUNT(*) FROM posts p WHERE p.feed_id = f.id) AS posts_count,
+  (SELECT COUNT(*) FROM posts p WHERE p.feed_id = f.id AND p.published_at > NOW() - INTERVAL '30 days') AS posts_last_30_days,
+  (SELECT COUNT(*) FROM posts p WHERE p.feed_id = f.id AND p.published_at > NOW() - INTERVAL '90 days') AS posts_last_90_days,
+  (SELECT MAX(p.published_at) FROM posts p WHERE p.feed_id = f.id)::timestamptz AS last_post_at,
+  EXISTS (
+    SELECT 1 FROM user_feeds uf
+    WHERE uf.feed_id = f.id AND uf.user_id = sqlc.narg(user_id)
+  ) AS is_following
+FROM feeds f
+WHERE f.id = @id;
+
+-- name: GetFeedByID :one
+SELECT * FROM feeds
+WHERE id = $1;
+
+-- name: UpdateFeed :one
+-- 修改 URL 后清空抓取状态，让抓取器尽快重新抓取；还有其他人关注（含团队关注）时不允许修改 URL
+UPDATE feeds
+SET name = $2,
+  url = $3,
+  category = $4,
+  updated_at = NOW(),
+  last_fetched_at = CASE WHEN url = $3 THEN last_fetched_at END,
+  last_fetch_error = CASE WHEN url = $3 THEN last_fetch_error END,
+  fetch_error_count = CASE WHEN url = $3 THEN fetch_error_count ELSE 0 END
+WHERE id = $1 AND (url = $3 OR NOT EXISTS (
+  SELECT 1 FROM user_feeds uf WHERE uf.feed_id = feeds.id AND uf.user_id <> feeds.user_id
+))
+RETURNING *;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: TransferFeed :one
+UPDATE feeds
+SET user_id = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: TransferFeedUnlessConflict :execrows
+-- 新所有者已有同名或同 URL 的订阅源时不转让，避免唯一约束冲突中止所在事务
+UPDATE feeds
+SET user_id = $2, updated_at = NOW()
+WHERE feeds.id = $1 AND NOT EXISTS (
+  SELECT 1 FROM feeds other
+  WHERE other.user_id = $2 AND (other.name = feeds.name OR other.url = feeds.url)
+);
@@ -0,0 +1,66 @@
+-- name: GetFeverGroups :many
+SELECT short_id, name FROM folders
+WHERE user_id = $1
+ORDER BY position ASC, name ASC;
+
+-- name: GetFeverFeedsGroups :many
+SELECT fo.short_id AS group_id, f.short_id AS feed_id
+FROM folder_feeds fd
+JOIN folders fo ON fo.id = fd.folder_id
+JOIN feed_follows ff ON ff.id = fd.feed_follow_id
+JOIN feeds f ON f.id = ff.feed_id
+WHERE fo.user_id = $1
+ORDER BY fo.position ASC, fd.position ASC;
+
+-- name: GetFeverFeeds :many
+SELECT f.short_id, f.name, f.url, f.link, f.last_fetched_at
+FROM user_feeds uf
+JOIN feeds f ON uf.feed_id = f.id
+WHERE uf.user_id = $1
+ORDER BY f.name ASC;
+
+-- name: GetFeverFeedID :one
+SELECT f.id FROM feeds f
+JOIN user_feeds uf ON uf.feed_id = f.id
+WHERE uf.user_id = $1 AND f.short_id = $2;
+
+-- name: GetFeverGroupID :one
+SELECT id FROM folders
+WHERE user_id = $1 AND short_id = $2;
+
+-- name: GetFeverItems :many
+-- since_id 升序翻页，max_id 或无参数时从最新开始降序
+SELECT p.short_id, f.short_id AS feed_short_id, p.title, p.author, p.description, p.url, p.published_at,
+  ps.read_at, ps.starred_at
+FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id AND uf.user_id = @user_id
+JOIN feeds f ON p.feed_id = f.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE ps.hidden_at IS NULL
+  AND (sqlc.narg(since_id)::bigint IS NULL OR p.short_id > sqlc.narg(since_id)::bigint)
+  AND (sqlc.narg(max_id)::bigint IS NULL OR p.short_id < sqlc.narg(max_id)::bigint)
+  AND (cardinality(@with_ids::bigint[]) = 0 OR p.short_id = ANY(@with_ids::bigint[]))
+ORDER BY
+  CASE WHEN sqlc.narg(since_id)::bigint IS NOT NULL THEN p.short_id END ASC,
+  p.short_id DESC
+LIMIT @page_limit;
+
+-- name: CountFeverItems :one
+SELECT COUNT(*) FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE uf.user_id = $1 AND ps.hidden_at IS NULL;
+
+-- name: GetFeverUnreadItemIDs :many
+SELECT p.short_id FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE uf.user_id = $1 AND ps.read_at IS NULL AND ps.hidden_at IS NULL
+ORDER BY p.short_id ASC;
+
+-- name: GetFeverSavedItemIDs :many
+SELECT p.short_id FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE uf.user_id = $1 AND ps.starred_at IS NOT NULL
+ORDER BY p.short_id ASC;
@@ -0,0 +1,103 @@
+-- name: CreateFolder :one
+INSERT INTO folders (
+  id,
+  user_id,
+  name,
+  position,
+  created_at,
+  updated_at
+)
+VALUES (
+  $1, $2, $3,
+  COALESCE((SELECT MAX(position) + 1 FROM folders WHERE user_id = $2), 0)::integer,
+  $4, $5
+)
+RETURNING *;
+
+-- name: GetFolderByID :one
+SELECT * FROM folders
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetFoldersByUserID :many
+SELECT f.*, (
+  SELECT COUNT(*) FROM posts p
+  JOIN feed_follows ff ON p.feed_id = ff.feed_id
+  JOIN folder_feeds fd ON fd.feed_follow_id = ff.id
+  LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = f.user_id
+  WHERE fd.folder_id = f.id AND ps.read_at IS NULL
+) AS unread_count
+FROM folders f
+WHERE f.user_id = $1
+ORDER BY f.position ASC, f.name ASC;
+
+-- name: GetFolderFeedsByUserID :many
+SELECT fd.folder_id, fd.position, ff.feed_id, feeds.name AS feed_name, feeds.url AS feed_url, (
+  SELECT COUNT(*) FROM posts p
+  LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+  WHERE p.feed_id = ff.feed_id AND ps.read_at IS NULL
+) AS unread_count
+FROM folder_feeds fd
+JOIN feed_follows ff ON fd.feed_follow_id = ff.id
+JOIN feeds ON ff.feed_id = feeds.id
+WHERE ff.user_id = $1
+ORDER BY fd.folder_id, fd.position ASC, feeds.name ASC;
+
+-- name: UpdateFolder :one
+UPDATE folders
+SET name = $3, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders
+WHERE id = $1 AND user_id = $2;
+
+-- name: ReorderFolders :exec
+-- 按 folder_ids 的顺序重写文件夹位置
+UPDATE folders f
+SET position = o.ord::integer, updated_at = NOW()
+FROM unnest(@folder_ids::uuid[]) WITH ORDINALITY AS o(id, ord)
+WHERE f.id = o.id AND f.user_id = @user_id;
+
+-- name: AddFeedToFolder :one
+-- 把用户已关注的订阅源放进文件夹，默认排在末尾；重复添加时保持原位置
+INSERT INTO folder_feeds (folder_id, feed_follow_id, position, created_at)
+SELECT f.id, ff.id,
+  COALESCE((SELECT MAX(position) + 1 FROM folder_feeds WHERE folder_id = f.id), 0)::integer,
+  NOW()
+FROM folders f
+JOIN feed_follows ff ON ff.user_id = f.user_id
+WHERE f.id = @folder_id AND f.user_id = @user_id AND ff.feed_id = @feed_id
+ON CONFLICT (folder_id, feed_follow_id) DO UPDATE SET position = folder_feeds.position
+RETURNING *;
+
+-- name: RemoveFeedFromFolder :execrows
+DELETE FROM folder_feeds fd
+USING folders f, feed_follows ff
+WHERE fd.folder_id = f.id AND fd.feed_follow_id = ff.id
+  AND f.id = @folder_id AND f.user_id = @user_id AND ff.feed_id = @feed_id;
+
+-- name: ReorderFolderFeeds :exec
+-- 按 feed_ids 的顺序重写文件夹内订阅源的位置
+UPDATE folder_feeds fd
+SET position = o.ord::integer
+FROM unnest(@feed_ids::uuid[]) WITH ORDINALITY AS o(feed_id, ord), feed_follows ff, folders f
+WHERE fd.feed_follow_id = ff.id AND ff.feed_id = o.feed_id
+  AND fd.folder_id = f.id AND f.id = @folder_id AND f.user_id = @user_id;
+
+-- name: GetOrCreateFolder :one
+INSERT INTO folders (
+  id,
+  user_id,
+  name,
+  position,
+  created_at,
+  updated_at
+)
+VALUES (
+  $1, $2, $3,
+  COALESCE((SELECT MAX(position) + 1 FROM folders WHERE user_id = $2), 0)::integer,
+  $4, $5
+)
+ON CONFLICT (user_id, name) DO UPDATE SET updated_at = folders.updated_at
+RETURNING *;
@@ -0,0 +1,96 @@
+-- name: GetGReaderSubscriptions :many
+-- 用户的订阅及其所在文件夹名称，团队订阅以团队名称作为文件夹，与个人文件夹重名时只算个人文件夹
+SELECT f.id, f.name, f.url, f.link, uf.created_at,
+  (ARRAY(
+    SELECT fo.name FROM feed_follows ff
+    JOIN folder_feeds fd ON fd.feed_follow_id = ff.id
+    JOIN folders fo ON fo.id = fd.folder_id
+    WHERE ff.user_id = uf.user_id AND ff.feed_id = f.id
+    ORDER BY fo.position
+  ) || ARRAY(
+    SELECT t.name FROM team_follows tf
+    JOIN team_members tm ON tm.team_id = tf.team_id
+    JOIN teams t ON t.id = tf.team_id
+    WHERE tm.user_id = uf.user_id AND tf.feed_id = f.id
+      AND NOT EXISTS (SELECT 1 FROM folders fo WHERE fo.user_id = uf.user_id AND fo.name = t.name)
+    ORDER BY t.name
+  ))::text[] AS folder_names
+FROM user_feeds uf
+JOIN feeds f ON uf.feed_id = f.id
+WHERE uf.user_id = $1
+ORDER BY f.name ASC;
+
+-- name: GetGReaderItemRefs :many
+-- 按 stream 筛选文章 ID，newer_than/older_than 对应协议中的 ot/nt 参数
+SELECT p.short_id, p.published_at, p.feed_id FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE uf.user_id = @user_id
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR p.feed_id = sqlc.narg(feed_id)::uuid)
+  AND (sqlc.narg(folder_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM folder_feeds fd
+    JOIN feed_follows ff ON ff.id = fd.feed_follow_id
+    WHERE ff.user_id = uf.user_id AND ff.feed_id = p.feed_id AND fd.folder_id = sqlc.narg(folder_id)::uuid
+  ))
+  AND (sqlc.narg(team_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM team_follows tf
+    WHERE tf.team_id = sqlc.narg(team_id)::uuid AND tf.feed_id = p.feed_id
+  ))
+  AND (NOT @unread_only::boolean OR ps.read_at IS NULL)
+  AND (NOT @read_only::boolean OR ps.read_at IS NOT NULL)
+  AND (NOT @starred_only::boolean OR ps.starred_at IS NOT NULL)
+  AND (sqlc.narg(newer_than)::timestamptz IS NULL OR p.published_at >= sqlc.narg(newer_than)::timestamptz)
+  AND (sqlc.narg(older_than)::timestamptz IS NULL OR p.published_at < sqlc.narg(older_than)::timestamptz)
+  AND ps.hidden_at IS NULL
+ORDER BY
+  CASE WHEN @oldest_first::boolean THEN p.published_at END ASC,
+  p.published_at DESC,
+  p.short_id DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: GetGReaderItems :many
+SELECT p.id, p.short_id, p.title, p.url, p.description, p.published_at, p.created_at, p.author, p.feed_id,
+  f.name AS feed_name, f.url AS feed_url, f.link AS feed_link, ps.read_at, ps.starred_at
+FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id AND uf.user_id = @user_id
+JOIN feeds f ON p.feed_id = f.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE p.short_id = ANY(@short_ids::bigint[])
+ORDER BY p.published_at DESC;
+
+-- name: GetGReaderPostIDs :many
+SELECT p.id FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id AND uf.user_id = @user_id
+WHERE p.short_id = ANY(@short_ids::bigint[]);
+
+-- name: GetGReaderUnreadCounts :many
+SELECT p.feed_id, COUNT(*) AS count, MAX(p.published_at)::timestamptz AS newest_published_at
+FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE uf.user_id = $1 AND ps.read_at IS NULL AND ps.hidden_at IS NULL
+GROUP BY p.feed_id;
+
+-- name: MarkGReaderStreamRead :execrows
+-- mark-all-as-read：把 stream 中 older_than 之前发布的文章标记为已读
+INSERT INTO post_states (user_id, post_id, read_at, created_at, updated_at)
+SELECT uf.user_id, p.id, NOW(), NOW(), NOW() FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+WHERE uf.user_id = @user_id
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR p.feed_id = sqlc.narg(feed_id)::uuid)
+  AND (sqlc.narg(folder_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM folder_feeds fd
+    JOIN feed_follows ff ON ff.id = fd.feed_follow_id
+    WHERE ff.user_id = uf.user_id AND ff.feed_id = p.feed_id AND fd.folder_id = sqlc.narg(folder_id)::uuid
+  ))
+  AND (sqlc.narg(team_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM team_follows tf
+    WHERE tf.team_id = sqlc.narg(team_id)::uuid AND tf.feed_id = p.feed_id
+  ))
+  AND (NOT @starred_only::boolean OR EXISTS (
+    SELECT 1 FROM post_states s
+    WHERE s.user_id = uf.user_id AND s.post_id = p.id AND s.starred_at IS NOT NULL
+  ))
+  AND p.published_at <= @older_than
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = COALESCE(post_states.read_at, NOW()), updated_at = NOW();
@@ -0,0 +1,35 @@
+-- name: CreateInvite :one
+INSERT INTO invites (id, code_hash, prefix, note, max_uses, created_by, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: ListInvites :many
+SELECT i.*, u.username AS created_by_username FROM invites i
+LEFT JOIN users u ON i.created_by = u.id
+ORDER BY i.created_at DESC;
+
+-- name: RevokeInvite :execrows
+UPDATE invites
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: RedeemInvite :one
+-- 占用一次邀请码的使用次数，无效、已用完、过期或已吊销时没有结果
+UPDATE invites
+SET uses = uses + 1
+WHERE code_hash = $1
+  AND revoked_at IS NULL
+  AND uses < max_uses
+  AND (expires_at IS NULL OR expires_at > NOW())
+RETURNING *;
+
+-- name: ReleaseInvite :exec
+-- 注册失败时归还占用的使用次数
+UPDATE invites
+SET uses = uses - 1
+WHERE id = $1 AND uses > 0;
+
+-- name: SetUserInvite :exec
+UPDATE users
+SET invite_id = $1
+WHERE id = $2;
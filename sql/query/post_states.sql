@@ -0,0 +1,59 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_states (user_id, post_id, read_at, created_at, updated_at)
+VALUES ($1, $2, NOW(), NOW(), NOW())
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = COALESCE(post_states.read_at, NOW()), updated_at = NOW();
+
+-- name: MarkPostUnread :exec
+UPDATE post_states
+SET read_at = NULL, updated_at = NOW()
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: StarPost :exec
+INSERT INTO post_states (user_id, post_id, starred_at, created_at, updated_at)
+VALUES ($1, $2, NOW(), NOW(), NOW())
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET starred_at = COALESCE(post_states.starred_at, NOW()), updated_at = NOW();
+
+-- name: UnstarPost :exec
+UPDATE post_states
+SET starred_at = NULL, updated_at = NOW()
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: HidePost :exec
+INSERT INTO post_states (user_id, post_id, hidden_at, created_at, updated_at)
+VALUES ($1, $2, NOW(), NOW(), NOW())
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET hidden_at = COALESCE(post_states.hidden_at, NOW()), updated_at = NOW();
+
+-- name: UnhidePost :exec
+UPDATE post_states
+SET hidden_at = NULL, updated_at = NOW()
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: ApplyRuleActions :exec
+-- 规则动作只会补充状态（隐藏、已读、收藏、追加标签），不会撤销用户已有的状态
+INSERT INTO post_states (user_id, post_id, read_at, starred_at, hidden_at, tags, created_at, updated_at)
+VALUES (
+  @user_id, @post_id,
+  CASE WHEN @mark_read::boolean THEN NOW() END,
+  CASE WHEN @star::boolean THEN NOW() END,
+  CASE WHEN @hide::boolean THEN NOW() END,
+  @tags::text[], NOW(), NOW()
+)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+  starred_at = COALESCE(post_states.starred_at, EXCLUDED.starred_at),
+  hidden_at = COALESCE(post_states.hidden_at, EXCLUDED.hidden_at),
+  tags = ARRAY(SELECT DISTINCT t FROM unnest(post_states.tags || EXCLUDED.tags) AS t ORDER BY t),
+  updated_at = NOW();
+
+-- name: GetPostStatesForExport :many
+-- 导出用户的阅读、收藏、隐藏和标签状态，附带文章和订阅源地址以便迁移到其他服务
+SELECT ps.post_id, p.title, p.url, f.url AS feed_url, ps.read_at, ps.starred_at, ps.hidden_at, ps.tags
+FROM post_states ps
+JOIN posts p ON p.id = ps.post_id
+JOIN feeds f ON f.id = p.feed_id
+WHERE ps.user_id = $1
+  AND (ps.read_at IS NOT NULL OR ps.starred_at IS NOT NULL OR ps.hidden_at IS NOT NULL OR cardinality(ps.tags) > 0)
+ORDER BY p.published_at DESC;
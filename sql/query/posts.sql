@@ -7,18 +7,85 @@ INSERT INTO posts (
   url,
   description,
   published_at,
-  feed_id
+  feed_id,
+  search_title,
+  search_body,
+  author,
+  categories
 )
 VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 )
 RETURNING *;
 
 -- name: GetPostsForUser :many
-SELECT p.*, feeds.name as feed_name FROM posts p
-JOIN feed_follows ff ON p.feed_id = ff.feed_id
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, feeds.name as feed_name, ps.read_at, ps.starred_at, COALESCE(ps.tags, '{}')::text[] AS tags FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
 JOIN feeds ON p.feed_id = feeds.id
-WHERE ff.user_id = $1
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE uf.user_id = @user_id
+  AND (sqlc.narg(folder_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM folder_feeds fd
+    JOIN feed_follows ff ON ff.id = fd.feed_follow_id
+    WHERE ff.user_id = uf.user_id AND ff.feed_id = p.feed_id AND fd.folder_id = sqlc.narg(folder_id)::uuid
+  ))
+  AND (NOT @unread_only::boolean OR ps.read_at IS NULL)
+  AND (NOT @starred_only::boolean OR ps.starred_at IS NOT NULL)
+  AND (sqlc.narg(tag)::text IS NULL OR sqlc.narg(tag)::text = ANY(ps.tags))
+  AND ps.hidden_at IS NULL
 ORDER BY p.published_at DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: GetPostsMissingSearchText :many
+SELECT id, title, description FROM posts
+WHERE search_title IS NULL
+LIMIT $1;
+
+-- name: SearchPosts :many
+-- 全文检索文章，默认只搜索用户关注的订阅源，all_feeds 为 true 时搜索全部公开订阅源
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, feeds.name AS feed_name,
+  ts_rank_cd(p.search_vector, to_tsquery('simple', @query::text)) AS rank
+FROM posts p
+JOIN feeds ON p.feed_id = feeds.id
+WHERE p.search_vector @@ to_tsquery('simple', @query::text)
+  AND (@all_feeds::boolean OR EXISTS (
+    SELECT 1 FROM user_feeds uf
+    WHERE uf.feed_id = p.feed_id AND uf.user_id = @user_id
+  ))
+ORDER BY rank DESC, p.published_at DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: UpdatePostSearchText :exec
+UPDATE posts
+SET search_title = $2, search_body = $3
+WHERE id = $1;
+
+-- name: GetRecentPostsByFeed :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
+WHERE feed_id = $1
+ORDER BY published_at DESC
 LIMIT $2;
 
+-- name: GetPostsForRules :many
+-- 规则变更后回溯匹配：用户关注的订阅源中 since 之后发布的文章
+SELECT p.id, p.title, p.description, p.author, p.categories, feeds.name AS feed_name, feeds.url AS feed_url FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+JOIN feeds ON p.feed_id = feeds.id
+WHERE uf.user_id = $1 AND p.published_at >= $2
+ORDER BY p.published_at DESC;
+
+-- name: GetStreamPosts :many
+-- 实时推送：用户关注的订阅源中 short_id 大于 after_id 的文章，按入库顺序返回
+SELECT p.id, p.short_id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.author, p.feed_id, feeds.name AS feed_name FROM posts p
+JOIN user_feeds uf ON p.feed_id = uf.feed_id
+JOIN feeds ON p.feed_id = feeds.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = uf.user_id
+WHERE uf.user_id = @user_id AND p.short_id > @after_id AND ps.hidden_at IS NULL
+ORDER BY p.short_id ASC
+LIMIT @page_limit;
+
+-- name: GetMaxPostShortID :one
+SELECT COALESCE(MAX(short_id), 0)::bigint FROM posts;
+
+-- name: NotifyNewPost :exec
+SELECT pg_notify('new_posts', @payload::text);
@@ -0,0 +1,50 @@
+-- name: GetUserQuota :one
+-- 用户的有效配额（单个用户的设置优先于角色默认值，-1 或 NULL 为不限制）及当前用量，关注数包括用户创建的团队订阅
+SELECT
+  NULLIF(COALESCE(u.max_feeds, rq.max_feeds), -1) AS max_feeds,
+  NULLIF(COALESCE(u.max_follows, rq.max_follows), -1) AS max_follows,
+  NULLIF(COALESCE(u.max_webhooks, rq.max_webhooks), -1) AS max_webhooks,
+  (SELECT COUNT(*) FROM feeds f WHERE f.user_id = u.id) AS feeds,
+  (SELECT COUNT(*) FROM (
+    SELECT ff.feed_id FROM feed_follows ff WHERE ff.user_id = u.id
+    UNION
+    SELECT tf.feed_id FROM team_follows tf WHERE tf.created_by = u.id
+  ) followed) AS follows,
+  (SELECT COUNT(*) FROM webhooks wh WHERE wh.user_id = u.id) AS webhooks
+FROM users u
+LEFT JOIN role_quotas rq ON rq.role = u.role
+WHERE u.id = $1;
+
+-- name: LockUserQuota :exec
+-- 事务级的用户配额锁，检查配额前调用并在同一事务中插入，事务结束时自动释放
+SELECT pg_advisory_xact_lock(hashtextextended('quota:' || $1::uuid::text, 0));
+
+-- name: IsFollowingFeed :one
+-- 订阅源是否已占用用户的关注配额：个人关注或用户创建的团队订阅
+SELECT EXISTS (
+  SELECT 1 FROM feed_follows
+  WHERE user_id = $1 AND feed_id = $2
+  UNION ALL
+  SELECT 1 FROM team_follows
+  WHERE created_by = $1 AND feed_id = $2
+);
+
+-- name: GetRoleQuotas :many
+SELECT * FROM role_quotas
+ORDER BY role ASC;
+
+-- name: UpsertRoleQuota :one
+INSERT INTO role_quotas (role, max_feeds, max_follows, max_webhooks, updated_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (role) DO UPDATE
+SET max_feeds = EXCLUDED.max_feeds,
+  max_follows = EXCLUDED.max_follows,
+  max_webhooks = EXCLUDED.max_webhooks,
+  updated_at = NOW()
+RETURNING *;
+
+-- name: SetUserQuota :one
+UPDATE users
+SET max_feeds = $1, max_follows = $2, max_webhooks = $3, updated_at = NOW()
+WHERE id = $4
+RETURNING *;
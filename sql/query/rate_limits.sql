@@ -0,0 +1,37 @@
+-- name: TakeRateLimitToken :one
+-- 按距上次请求的时间补充令牌后尝试取走一个，SET 中的表达式都基于更新前的行计算，整个过程在一条语句内原子完成
+INSERT INTO rate_limit_buckets (key, tokens, allowed, updated_at)
+VALUES (@key, @burst::float8 - 1, TRUE, @now)
+ON CONFLICT (key) DO UPDATE SET
+  tokens = LEAST(@burst::float8, rate_limit_buckets.tokens + GREATEST(EXTRACT(EPOCH FROM @now - rate_limit_buckets.updated_at)::float8, 0) * @refill_per_second::float8)
+    - CASE WHEN LEAST(@burst::float8, rate_limit_buckets.tokens + GREATEST(EXTRACT(EPOCH FROM @now - rate_limit_buckets.updated_at)::float8, 0) * @refill_per_second::float8) >= 1 THEN 1 ELSE 0 END,
+  allowed = LEAST(@burst::float8, rate_limit_buckets.tokens + GREATEST(EXTRACT(EPOCH FROM @now - rate_limit_buckets.updated_at)::float8, 0) * @refill_per_second::float8) >= 1,
+  updated_at = GREATEST(rate_limit_buckets.updated_at, @now)
+RETURNING tokens, allowed;
+
+-- name: DeleteIdleRateLimitBuckets :exec
+-- 长时间没有请求的桶早已补满，删除后与新建的桶等价
+DELETE FROM rate_limit_buckets WHERE updated_at < @idle_before;
+
+-- name: GetLoginLockedUntil :one
+SELECT locked_until FROM login_failures
+WHERE key = @key AND locked_until > @now;
+
+-- name: RecordLoginFailure :one
+-- 距上次失败超过 reset_before 时重新计数
+INSERT INTO login_failures (key, failures, updated_at)
+VALUES (@key, 1, @now)
+ON CONFLICT (key) DO UPDATE SET
+  failures = CASE WHEN login_failures.updated_at < @reset_before THEN 1 ELSE login_failures.failures + 1 END,
+  updated_at = @now
+RETURNING failures;
+
+-- name: LockLogin :exec
+UPDATE login_failures SET locked_until = @locked_until WHERE key = @key;
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures WHERE key = @key;
+
+-- name: DeleteStaleLoginFailures :exec
+DELETE FROM login_failures
+WHERE updated_at < @reset_before AND (locked_until IS NULL OR locked_until < @now);
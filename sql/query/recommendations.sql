@@ -0,0 +1,65 @@
+-- name: RefreshFeedSimilarities :exec
+-- 余弦相似度：共同关注人数 / sqrt(两个订阅源各自的关注人数之积)
+INSERT INTO feed_similarities (feed_id, similar_feed_id, co_follows, score, computed_at)
+SELECT a.feed_id, b.feed_id, COUNT(*)::integer,
+  COUNT(*) / sqrt(ca.follows_count * cb.follows_count),
+  @computed_at
+FROM feed_follows a
+JOIN feed_follows b ON a.user_id = b.user_id AND a.feed_id <> b.feed_id
+JOIN (SELECT feed_id, COUNT(*)::float8 AS follows_count FROM feed_follows GROUP BY feed_id) ca ON ca.feed_id = a.feed_id
+JOIN (SELECT feed_id, COUNT(*)::float8 AS follows_count FROM feed_follows GROUP BY feed_id) cb ON cb.feed_id = b.feed_id
+GROUP BY a.feed_id, b.feed_id, ca.follows_count, cb.follows_count
+ON CONFLICT (feed_id, similar_feed_id) DO UPDATE
+SET co_follows = EXCLUDED.co_follows, score = EXCLUDED.score, computed_at = EXCLUDED.computed_at;
+
+-- name: DeleteStaleFeedSimilarities :exec
+DELETE FROM feed_similarities
+WHERE computed_at < @computed_at;
+
+-- name: GetRecommendedFeeds :many
+-- 推荐用户尚未关注的订阅源：协同过滤得分为主，叠加与已关注订阅源的分类/语言相似度，
+-- 并以关注数的对数作为冷启动时的兜底；失效的订阅源不参与推荐
+WITH followed AS (
+  SELECT f.id, f.category, split_part(lower(f.language), '-', 1) AS lang
+  FROM feed_follows ff
+  JOIN feeds f ON f.id = ff.feed_id
+  WHERE ff.user_id = @user_id
+),
+followed_total AS (
+  SELECT GREATEST(COUNT(*), 1)::float8 AS n FROM followed
+),
+category_prefs AS (
+  SELECT category, COUNT(*)::float8 / (SELECT n FROM followed_total) AS weight
+  FROM followed WHERE category IS NOT NULL
+  GROUP BY category
+),
+language_prefs AS (
+  SELECT lang, COUNT(*)::float8 / (SELECT n FROM followed_total) AS weight
+  FROM followed WHERE lang IS NOT NULL AND lang <> ''
+  GROUP BY lang
+),
+collaborative AS (
+  SELECT s.similar_feed_id AS feed_id, SUM(s.score) AS score, SUM(s.co_follows) AS co_follows
+  FROM feed_similarities s
+  JOIN followed fo ON fo.id = s.feed_id
+  GROUP BY s.similar_feed_id
+)
+SELECT f.*, fc.follows_count,
+  COALESCE(c.co_follows, 0)::bigint AS co_follows,
+  COALESCE(c.score, 0)::float8 AS collaborative_score,
+  (COALESCE(cp.weight, 0) * 0.3 + COALESCE(lp.weight, 0) * 0.2)::float8 AS similarity_score,
+  (COALESCE(c.score, 0)
+    + COALESCE(cp.weight, 0) * 0.3
+    + COALESCE(lp.weight, 0) * 0.2
+    + ln(1 + fc.follows_count) * 0.05)::float8 AS score
+FROM feeds f
+LEFT JOIN LATERAL (
+  SELECT COUNT(*) AS follows_count FROM feed_follows WHERE feed_id = f.id
+) fc ON true
+LEFT JOIN collaborative c ON c.feed_id = f.id
+LEFT JOIN category_prefs cp ON cp.category = f.category
+LEFT JOIN language_prefs lp ON lp.lang = split_part(lower(f.language), '-', 1)
+WHERE NOT EXISTS (SELECT 1 FROM followed fo WHERE fo.id = f.id)
+  AND f.fetch_error_count < @dead_error_count
+ORDER BY score DESC, fc.follows_count DESC, f.created_at DESC
+LIMIT @page_limit;
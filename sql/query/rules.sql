@@ -0,0 +1,51 @@
+-- name: CreateRule :one
+INSERT INTO rules (
+  id,
+  user_id,
+  name,
+  field,
+  match_type,
+  pattern,
+  action,
+  tag,
+  enabled,
+  created_at,
+  updated_at
+)
+VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+)
+RETURNING *;
+
+-- name: GetRulesByUserID :many
+SELECT * FROM rules
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetRuleByID :one
+SELECT * FROM rules
+WHERE id = $1 AND user_id = $2;
+
+-- name: UpdateRule :one
+UPDATE rules
+SET name = $3,
+  field = $4,
+  match_type = $5,
+  pattern = $6,
+  action = $7,
+  tag = $8,
+  enabled = $9,
+  updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteRule :execrows
+DELETE FROM rules
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetEnabledRulesForFeed :many
+-- 关注了该订阅源的所有用户的已启用规则，用于抓取入库时匹配
+SELECT r.* FROM rules r
+JOIN user_feeds uf ON uf.user_id = r.user_id
+WHERE uf.feed_id = $1 AND r.enabled
+ORDER BY r.user_id, r.created_at ASC;
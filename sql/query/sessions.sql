@@ -0,0 +1,58 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, refresh_token_hash, user_agent, ip, created_at, last_used_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $6, $7)
+RETURNING *;
+
+-- name: GetSessionUser :one
+-- 校验访问令牌时确认会话仍然有效，注销或用户被停用后访问令牌立即失效
+SELECT u.* FROM sessions s
+JOIN users u ON u.id = s.user_id
+WHERE s.id = $1 AND s.revoked_at IS NULL AND s.expires_at > NOW() AND u.disabled_at IS NULL;
+
+-- name: RotateSessionToken :one
+-- 轮换时间和有效期都以应用传入的 now 为准，与 GetRecentlyRotatedSession 的宽限期使用同一个时钟
+UPDATE sessions
+SET previous_token_hash = refresh_token_hash,
+  refresh_token_hash = @new_token_hash,
+  last_used_at = @now,
+  expires_at = @expires_at
+WHERE refresh_token_hash = @token_hash AND revoked_at IS NULL AND expires_at > @now
+RETURNING *;
+
+-- name: RevokeSessionByPreviousToken :execrows
+-- 已轮换的刷新令牌再次出现说明令牌可能被盗用，吊销整个会话
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE previous_token_hash = $1 AND revoked_at IS NULL;
+
+-- name: RevokeSessionByToken :one
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE refresh_token_hash = $1 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: GetActiveSessionsByUserID :many
+SELECT * FROM sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY last_used_at DESC;
+
+-- name: RevokeOtherSessions :execrows
+-- 修改密码后吊销除当前会话外的全部会话，keep_id 为空时全部吊销
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = @user_id AND id IS DISTINCT FROM sqlc.narg(keep_id) AND revoked_at IS NULL;
+
+-- name: GetRecentlyRotatedSession :one
+-- 并发刷新：旧令牌刚被轮换为同一个新令牌时返回该会话，last_used_at 即轮换时间
+SELECT * FROM sessions
+WHERE previous_token_hash = @previous_token_hash AND refresh_token_hash = @refresh_token_hash
+  AND last_used_at > @rotated_after AND revoked_at IS NULL AND expires_at > @now;
+
+-- name: GetSessionByID :one
+SELECT * FROM sessions
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW();
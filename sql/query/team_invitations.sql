@@ -0,0 +1,36 @@
+-- name: CreateTeamInvitation :one
+-- 再次邀请同一用户时更新角色和邀请人
+INSERT INTO team_invitations (team_id, user_id, role, invited_by, created_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (team_id, user_id) DO UPDATE SET
+  role = EXCLUDED.role,
+  invited_by = EXCLUDED.invited_by,
+  created_at = EXCLUDED.created_at
+RETURNING *;
+
+-- name: GetTeamInvitations :many
+SELECT i.*, u.username
+FROM team_invitations i
+JOIN users u ON u.id = i.user_id
+WHERE i.team_id = $1
+ORDER BY i.created_at DESC;
+
+-- name: GetIncomingTeamInvitations :many
+-- 发给用户的团队邀请，附带团队名称和邀请人
+SELECT i.*, t.name AS team_name, inv.username AS invited_by_username
+FROM team_invitations i
+JOIN teams t ON t.id = i.team_id
+LEFT JOIN users inv ON inv.id = i.invited_by
+WHERE i.user_id = $1
+ORDER BY i.created_at DESC;
+
+-- name: TakeTeamInvitation :one
+-- 接受邀请时删除邀请，并在同一事务中添加成员
+DELETE FROM team_invitations
+WHERE team_id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteTeamInvitation :execrows
+-- owner 撤回或被邀请人拒绝
+DELETE FROM team_invitations
+WHERE team_id = $1 AND user_id = $2;
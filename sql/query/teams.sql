@@ -0,0 +1,209 @@
+-- name: CreateTeam :one
+INSERT INTO teams (id, name, created_at, updated_at)
+VALUES ($1, $2, $3, $3)
+RETURNING *;
+
+-- name: GetTeamForMember :one
+-- 团队及请求者在其中的角色，不是成员时没有结果
+SELECT t.*, tm.role FROM teams t
+JOIN team_members tm ON tm.team_id = t.id
+WHERE t.id = $1 AND tm.user_id = $2;
+
+-- name: GetTeamsByUserID :many
+SELECT t.*, tm.role,
+  (SELECT COUNT(*) FROM team_members m WHERE m.team_id = t.id) AS members_count
+FROM teams t
+JOIN team_members tm ON tm.team_id = t.id
+WHERE tm.user_id = $1
+ORDER BY t.name ASC;
+
+-- name: UpdateTeam :one
+UPDATE teams
+SET name = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTeam :exec
+DELETE FROM teams
+WHERE id = $1;
+
+-- name: AddTeamMember :one
+INSERT INTO team_members (team_id, user_id, role, created_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetTeamMember :one
+SELECT * FROM team_members
+WHERE team_id = $1 AND user_id = $2;
+
+-- name: GetTeamMembers :many
+SELECT tm.team_id, tm.user_id, tm.role, tm.created_at, u.username, u.display_name FROM team_members tm
+JOIN users u ON u.id = tm.user_id
+WHERE tm.team_id = $1
+ORDER BY CASE tm.role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END, u.username ASC;
+
+-- name: UpdateTeamMemberRole :one
+-- 团队至少保留一个 owner，降级最后一个 owner 时不更新；需要在同一事务中先调用 LockTeamOwners
+UPDATE team_members
+SET role = @role
+WHERE team_id = @team_id AND user_id = @user_id
+  AND (@role::text = 'owner' OR EXISTS (
+    SELECT 1 FROM team_members o
+    WHERE o.team_id = @team_id AND o.user_id <> @user_id AND o.role = 'owner'
+  ))
+RETURNING *;
+
+-- name: RemoveTeamMember :execrows
+-- 团队至少保留一个 owner，移除最后一个 owner 时不删除；需要在同一事务中先调用 LockTeamOwners
+DELETE FROM team_members
+WHERE team_id = @team_id AND user_id = @user_id
+  AND (role <> 'owner' OR EXISTS (
+    SELECT 1 FROM team_members o
+    WHERE o.team_id = @team_id AND o.user_id <> @user_id AND o.role = 'owner'
+  ));
+
+-- name: LockTeamOwners :exec
+-- 在事务中锁住团队现有的 owner，降级或移除成员前调用，避免并发降级后团队没有 owner
+SELECT user_id FROM team_members
+WHERE team_id = $1 AND role = 'owner'
+ORDER BY user_id
+FOR UPDATE;
+
+-- name: CreateTeamFollow :one
+INSERT INTO team_follows (id, team_id, feed_id, created_by, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetTeamFollows :many
+-- 团队订阅的订阅源，未读数按请求的成员自己的阅读状态计算
+SELECT tf.id, tf.team_id, tf.feed_id, tf.created_by, tf.created_at,
+  feeds.name AS feed_name, feeds.url AS feed_url, u.username AS created_by_username, (
+  SELECT COUNT(*) FROM posts p
+  LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = @user_id
+  WHERE p.feed_id = tf.feed_id AND ps.read_at IS NULL
+) AS unread_count
+FROM team_follows tf
+JOIN feeds ON tf.feed_id = feeds.id
+LEFT JOIN users u ON tf.created_by = u.id
+WHERE tf.team_id = @team_id
+ORDER BY feeds.name ASC;
+
+-- name: DeleteTeamFollow :execrows
+DELETE FROM team_follows
+WHERE team_id = $1 AND feed_id = $2;
+
+-- name: CreateTeamFolder :one
+INSERT INTO team_folders (
+  id,
+  team_id,
+  name,
+  position,
+  created_at,
+  updated_at
+)
+VALUES (
+  $1, $2, $3,
+  COALESCE((SELECT MAX(position) + 1 FROM team_folders WHERE team_id = $2), 0)::integer,
+  $4, $4
+)
+RETURNING *;
+
+-- name: GetTeamFolders :many
+SELECT f.*, (
+  SELECT COUNT(*) FROM posts p
+  JOIN team_follows tf ON p.feed_id = tf.feed_id
+  JOIN team_folder_feeds fd ON fd.team_follow_id = tf.id
+  LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = @user_id
+  WHERE fd.folder_id = f.id AND ps.read_at IS NULL
+) AS unread_count
+FROM team_folders f
+WHERE f.team_id = @team_id
+ORDER BY f.position ASC, f.name ASC;
+
+-- name: GetTeamFolderFeeds :many
+SELECT fd.folder_id, fd.position, tf.feed_id, feeds.name AS feed_name, feeds.url AS feed_url, (
+  SELECT COUNT(*) FROM posts p
+  LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = @user_id
+  WHERE p.feed_id = tf.feed_id AND ps.read_at IS NULL
+) AS unread_count
+FROM team_folder_feeds fd
+JOIN team_follows tf ON fd.team_follow_id = tf.id
+JOIN feeds ON tf.feed_id = feeds.id
+WHERE tf.team_id = @team_id
+ORDER BY fd.folder_id, fd.position ASC, feeds.name ASC;
+
+-- name: UpdateTeamFolder :one
+UPDATE team_folders
+SET name = $3, updated_at = NOW()
+WHERE id = $1 AND team_id = $2
+RETURNING *;
+
+-- name: DeleteTeamFolder :execrows
+DELETE FROM team_folders
+WHERE id = $1 AND team_id = $2;
+
+-- name: AddFeedToTeamFolder :one
+-- 把团队订阅的订阅源放进团队文件夹，默认排在末尾；重复添加时保持原位置
+INSERT INTO team_folder_feeds (folder_id, team_follow_id, position, created_at)
+SELECT f.id, tf.id,
+  COALESCE((SELECT MAX(position) + 1 FROM team_folder_feeds WHERE folder_id = f.id), 0)::integer,
+  NOW()
+FROM team_folders f
+JOIN team_follows tf ON tf.team_id = f.team_id
+WHERE f.id = @folder_id AND f.team_id = @team_id AND tf.feed_id = @feed_id
+ON CONFLICT (folder_id, team_follow_id) DO UPDATE SET position = team_folder_feeds.position
+RETURNING *;
+
+-- name: RemoveFeedFromTeamFolder :execrows
+DELETE FROM team_folder_feeds fd
+USING team_folders f, team_follows tf
+WHERE fd.folder_id = f.id AND fd.team_follow_id = tf.id
+  AND f.id = @folder_id AND f.team_id = @team_id AND tf.feed_id = @feed_id;
+
+-- name: GetTeamPosts :many
+-- 团队时间线，列与 GetPostsForUser 相同，阅读状态来自请求的成员自己
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, feeds.name as feed_name, ps.read_at, ps.starred_at, COALESCE(ps.tags, '{}')::text[] AS tags FROM posts p
+JOIN team_follows tf ON p.feed_id = tf.feed_id
+JOIN feeds ON p.feed_id = feeds.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = @user_id
+WHERE tf.team_id = @team_id
+  AND (sqlc.narg(folder_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM team_folder_feeds fd
+    WHERE fd.team_follow_id = tf.id AND fd.folder_id = sqlc.narg(folder_id)::uuid
+  ))
+  AND (NOT @unread_only::boolean OR ps.read_at IS NULL)
+  AND (NOT @starred_only::boolean OR ps.starred_at IS NOT NULL)
+  AND (sqlc.narg(tag)::text IS NULL OR sqlc.narg(tag)::text = ANY(ps.tags))
+  AND ps.hidden_at IS NULL
+ORDER BY p.published_at DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: LockUserTeamOwners :exec
+-- 注销账号时锁住用户担任 owner 的所有团队的 owner，与 LockTeamOwners 互斥
+SELECT o.team_id, o.user_id FROM team_members o
+WHERE o.role = 'owner'
+  AND o.team_id IN (SELECT team_id FROM team_members WHERE user_id = $1 AND role = 'owner')
+ORDER BY o.team_id, o.user_id
+FOR UPDATE OF o;
+
+-- name: PromoteNextTeamOwners :exec
+-- 注销账号前，把用户作为唯一 owner 的团队交给其余成员中最早加入的 editor，没有 editor 时交给最早加入的成员
+UPDATE team_members tm
+SET role = 'owner'
+FROM (
+  SELECT DISTINCT ON (m.team_id) m.team_id, m.user_id FROM team_members m
+  WHERE m.user_id <> @user_id
+    AND m.team_id IN (SELECT team_id FROM team_members WHERE user_id = @user_id AND role = 'owner')
+    AND NOT EXISTS (
+      SELECT 1 FROM team_members o
+      WHERE o.team_id = m.team_id AND o.user_id <> @user_id AND o.role = 'owner'
+    )
+  ORDER BY m.team_id, CASE m.role WHEN 'editor' THEN 0 ELSE 1 END, m.created_at ASC
+) next_owner
+WHERE tm.team_id = next_owner.team_id AND tm.user_id = next_owner.user_id;
+
+-- name: DeleteSoleMemberTeams :execrows
+-- 注销账号前，删除只有该用户一个成员的团队
+DELETE FROM teams t
+WHERE EXISTS (SELECT 1 FROM team_members WHERE team_id = t.id AND user_id = @user_id)
+  AND NOT EXISTS (SELECT 1 FROM team_members WHERE team_id = t.id AND user_id <> @user_id);
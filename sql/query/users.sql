@@ -4,18 +4,66 @@ INSERT INTO users (
   username,
   password,
   created_at,
-  updated_at,
-  api_key
+  updated_at
 )
 VALUES (
-  $1, $2, $3, $4, $5, encode(sha256(random()::text::bytea), 'hex')
+  $1, $2, $3, $4, $5
 )
 RETURNING *;
 
--- name: GetUserByAPIKey :one
-SELECT * FROM users
-WHERE api_key = $1 LIMIT 1;
-
 -- name: GetUserByUsername :one
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
+
+-- name: GetUserByFeedToken :one
+SELECT * FROM users
+WHERE feed_token_hash = $1 AND disabled_at IS NULL LIMIT 1;
+
+-- name: SetUserFeedToken :one
+UPDATE users
+SET feed_token_hash = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1 LIMIT 1;
+
+-- name: GetUserByFeverAPIKey :one
+SELECT * FROM users
+WHERE fever_api_key = $1 AND disabled_at IS NULL LIMIT 1;
+
+-- name: SetUserFeverAPIKey :one
+UPDATE users
+SET fever_api_key = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET username = @username, display_name = @display_name, email = @email, updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- name: UpdateUserPassword :one
+-- Fever 凭据由旧密码计算得出，修改密码后一并停用
+UPDATE users
+SET password = @password, fever_api_key = NULL, updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- name: DeleteUser :execrows
+-- 关注、文件夹、阅读状态、规则、webhook、API Key 和会话通过外键级联删除
+DELETE FROM users WHERE id = $1;
+
+-- name: CreateExternalUser :one
+-- 单点登录自动创建的用户没有密码
+INSERT INTO users (id, username, password, display_name, email, created_at, updated_at)
+VALUES (@id, @username, '', @display_name, @email, @created_at, @created_at)
+RETURNING *;
+
+-- name: SetUserRole :one
+UPDATE users
+SET role = @role, updated_at = NOW()
+WHERE id = @id
+RETURNING *;
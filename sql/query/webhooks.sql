@@ -0,0 +1,95 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+  id,
+  user_id,
+  url,
+  secret,
+  feed_id,
+  folder_id,
+  rule_id,
+  created_at,
+  updated_at
+)
+VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetWebhooksByUserID :many
+SELECT * FROM webhooks
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetWebhookByID :one
+SELECT * FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetWebhooksForFeed :many
+-- 订阅源有新文章时需要推送的 webhook：所有者仍关注该订阅源，且满足订阅源、文件夹过滤条件
+SELECT w.* FROM webhooks w
+JOIN feed_follows ff ON ff.user_id = w.user_id AND ff.feed_id = @feed_id
+WHERE (w.feed_id IS NULL OR w.feed_id = @feed_id)
+  AND (w.folder_id IS NULL OR EXISTS (
+    SELECT 1 FROM folder_feeds fd
+    WHERE fd.folder_id = w.folder_id AND fd.feed_follow_id = ff.id
+  ));
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, webhook_id, post_id, created_at, updated_at)
+VALUES ($1, $2, $3, NOW(), NOW())
+ON CONFLICT (webhook_id, post_id) DO NOTHING;
+
+-- name: ClaimDueWebhookDeliveries :many
+-- 领取到期的投递并顺延 next_attempt_at，避免进程中断后任务丢失或被重复领取
+UPDATE webhook_deliveries
+SET next_attempt_at = NOW() + INTERVAL '5 minutes', updated_at = NOW()
+WHERE id IN (
+  SELECT d.id FROM webhook_deliveries d
+  WHERE d.status = 'pending' AND d.next_attempt_at <= NOW()
+  ORDER BY d.next_attempt_at ASC
+  LIMIT $1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id;
+
+-- name: GetWebhookDeliveryPayload :one
+SELECT d.id, d.webhook_id, d.attempts, w.url AS webhook_url, w.secret,
+  p.id AS post_id, p.title, p.url AS post_url, p.description, p.published_at, p.author, p.categories,
+  f.id AS feed_id, f.name AS feed_name, f.url AS feed_url
+FROM webhook_deliveries d
+JOIN webhooks w ON d.webhook_id = w.id
+JOIN posts p ON d.post_id = p.id
+JOIN feeds f ON p.feed_id = f.id
+WHERE d.id = $1;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded', attempts = attempts + 1, last_status_code = $2, last_error = NULL,
+  delivered_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- status 为 pending 时在 next_attempt_at 重试，为 dead 时不再重试
+UPDATE webhook_deliveries
+SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = $4,
+  next_attempt_at = $5, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = @webhook_id
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY created_at DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: RetryWebhookDelivery :one
+-- 手动重试：重置为 pending 并立即投递，重试次数重新计算
+UPDATE webhook_deliveries d
+SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+FROM webhooks w
+WHERE d.id = $1 AND d.webhook_id = w.id AND w.id = $2 AND w.user_id = $3
+RETURNING d.*;
@@ -0,0 +1,27 @@
+-- +goose Up
+
+-- 由 Go 端（search.Tokenize）生成的检索表示，CJK 文本已切分为二元组
+ALTER TABLE posts ADD COLUMN search_title TEXT;
+ALTER TABLE posts ADD COLUMN search_body TEXT;
+
+-- 重建生成列：优先使用检索表示，旧数据回退到原始标题和描述
+DROP INDEX IF EXISTS posts_search_vector_idx;
+ALTER TABLE posts DROP COLUMN search_vector;
+ALTER TABLE posts ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('simple', coalesce(search_title, title, '')), 'A') ||
+  setweight(to_tsvector('simple', coalesce(search_body, description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS posts_search_vector_idx ON posts USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX IF EXISTS posts_search_vector_idx;
+ALTER TABLE posts DROP COLUMN search_vector;
+ALTER TABLE posts DROP COLUMN search_title;
+ALTER TABLE posts DROP COLUMN search_body;
+ALTER TABLE posts ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+  setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS posts_search_vector_idx ON posts USING GIN (search_vector);
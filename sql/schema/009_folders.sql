@@ -0,0 +1,24 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS folders (
+  id UUID PRIMARY KEY NOT NULL,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  position INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, name)
+);
+
+-- 同一个订阅可以放进多个文件夹，取消订阅时自动移出
+CREATE TABLE IF NOT EXISTS folder_feeds (
+  folder_id UUID NOT NULL REFERENCES folders(id) ON DELETE CASCADE,
+  feed_follow_id UUID NOT NULL REFERENCES feed_follows(id) ON DELETE CASCADE,
+  position INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (folder_id, feed_follow_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS folder_feeds;
+DROP TABLE IF EXISTS folders;
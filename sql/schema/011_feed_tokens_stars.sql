@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- 用于访问个人输出订阅（/feeds/u/{token}.rss），与 API Key 分开，可随时重置或吊销
+-- 只保存令牌的 SHA-256 哈希，明文仅在生成时返回一次
+ALTER TABLE users ADD COLUMN feed_token_hash VARCHAR(64) UNIQUE;
+
+ALTER TABLE post_states ADD COLUMN starred_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose Down
+ALTER TABLE post_states DROP COLUMN starred_at;
+ALTER TABLE users DROP COLUMN feed_token_hash;
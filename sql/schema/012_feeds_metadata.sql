@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- description/language/link 由抓取时的频道信息填充，category 由创建者指定
+ALTER TABLE feeds ADD COLUMN description TEXT;
+ALTER TABLE feeds ADD COLUMN language VARCHAR(32);
+ALTER TABLE feeds ADD COLUMN link TEXT;
+ALTER TABLE feeds ADD COLUMN category VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS feeds_language_idx ON feeds (language);
+CREATE INDEX IF NOT EXISTS feeds_category_idx ON feeds (category);
+CREATE INDEX IF NOT EXISTS posts_feed_id_published_at_idx ON posts (feed_id, published_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS posts_feed_id_published_at_idx;
+DROP INDEX IF EXISTS feeds_category_idx;
+DROP INDEX IF EXISTS feeds_language_idx;
+ALTER TABLE feeds DROP COLUMN category;
+ALTER TABLE feeds DROP COLUMN link;
+ALTER TABLE feeds DROP COLUMN language;
+ALTER TABLE feeds DROP COLUMN description;
@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- last_fetched_at 记录每次尝试抓取的时间，以下字段记录抓取结果
+ALTER TABLE feeds ADD COLUMN last_fetch_succeeded_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE feeds ADD COLUMN last_fetch_error TEXT;
+ALTER TABLE feeds ADD COLUMN fetch_error_count INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN fetch_error_count;
+ALTER TABLE feeds DROP COLUMN last_fetch_error;
+ALTER TABLE feeds DROP COLUMN last_fetch_succeeded_at;
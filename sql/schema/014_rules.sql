@@ -0,0 +1,34 @@
+-- +goose Up
+
+ALTER TABLE posts ADD COLUMN author TEXT;
+ALTER TABLE posts ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE post_states ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE post_states ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- 用户自定义的入库过滤规则：文章的某个字段匹配 pattern 时执行 action
+CREATE TABLE IF NOT EXISTS rules (
+  id UUID PRIMARY KEY NOT NULL,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  -- feed | title | content | author | category
+  field VARCHAR(16) NOT NULL,
+  -- contains（不区分大小写的子串）| regex
+  match_type VARCHAR(16) NOT NULL,
+  pattern TEXT NOT NULL,
+  -- hide | read | star | tag
+  action VARCHAR(16) NOT NULL,
+  tag VARCHAR(64),
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS rules_user_id_idx ON rules (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS rules;
+ALTER TABLE post_states DROP COLUMN tags;
+ALTER TABLE post_states DROP COLUMN hidden_at;
+ALTER TABLE posts DROP COLUMN categories;
+ALTER TABLE posts DROP COLUMN author;
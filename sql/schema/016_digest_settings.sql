@@ -0,0 +1,25 @@
+-- +goose Up
+
+-- 邮件摘要设置：frequency 为 daily | weekly，send_hour/weekday 按 timezone 解释（weekday 0 为周日，仅 weekly 使用）
+-- folder_ids 为空表示包含全部订阅
+CREATE TABLE IF NOT EXISTS digest_settings (
+  user_id UUID PRIMARY KEY NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  email VARCHAR(255) NOT NULL,
+  frequency VARCHAR(16) NOT NULL DEFAULT 'daily',
+  send_hour INT NOT NULL DEFAULT 8,
+  weekday INT NOT NULL DEFAULT 1,
+  timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+  folder_ids UUID[] NOT NULL DEFAULT '{}',
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  -- 邮件中一键退订链接使用，无需登录
+  unsubscribe_token VARCHAR(64) NOT NULL UNIQUE,
+  last_sent_at TIMESTAMP WITH TIME ZONE,
+  next_send_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS digest_settings_due_idx ON digest_settings (next_send_at) WHERE enabled;
+
+-- +goose Down
+DROP TABLE IF EXISTS digest_settings;
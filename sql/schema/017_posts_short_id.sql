@@ -0,0 +1,8 @@
+-- +goose Up
+
+-- Google Reader API 等客户端协议要求文章 ID 为 64 位整数
+ALTER TABLE posts ADD COLUMN short_id BIGSERIAL;
+ALTER TABLE posts ADD CONSTRAINT posts_short_id_key UNIQUE (short_id);
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN short_id;
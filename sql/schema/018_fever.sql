@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- Fever API 使用整数 ID 标识订阅源与分组
+ALTER TABLE feeds ADD COLUMN short_id BIGSERIAL;
+ALTER TABLE feeds ADD CONSTRAINT feeds_short_id_key UNIQUE (short_id);
+ALTER TABLE folders ADD COLUMN short_id BIGSERIAL;
+ALTER TABLE folders ADD CONSTRAINT folders_short_id_key UNIQUE (short_id);
+
+-- Fever 客户端使用 md5(username:password) 作为 api_key
+ALTER TABLE users ADD COLUMN fever_api_key TEXT UNIQUE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN fever_api_key;
+ALTER TABLE folders DROP COLUMN short_id;
+ALTER TABLE feeds DROP COLUMN short_id;
@@ -0,0 +1,16 @@
+-- +goose Up
+
+-- 订阅源之间的协同过滤相似度（共同关注），由后台任务定期重新计算
+CREATE TABLE feed_similarities (
+  feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+  similar_feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+  co_follows INTEGER NOT NULL,
+  score DOUBLE PRECISION NOT NULL,
+  computed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (feed_id, similar_feed_id)
+);
+
+CREATE INDEX feed_similarities_computed_at_idx ON feed_similarities (computed_at);
+
+-- +goose Down
+DROP TABLE feed_similarities;
@@ -0,0 +1,29 @@
+-- +goose Up
+
+-- API Key 只保存 SHA-256 哈希，明文仅在创建时返回一次
+CREATE TABLE api_keys (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  key_hash VARCHAR(64) NOT NULL UNIQUE,
+  scope VARCHAR(16) NOT NULL CHECK (scope IN ('read-only', 'read-write', 'admin')),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  last_used_at TIMESTAMP WITH TIME ZONE,
+  expires_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
+
+-- 保留已有的 API Key，迁移后仍可继续使用
+INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scope, created_at)
+SELECT gen_random_uuid(), id, 'default', left(api_key, 8), encode(sha256(api_key::bytea), 'hex'), 'admin', NOW()
+FROM users;
+
+ALTER TABLE users DROP COLUMN api_key;
+
+-- +goose Down
+ALTER TABLE users ADD COLUMN api_key VARCHAR(64) NOT NULL UNIQUE DEFAULT (
+    encode(sha256(random()::text::bytea), 'hex')
+);
+DROP TABLE api_keys;
@@ -0,0 +1,25 @@
+-- +goose Up
+
+-- 限流令牌桶，多实例共享；allowed 记录最近一次取令牌是否成功
+CREATE TABLE rate_limit_buckets (
+  key TEXT PRIMARY KEY,
+  tokens DOUBLE PRECISION NOT NULL,
+  allowed BOOLEAN NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
+
+-- 登录失败计数，连续失败达到阈值后按指数递增锁定时间
+CREATE TABLE login_failures (
+  key TEXT PRIMARY KEY,
+  failures INT NOT NULL,
+  locked_until TIMESTAMP WITH TIME ZONE,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX login_failures_updated_at_idx ON login_failures (updated_at);
+
+-- +goose Down
+DROP TABLE login_failures;
+DROP TABLE rate_limit_buckets;
@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- 用户资料，均为可选
+ALTER TABLE users ADD COLUMN display_name VARCHAR(255);
+ALTER TABLE users ADD COLUMN email VARCHAR(255);
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN email;
+ALTER TABLE users DROP COLUMN display_name;
@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- 用户角色，admin 为管理员；通过单点登录的用户可以按身份提供方的用户组同步该角色
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user';
+
+-- 外部身份：身份提供方的 issuer 和 sub 唯一确定一个用户
+-- 首次通过单点登录时自动创建本地用户，这类用户的 password 为空，不能使用密码登录
+CREATE TABLE external_identities (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  issuer TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  email VARCHAR(255),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  last_login_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  UNIQUE (issuer, subject)
+);
+
+CREATE INDEX external_identities_user_id_idx ON external_identities (user_id);
+
+-- +goose Down
+DROP TABLE external_identities;
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- 管理员停用的用户不能登录，也不能使用已有的会话、API Key、Fever 凭据和输出订阅
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN disabled_at;
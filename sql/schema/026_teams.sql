@@ -0,0 +1,56 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS teams (
+  id UUID PRIMARY KEY NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- owner 管理成员和团队，editor 管理团队订阅和文件夹，viewer 只能阅读
+CREATE TABLE IF NOT EXISTS team_members (
+  team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role VARCHAR(16) NOT NULL DEFAULT 'viewer',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS team_members_user_id_idx ON team_members (user_id);
+
+-- 团队订阅由所有成员共享，阅读状态仍记录在每个成员自己的 post_states 中
+CREATE TABLE IF NOT EXISTS team_follows (
+  id UUID PRIMARY KEY NOT NULL,
+  team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+  feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (team_id, feed_id)
+);
+
+CREATE TABLE IF NOT EXISTS team_folders (
+  id UUID PRIMARY KEY NOT NULL,
+  team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  position INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (team_id, name)
+);
+
+-- 与个人文件夹相同，同一个团队订阅可以放进多个文件夹，取消订阅时自动移出
+CREATE TABLE IF NOT EXISTS team_folder_feeds (
+  folder_id UUID NOT NULL REFERENCES team_folders(id) ON DELETE CASCADE,
+  team_follow_id UUID NOT NULL REFERENCES team_follows(id) ON DELETE CASCADE,
+  position INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (folder_id, team_follow_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS team_folder_feeds;
+DROP TABLE IF EXISTS team_folders;
+DROP TABLE IF EXISTS team_follows;
+DROP INDEX IF EXISTS team_members_user_id_idx;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
@@ -0,0 +1,41 @@
+-- +goose Up
+
+-- 审计日志只能追加；actor_id 和 target_id 不设外键，账号或订阅源删除后记录仍然保留
+-- actor_username 保存操作时的用户名，便于账号删除后查看
+CREATE TABLE IF NOT EXISTS audit_events (
+  id UUID PRIMARY KEY NOT NULL,
+  actor_id UUID,
+  actor_username VARCHAR(255),
+  action VARCHAR(64) NOT NULL,
+  target_type VARCHAR(32),
+  target_id UUID,
+  details JSONB NOT NULL DEFAULT '{}',
+  ip VARCHAR(64) NOT NULL DEFAULT '',
+  user_agent TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events (target_type, target_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_action_idx ON audit_events (action, created_at DESC);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_events_no_update_delete
+  BEFORE UPDATE OR DELETE ON audit_events
+  FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+  BEFORE TRUNCATE ON audit_events
+  FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
@@ -0,0 +1,41 @@
+-- +goose Up
+
+-- 邀请码只保存 SHA-256 哈希，明文只在创建时返回一次；uses 达到 max_uses、过期或吊销后不能再使用
+CREATE TABLE IF NOT EXISTS invites (
+  id UUID PRIMARY KEY NOT NULL,
+  code_hash VARCHAR(64) NOT NULL UNIQUE,
+  prefix VARCHAR(16) NOT NULL,
+  note VARCHAR(255) NOT NULL DEFAULT '',
+  max_uses INTEGER NOT NULL DEFAULT 1,
+  uses INTEGER NOT NULL DEFAULT 0,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMP WITH TIME ZONE,
+  revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+ALTER TABLE users ADD COLUMN invite_id UUID REFERENCES invites(id) ON DELETE SET NULL;
+
+-- 按角色的默认配额，NULL 表示不限制
+CREATE TABLE IF NOT EXISTS role_quotas (
+  role VARCHAR(16) PRIMARY KEY NOT NULL,
+  max_feeds INTEGER,
+  max_follows INTEGER,
+  max_webhooks INTEGER,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO role_quotas (role) VALUES ('user'), ('admin') ON CONFLICT DO NOTHING;
+
+-- 单个用户的配额，NULL 时使用角色的默认配额
+ALTER TABLE users ADD COLUMN max_feeds INTEGER;
+ALTER TABLE users ADD COLUMN max_follows INTEGER;
+ALTER TABLE users ADD COLUMN max_webhooks INTEGER;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN max_webhooks;
+ALTER TABLE users DROP COLUMN max_follows;
+ALTER TABLE users DROP COLUMN max_feeds;
+DROP TABLE IF EXISTS role_quotas;
+ALTER TABLE users DROP COLUMN invite_id;
+DROP TABLE IF EXISTS invites;
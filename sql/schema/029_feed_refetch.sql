@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- 管理员排队重新抓取的时间，抓取器优先处理，抓取后清空；不再通过清空 last_fetched_at 排队，以免丢失健康状态
+ALTER TABLE feeds ADD COLUMN refetch_requested_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN refetch_requested_at;
@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- 用户可以阅读的订阅源：个人订阅加上所在团队的订阅，同一订阅源只出现一次，created_at 取最早的关注时间
+CREATE VIEW user_feeds AS
+SELECT user_id, feed_id, MIN(created_at) AS created_at FROM (
+  SELECT ff.user_id, ff.feed_id, ff.created_at FROM feed_follows ff
+  UNION ALL
+  SELECT tm.user_id, tf.feed_id, GREATEST(tf.created_at, tm.created_at) FROM team_follows tf
+  JOIN team_members tm ON tm.team_id = tf.team_id
+) follows
+GROUP BY user_id, feed_id;
+
+-- +goose Down
+DROP VIEW IF EXISTS user_feeds;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- 待接受的订阅源转让：接收人接受后所有权才会变更，每个订阅源只保留最近一次请求
+-- 发起人不再是所有者时请求自动失效
+CREATE TABLE feed_transfers (
+  feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+  from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX feed_transfers_to_user_id_idx ON feed_transfers (to_user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS feed_transfers;
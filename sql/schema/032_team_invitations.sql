@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- 待接受的团队邀请：被邀请人接受后才会成为成员，团队订阅才会合并进其个人视图
+-- 同一团队对同一用户只保留最近一次邀请
+CREATE TABLE team_invitations (
+  team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role VARCHAR(16) NOT NULL,
+  invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX team_invitations_user_id_idx ON team_invitations (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS team_invitations;
@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/lib/pq"
+)
+
+// Channel 是新文章通知使用的 Postgres NOTIFY 频道
+const Channel = "new_posts"
+
+// Publish 在文章入库并应用规则后通知所有实例，payload 为文章的 short_id
+func Publish(ctx context.Context, query *db.Queries, post db.Post) {
+	if err := query.NotifyNewPost(ctx, strconv.FormatInt(post.ShortID, 10)); err != nil {
+		log.Printf("Error publishing post %s: %v\n", post.ID, err)
+	}
+}
+
+// Hub 通过 LISTEN 接收新文章通知，并唤醒本实例上的所有订阅者
+// 通知只是唤醒信号，订阅者自行按 short_id 查询错过的文章，因此多次通知可以合并
+type Hub struct {
+	listener *pq.Listener
+	mu       sync.Mutex
+	subs     map[chan struct{}]struct{}
+}
+
+// NewHub 建立独立的监听连接，断线后由 pq.Listener 自动重连
+func NewHub(dbURL string) (*Hub, error) {
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("Stream listener error:", err)
+		}
+	})
+	if err := listener.Listen(Channel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	h := &Hub{
+		listener: listener,
+		subs:     make(map[chan struct{}]struct{}),
+	}
+	go h.run()
+	return h, nil
+}
+
+func (h *Hub) run() {
+	for {
+		select {
+		case _, ok := <-h.listener.Notify:
+			if !ok {
+				return
+			}
+			// 收到 nil 表示连接已重建，期间的通知可能丢失，同样唤醒订阅者补查
+			h.broadcast()
+		case <-time.After(90 * time.Second):
+			go h.listener.Ping()
+		}
+	}
+}
+
+// Subscribe 返回一个唤醒信号通道和取消订阅函数
+func (h *Hub) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+func (h *Hub) broadcast() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		// 订阅者尚未处理上一次唤醒时直接跳过，等价于合并通知
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress 表示目标地址属于本机、内网或其他保留网段，不能作为 webhook 地址
+var ErrForbiddenAddress = errors.New("webhook address is not publicly routable")
+
+// nat64Prefix 是 NAT64 的知名前缀，末尾 32 位嵌入 IPv4 地址
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// forbiddenPrefixes 是 IsPrivate、IsLoopback、IsLinkLocalUnicast 等方法之外还需要拒绝的保留网段
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"),  // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF 协议分配
+	netip.MustParsePrefix("198.18.0.0/15"),  // 网络基准测试
+	netip.MustParsePrefix("240.0.0.0/4"),    // 保留
+	netip.MustParsePrefix("64:ff9b:1::/48"), // 本地 NAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // 文档
+	netip.MustParsePrefix("100::/64"),       // 丢弃
+	netip.MustParsePrefix("2001::/23"),      // IETF 协议分配
+	netip.MustParsePrefix("fec0::/10"),      // 已废弃的站点本地地址
+}
+
+// IsPublicAddr 判断地址能否作为 webhook 目标：拒绝回环、内网（RFC 1918、fc00::/7）、
+// 链路本地（含 169.254.169.254 等云厂商元数据服务）、组播和保留网段
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if nat64Prefix.Contains(addr) {
+		b := addr.As16()
+		addr = netip.AddrFrom4([4]byte(b[12:]))
+	}
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialControl 在建立连接前检查解析后的实际地址，域名在创建后改为解析到内网（DNS rebinding）时同样会被拒绝
+func dialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !IsPublicAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	return nil
+}
+
+// newTransport 返回只能连接公网地址的 Transport，不使用环境变量中的代理，以免绕过地址检查
+func newTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: dialControl,
+	}
+	return &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        20,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// ValidateURL 在创建 webhook 时校验地址：必须是 http(s) 绝对地址，且主机解析出的所有地址都是公网地址
+// 这只是提前给出明确的错误，投递时仍由 dialControl 检查实际连接的地址
+func ValidateURL(ctx context.Context, rawURL string) (*url.URL, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, errors.New("webhook url must be an absolute http(s) url")
+	}
+	host := target.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !IsPublicAddr(addr) {
+			return nil, ErrForbiddenAddress
+		}
+		return target, nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve webhook host %q", host)
+	}
+	for _, addr := range addrs {
+		if !IsPublicAddr(addr) {
+			return nil, ErrForbiddenAddress
+		}
+	}
+	return target, nil
+}
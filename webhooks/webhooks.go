@@ -0,0 +1,94 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strconv"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/djchanahcjd/go-rss/rules"
+	"github.com/google/uuid"
+)
+
+// 投递状态
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// 签名相关的请求头
+const (
+	HeaderWebhookID = "X-Webhook-ID"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign 计算签名：HMAC-SHA256(secret, timestamp + "." + body)，格式为 sha256=<hex>
+// 接收方应校验签名并拒绝时间戳过旧的请求以防重放
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Target 是某个订阅源的新文章需要推送到的 webhook
+type Target struct {
+	WebhookID uuid.UUID
+	// Rule 不为空时只推送命中该规则的文章
+	Rule *rules.Rule
+}
+
+// TargetsForFeed 加载订阅源的推送目标，规则过滤无法加载时跳过该 webhook
+func TargetsForFeed(ctx context.Context, query *db.Queries, feedID uuid.UUID) []Target {
+	hooks, err := query.GetWebhooksForFeed(ctx, feedID)
+	if err != nil {
+		log.Println("Error loading webhooks:", err)
+		return nil
+	}
+	targets := make([]Target, 0, len(hooks))
+	for _, hook := range hooks {
+		target := Target{WebhookID: hook.ID}
+		if hook.RuleID.Valid {
+			r, err := query.GetRuleByID(ctx, db.GetRuleByIDParams{
+				ID:     hook.RuleID.UUID,
+				UserID: hook.UserID,
+			})
+			if err != nil {
+				log.Printf("Skipping webhook %s: error loading rule: %v\n", hook.ID, err)
+				continue
+			}
+			rule, err := rules.FromDB(r)
+			if err != nil {
+				log.Printf("Skipping webhook %s: %v\n", hook.ID, err)
+				continue
+			}
+			target.Rule = &rule
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// Enqueue 为新入库的文章创建投递任务，由投递进程异步发送
+func Enqueue(ctx context.Context, query *db.Queries, targets []Target, postID uuid.UUID, post rules.Post) {
+	for _, target := range targets {
+		if target.Rule != nil && !target.Rule.Match(post) {
+			continue
+		}
+		err := query.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			ID:        uuid.New(),
+			WebhookID: target.WebhookID,
+			PostID:    postID,
+		})
+		if err != nil {
+			log.Printf("Error enqueueing webhook delivery: %v\n", err)
+		}
+	}
+}
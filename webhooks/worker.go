@@ -0,0 +1,192 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/djchanahcjd/go-rss/internal/db"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxAttempts 连续失败达到该次数后进入 dead 状态
+	MaxAttempts = 8
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+var httpClient = http.Client{
+	Timeout: 10 * time.Second,
+	// 只连接公网地址，见 dialControl
+	Transport: newTransport(),
+	// 不跟随重定向，避免把签名请求转发到其他地址（包括内网地址）
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Payload 是推送给 webhook 的请求体
+type Payload struct {
+	Event      string      `json:"event"`
+	DeliveryID uuid.UUID   `json:"delivery_id"`
+	WebhookID  uuid.UUID   `json:"webhook_id"`
+	Feed       PayloadFeed `json:"feed"`
+	Post       PayloadPost `json:"post"`
+}
+
+type PayloadFeed struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	URL  string    `json:"url"`
+}
+
+type PayloadPost struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Categories  []string  `json:"categories"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// StartDelivering 启动定时投递任务
+// 参数：
+//   - query: 数据库查询接口
+//   - concurrency: 每轮并发投递数量
+//   - interval: 轮询间隔
+func StartDelivering(query *db.Queries, concurrency int, interval time.Duration) {
+	log.Printf("Delivering webhooks on %v goroutines every %s duration", concurrency, interval)
+	ticker := time.NewTicker(interval)
+	for ; ; <-ticker.C {
+		for {
+			ids, err := query.ClaimDueWebhookDeliveries(context.Background(), int64(concurrency))
+			if err != nil {
+				log.Println("Error claiming webhook deliveries:", err)
+				break
+			}
+			wg := &sync.WaitGroup{}
+			for _, id := range ids {
+				wg.Add(1)
+				go deliver(wg, query, id)
+			}
+			wg.Wait()
+			if len(ids) < concurrency {
+				break
+			}
+		}
+	}
+}
+
+// deliver 发送一次投递并记录结果，失败时按指数退避安排重试
+func deliver(wg *sync.WaitGroup, query *db.Queries, id uuid.UUID) {
+	defer wg.Done()
+	row, err := query.GetWebhookDeliveryPayload(context.Background(), id)
+	if err != nil {
+		log.Printf("Error loading webhook delivery %s: %v\n", id, err)
+		return
+	}
+
+	statusCode, err := send(row)
+	if err == nil {
+		err = query.MarkWebhookDeliverySucceeded(context.Background(), db.MarkWebhookDeliverySucceededParams{
+			ID:             id,
+			LastStatusCode: sql.NullInt32{Int32: int32(statusCode), Valid: true},
+		})
+		if err != nil {
+			log.Println("Error marking webhook delivery succeeded:", err)
+		}
+		return
+	}
+
+	attempts := int(row.Attempts) + 1
+	status := StatusPending
+	if attempts >= MaxAttempts {
+		status = StatusDead
+		log.Printf("==> ☠️ Webhook delivery %s dead after %d attempts: %v", id, attempts, err)
+	}
+	err = query.MarkWebhookDeliveryFailed(context.Background(), db.MarkWebhookDeliveryFailedParams{
+		ID:             id,
+		Status:         status,
+		LastStatusCode: sql.NullInt32{Int32: int32(statusCode), Valid: statusCode != 0},
+		LastError:      sql.NullString{String: err.Error(), Valid: true},
+		NextAttemptAt:  time.Now().UTC().Add(Backoff(attempts)),
+	})
+	if err != nil {
+		log.Println("Error marking webhook delivery failed:", err)
+	}
+}
+
+// send 签名并发送请求，2xx 视为成功，返回响应状态码（网络错误时为 0）
+func send(row db.GetWebhookDeliveryPayloadRow) (int, error) {
+	categories := row.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+	body, err := json.Marshal(Payload{
+		Event:      "post.created",
+		DeliveryID: row.ID,
+		WebhookID:  row.WebhookID,
+		Feed: PayloadFeed{
+			ID:   row.FeedID,
+			Name: row.FeedName,
+			URL:  row.FeedUrl,
+		},
+		Post: PayloadPost{
+			ID:          row.PostID,
+			Title:       row.Title,
+			URL:         row.PostUrl,
+			Description: row.Description.String,
+			Author:      row.Author.String,
+			Categories:  categories,
+			PublishedAt: row.PublishedAt,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, row.WebhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-rss-webhooks/1.0")
+	req.Header.Set(HeaderWebhookID, row.WebhookID.String())
+	req.Header.Set(HeaderDelivery, row.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(row.Secret, timestamp, body))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff 第 attempts 次失败后的重试间隔：30s、1m、2m……最长 6 小时
+func Backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}